@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/command/cmdbuild"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/command/cmdclear"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/command/cmdsearch"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/command/cmdstats"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/command/cmdsync"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/store/log"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/store/uaivectordbx"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
@@ -22,13 +25,28 @@ func main() {
 	}
 	opt.UiRv.LoadMdFilePloy.IsLoadMdFiles = false
 	ctx := context.Background()
-	uaivectordbx.NewUAiVectorDb(ctx, opt.UiRv, log.SysLog())
 	app := &cli.App{
 		Name:  "fmc-go-agent-cli",
 		Usage: "fmc-go-agent 命令行工具",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "reindex",
+				Usage: "嵌入模型或向量维度与已有索引不一致时重建索引,之后需执行sync重新索引知识库文件",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			if c.Bool("reindex") {
+				opt.UiRv.IRVModel.Reindex = true
+			}
+			uaivectordbx.NewUAiVectorDb(ctx, opt.UiRv, log.SysLog())
+			return nil
+		},
 		Commands: []*cli.Command{
 			cmdbuild.Handler,
 			cmdclear.Handler,
+			cmdsync.Handler,
+			cmdsearch.Handler,
+			cmdstats.Handler,
 		},
 	}
 	err = app.Run(os.Args)
@@ -4,6 +4,7 @@ go 1.24.4
 
 require (
 	github.com/apache/rocketmq-client-go/v2 v2.1.2
+	github.com/cloudwego/eino-ext/libs/acl/openai v0.0.0-20250626133421-3c142631c961
 	github.com/denisenkom/go-mssqldb v0.12.3
 	github.com/eclipse/paho.mqtt.golang v1.5.0
 	github.com/fsnotify/fsnotify v1.9.0
@@ -14,6 +15,7 @@ require (
 	github.com/golang-jwt/jwt/v5 v5.2.3
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/websocket v1.5.3
+	github.com/ollama/ollama v0.6.5
 	github.com/robfig/cron/v3 v3.0.2-0.20210106135023-bc59245fe10e
 	github.com/spf13/viper v1.20.1
 	github.com/zwgblue/yaml-encoder v0.0.0-20221226083717-a0bdbda0d998
@@ -30,7 +32,6 @@ require (
 	github.com/cloudwego/eino-ext/components/model/deepseek v0.0.0-20250716114210-6b285e194382
 	github.com/cloudwego/eino-ext/components/model/ollama v0.0.0-20250626134119-cf4f96ea0039
 	github.com/cloudwego/eino-ext/components/model/openai v0.0.0-20250626134119-cf4f96ea0039
-	github.com/cloudwego/eino-ext/components/retriever/redis v0.0.0-20250703162323-8553b6952bf3
 	github.com/cloudwego/eino-ext/components/tool/duckduckgo v0.0.0-20250703162323-8553b6952bf3
 	github.com/go-kit/log v0.2.0
 	github.com/golang/snappy v1.0.0
@@ -75,10 +76,11 @@ require (
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/cloudwego/base64x v0.1.5 // indirect
 	github.com/cloudwego/eino-ext/components/document/loader/file v0.0.0-20250626134119-cf4f96ea0039
+	github.com/cloudwego/eino-ext/components/document/parser/html v0.0.0-20241224063832-9fbcc0e56c28
+	github.com/cloudwego/eino-ext/components/document/parser/pdf v0.0.0-20250605072634-0f875e04269d
 	github.com/cloudwego/eino-ext/components/document/transformer/splitter/markdown v0.0.0-20250626134119-cf4f96ea0039
+	github.com/cloudwego/eino-ext/components/document/transformer/splitter/recursive v0.0.0-20251011073417-75b93b87b8a9
 	github.com/cloudwego/eino-ext/components/embedding/openai v0.0.0-20250626134119-cf4f96ea0039
-	github.com/cloudwego/eino-ext/components/indexer/redis v0.0.0-20250626134119-cf4f96ea0039
-	github.com/cloudwego/eino-ext/libs/acl/openai v0.0.0-20250626133421-3c142631c961 // indirect
 	github.com/cohesion-org/deepseek-go v1.3.2 // indirect
 	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
@@ -123,7 +125,6 @@ require (
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826 // indirect
 	github.com/nikolalohinski/gonja v1.5.3 // indirect
-	github.com/ollama/ollama v0.6.5 // indirect
 	github.com/patrickmn/go-cache v2.1.0+incompatible // indirect
 	github.com/pelletier/go-toml/v2 v2.2.3 // indirect
 	github.com/perimeterx/marshmallow v1.1.5 // indirect
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/store/log"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/store/uaivectordbx"
+	"github.com/urfave/cli/v2"
 	"io/fs"
 	"os"
 	"path"
@@ -48,7 +49,7 @@ func buildHandler(c *cli.Context) error {
 
 	db := uaivectordbx.UAiVectorDb()
 	if isClear {
-		err := db.Redis().FlushDB(c.Context).Err()
+		err := db.Store().Flush(c.Context)
 		if err != nil {
 			return err
 		}
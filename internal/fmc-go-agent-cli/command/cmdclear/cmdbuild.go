@@ -3,20 +3,38 @@ package cmdclear
 import (
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/store/log"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/store/uaivectordbx"
+	"github.com/urfave/cli/v2"
 )
 
 var Handler = &cli.Command{
 	Name:    "clear",
 	Usage:   "构建知识库",
 	Aliases: []string{"c"},
-	Flags:   []cli.Flag{},
-	Action:  clearHandler,
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:    "file",
+			Aliases: []string{"f"},
+			Usage:   "只删除指定文件的分片,不指定时清空整个向量数据库",
+		},
+	},
+	Action: clearHandler,
 }
 
 func clearHandler(c *cli.Context) error {
 	db := uaivectordbx.UAiVectorDb()
+	if files := c.StringSlice("file"); len(files) > 0 {
+		for _, f := range files {
+			n, err := db.DeleteFile(c.Context, f)
+			if err != nil {
+				log.SysLog().Infof("删除文件 %s 的分片异常：%s", f, err.Error())
+				return err
+			}
+			log.SysLog().Infof("删除文件 %s 的分片,数量: %d", f, n)
+		}
+		return nil
+	}
 	log.SysLog().Infof("开始清理知识库")
-	err := db.Redis().FlushDB(c.Context).Err()
+	err := db.Store().Flush(c.Context)
 	if err != nil {
 		log.SysLog().Infof("清理知识库异常：%s", err.Error())
 		return err
@@ -0,0 +1,118 @@
+package cmdsearch
+
+import (
+	"fmt"
+	"github.com/cloudwego/eino-ext/components/document/loader/file"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/store/log"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/store/uaivectordbx"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaivectordb"
+	"github.com/urfave/cli/v2"
+	"sort"
+	"strings"
+)
+
+var Handler = &cli.Command{
+	Name:      "search",
+	Usage:     "检索知识库,输出命中分片的相似度、来源与内容,用于排查回答质量问题",
+	Aliases:   []string{"q"},
+	ArgsUsage: "<检索文本>",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:    "collection",
+			Aliases: []string{"c"},
+			Usage:   "限定检索的集合,可指定多个,为空时检索所有集合",
+		},
+		&cli.IntFlag{
+			Name:    "topK",
+			Aliases: []string{"k"},
+			Usage:   "返回的分片数量",
+			Value:   uaivectordb.DefaultSearchTopK,
+		},
+		&cli.StringFlag{
+			Name:    "fileType",
+			Aliases: []string{"t"},
+			Usage:   "限定文件类型,如 md、pdf",
+		},
+		&cli.StringFlag{
+			Name:  "header",
+			Usage: "限定标题路径,如 标题 > 章",
+		},
+		&cli.StringFlag{
+			Name:    "userID",
+			Aliases: []string{"u"},
+			Usage:   "只检索公开分片与该用户私有集合的分片,为空时不限",
+		},
+		&cli.BoolFlag{
+			Name:    "full",
+			Aliases: []string{"f"},
+			Usage:   "输出完整的分片内容与元数据",
+			Value:   false,
+		},
+	},
+	Action: searchHandler,
+}
+
+func searchHandler(c *cli.Context) error {
+	query := strings.TrimSpace(strings.Join(c.Args().Slice(), " "))
+	if query == "" {
+		return fmt.Errorf("query is required")
+	}
+	filter := uaivectordb.Filter{}
+	if cs := c.StringSlice("collection"); len(cs) > 0 {
+		filter[uaivectordb.CollectionField] = cs
+	}
+	if t := c.String("fileType"); t != "" {
+		filter[uaivectordb.FileTypeField] = []string{strings.ToLower(strings.TrimPrefix(t, "."))}
+	}
+	if h := c.String("header"); h != "" {
+		filter[uaivectordb.HeaderField] = []string{h}
+	}
+	if u := c.String("userID"); u != "" {
+		filter[uaivectordb.OwnerField] = []string{uaivectordb.PublicOwner, u}
+	}
+
+	db := uaivectordbx.UAiVectorDb()
+	hits, err := db.Search(c.Context, query, filter, c.Int("topK"))
+	if err != nil {
+		log.SysLog().Errorf("检索知识库异常：%s", err.Error())
+		return err
+	}
+	if len(hits) == 0 {
+		fmt.Println("没有命中的分片")
+		return nil
+	}
+	full := c.Bool("full")
+	for i, h := range hits {
+		fmt.Printf("[%d] score=%.4f id=%s collection=%s\n", i+1, h.Score, h.Chunk.ID, h.Chunk.Tags[uaivectordb.CollectionField])
+		fmt.Printf("    来源: %v", h.Chunk.MetaData[file.MetaKeySource])
+		if header := h.Chunk.Tags[uaivectordb.HeaderField]; header != "" {
+			fmt.Printf(" > %s", header)
+		}
+		fmt.Println()
+		content := h.Chunk.Content
+		if !full {
+			content = abbreviate(content, 200)
+		}
+		fmt.Printf("    %s\n", strings.ReplaceAll(content, "\n", "\n    "))
+		if full {
+			keys := make([]string, 0, len(h.Chunk.MetaData))
+			for k := range h.Chunk.MetaData {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Printf("    - %s: %v\n", k, h.Chunk.MetaData[k])
+			}
+		}
+	}
+	return nil
+}
+
+// abbreviate 截取内容的前n个字符
+func abbreviate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
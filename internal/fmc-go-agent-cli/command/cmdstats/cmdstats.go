@@ -0,0 +1,40 @@
+package cmdstats
+
+import (
+	"fmt"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/store/log"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/store/uaivectordbx"
+	"github.com/urfave/cli/v2"
+)
+
+var Handler = &cli.Command{
+	Name:    "stats",
+	Usage:   "查看知识库统计信息:文件与分片数量、嵌入模型与向量索引大小",
+	Aliases: []string{"st"},
+	Action:  statsHandler,
+}
+
+func statsHandler(c *cli.Context) error {
+	db := uaivectordbx.UAiVectorDb()
+	st, err := db.Stats(c.Context)
+	if err != nil {
+		log.SysLog().Errorf("获取知识库统计信息异常：%s", err.Error())
+		return err
+	}
+	fmt.Printf("文件: %d\n分片: %d\n", st.Files, st.Chunks)
+	if e := st.Embedding; e != nil {
+		fmt.Printf("嵌入模型: %s/%s,维度 %d\n", e.Provider, e.Model, e.Dimension)
+	}
+	if s := st.Store; s != nil {
+		fmt.Printf("向量存储: %s,索引分片 %d,维度 %d,索引大小 %.2fMB,向量大小 %.2fMB,索引失败 %d,索引中 %v\n",
+			s.Backend, s.Chunks, s.Dimension, s.IndexSizeMB, s.VectorSizeMB, s.Failures, s.Indexing)
+		if s.Chunks != st.Chunks {
+			fmt.Printf("注意: 索引分片数量与文件清单记录的分片数量不一致,可执行sync重新同步\n")
+		}
+	}
+	fmt.Println("集合:")
+	for _, v := range st.Collections {
+		fmt.Printf("  %s: 文件 %d,分片 %d\n", v.Name, v.Files, v.Chunks)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package cmdsync
+
+import (
+	"fmt"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/store/log"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-cli/store/uaivectordbx"
+	"github.com/urfave/cli/v2"
+)
+
+var Handler = &cli.Command{
+	Name:    "sync",
+	Usage:   "增量同步知识库,只索引新增或变化的文件,删除已移除文件的分片",
+	Aliases: []string{"s"},
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "dir",
+			Aliases: []string{"d"},
+			Usage:   "指定需要同步的文档路径",
+			Value:   "./knowdb",
+		},
+		&cli.BoolFlag{
+			Name:    "dryRun",
+			Aliases: []string{"dr"},
+			Usage:   "只报告将要发生的变化,不写入向量数据库",
+			Value:   false,
+		},
+	},
+	Action: syncHandler,
+}
+
+func syncHandler(c *cli.Context) error {
+	dir := c.String("dir")
+	if dir == "" {
+		return fmt.Errorf("dir is required")
+	}
+	dryRun := c.Bool("dryRun")
+
+	db := uaivectordbx.UAiVectorDb()
+	log.SysLog().Infof("开始同步目录 %s,dryRun: %v", dir, dryRun)
+	report, err := db.Sync(c.Context, dir, dryRun)
+	if report != nil {
+		for _, f := range report.Added {
+			log.SysLog().Infof("新增: %s", f)
+		}
+		for _, f := range report.Changed {
+			log.SysLog().Infof("变化: %s", f)
+		}
+		for _, f := range report.Removed {
+			log.SysLog().Infof("移除: %s", f)
+		}
+		for f, e := range report.Failed {
+			log.SysLog().Errorf("失败: %s,%s", f, e)
+		}
+		log.SysLog().Infof("同步结束,新增 %d,变化 %d,移除 %d,未变化 %d,跳过 %d,失败 %d,写入分片 %d",
+			len(report.Added), len(report.Changed), len(report.Removed), report.Unchanged, report.Skipped, len(report.Failed), report.Chunks)
+	}
+	if err != nil {
+		log.SysLog().Errorf("同步知识库异常：%s", err.Error())
+		return err
+	}
+	if len(report.Failed) > 0 {
+		return fmt.Errorf("%d files failed to sync", len(report.Failed))
+	}
+	return nil
+}
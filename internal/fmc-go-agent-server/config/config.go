@@ -1,7 +1,10 @@
 package config
 
 import (
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
 	"github.com/freedqo/fmc-go-agent/pkg/httpclient"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaiagent"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaiagent/mem"
 	"github.com/freedqo/fmc-go-agent/pkg/uai/uaicharmodel"
 	"github.com/freedqo/fmc-go-agent/pkg/uai/uaimcp"
 	"github.com/freedqo/fmc-go-agent/pkg/uai/uaivectordb"
@@ -23,32 +26,44 @@ func NewDefault() *Config {
 			TcpPort:      7588,
 			LastExitTime: "",
 		},
-		Log: uzlog.NewDefaultOption(),
-		Db:  ugrom.NewDefaultOption(),
-		Ext: newExtOption(),
+		Log:  uzlog.NewDefaultOption(),
+		Db:   ugrom.NewDefaultOption(),
+		Ext:  newExtOption(),
+		Auth: newAuthOption(),
 		UCM: &uaicharmodel.Option{
-			APIKey:       "",
-			BaseURL:      "http://192.168.53.217:11434",
-			Organization: "fmc",
-			Provider:     "ollama",
-			Model:        "deepseek-r1:7b",
-			Timeout:      120,
+			APIKey:        "",
+			BaseURL:       "http://192.168.53.217:11434",
+			Organization:  "fmc",
+			Provider:      "ollama",
+			Model:         "deepseek-r1:7b",
+			Timeout:       120,
+			ContextWindow: 8192,
 		},
+		Resilient: uaicharmodel.NewDefaultResilientOption(),
+		Mem:       mem.NewDefaultBudgetOption(),
+		Session:   newSessionOption(),
 		UiRv:      uaivectordb.NewOption(),
+		Agent:     uaiagent.NewDefaultOption(),
 		McpServer: uaimcp.NewDefaultOption(),
 		Msg:       newMsgOption(),
 	}
 }
 
 type Config struct {
-	Base      *BaseOption          `comment:"基础配置"`
-	Log       *uzlog.Option        `comment:"日志配置"`
-	Db        *ugrom.Option        `comment:"数据库配置"`
-	Ext       *ExtOption           `comment:"外部服务配置"`
-	UCM       *uaicharmodel.Option `comment:"UChatModel配置,用于连接大模型"`
-	UiRv      *uaivectordb.Option  `comment:"UAIIRVector配置,用于意图识别的向量检索"`
-	McpServer *uaimcp.Option       `comment:"MCP服务配置(MCP服务)"`
-	Msg       *MsgOption           `comment:"消息配置"`
+	Base      *BaseOption                     `comment:"基础配置"`
+	Log       *uzlog.Option                   `comment:"日志配置"`
+	Db        *ugrom.Option                   `comment:"数据库配置"`
+	Ext       *ExtOption                      `comment:"外部服务配置"`
+	Auth      *AuthOption                     `comment:"认证配置"`
+	UCM       *uaicharmodel.Option            `comment:"UChatModel配置,用于连接大模型,为默认模型,以其模型名称登记到模型目录"`
+	Models    map[string]*uaicharmodel.Option `comment:"其他命名模型(名称:模型配置),请求的model字段或提示词模板按名称选择"`
+	Resilient *uaicharmodel.ResilientOption   `comment:"模型调用的重试、故障转移与熔断配置"`
+	UiRv      *uaivectordb.Option             `comment:"UAIIRVector配置,用于意图识别的向量检索"`
+	Agent     *uaiagent.Option                `comment:"代理配置,知识库混合检索(全文+向量)与重排序"`
+	McpServer *uaimcp.Option                  `comment:"MCP服务配置(MCP服务)"`
+	Msg       *MsgOption                      `comment:"消息配置"`
+	Mem       *mem.BudgetOption               `comment:"对话记忆配置,提示词模板的记忆策略为summary时按token预算加载历史"`
+	Session   *SessionOption                  `comment:"会话配置"`
 }
 
 type BaseOption struct {
@@ -61,17 +76,36 @@ type BaseOption struct {
 type ExtOption struct {
 	UserCenter     *httpclient.Option         `comment:"用户中心配置"`
 	McpServer      *map[string]*uaimcp.Option `comment:"MCP客户配置(MCP客户)"`
+	McpToolsReload int                        `comment:"MCP工具列表定时刷新间隔,单位秒,0为不定时刷新(MCP服务通知工具变更时仍会刷新)"`
 	UserCenterRoot *UserCenterRoot            `comment:"用户中心管理员配置"`
+	UserCacheTTL   int                        `comment:"用户中心令牌解析的登录用户缓存时间,单位秒,0为不缓存"`
+	Rbac           *RbacOption                `comment:"权限配置,管理操作需要的权限由用户中心的权限编码与角色授予"`
 }
 type UserCenterRoot struct {
 	Username string `comment:"用户中心管理员用户名"`
 	Password string `comment:"用户中心管理员密码,sm3加密后密码字符串"`
 }
 
+// RbacOption 权限配置,权限编码见authm,如 knowdb:admin、prompt:admin,*为所有权限
+type RbacOption struct {
+	Enable  bool                `comment:"是否启用权限校验,关闭时登录用户拥有所有权限"`
+	Admins  []string            `comment:"拥有所有权限的用户中心账号"`
+	Roles   map[string][]string `comment:"用户中心角色名称对应的权限(角色名称:权限编码列表)"`
+	Default []string            `comment:"所有登录用户拥有的权限"`
+}
+
 func newExtOption() *ExtOption {
 	return &ExtOption{
-		UserCenter: httpclient.NewDefaultOption(),
-		McpServer:  nil,
+		UserCenter:     httpclient.NewDefaultOption(),
+		McpServer:      nil,
+		McpToolsReload: 300,
+		UserCacheTTL:   60,
+		Rbac: &RbacOption{
+			Enable:  true,
+			Admins:  []string{"admin"},
+			Roles:   map[string][]string{},
+			Default: []string{authm.PermKnowDbWrite},
+		},
 		UserCenterRoot: &UserCenterRoot{
 			Username: "admin",
 			Password: "sm3加密后字符串",
@@ -79,6 +113,74 @@ func newExtOption() *ExtOption {
 	}
 }
 
+// 认证方式
+const (
+	AuthApiKey     = "apikey"     // API密钥
+	AuthJwt        = "jwt"        // 本地签发的JWT
+	AuthOidc       = "oidc"       // OIDC身份提供方签发的JWT
+	AuthUserCenter = "usercenter" // 用户中心令牌
+)
+
+type AuthOption struct {
+	Chain   []string        `comment:"认证方式,按顺序尝试:apikey、jwt、oidc、usercenter;凭证不属于一种方式时交给下一种,用户中心令牌无法按格式识别,应放在最后"`
+	Jwt     *JwtOption      `comment:"本地签发的JWT,请求头 Authorization: Bearer <令牌>,令牌由 /auth/token 签发"`
+	ApiKeys []*ApiKeyOption `comment:"API密钥,供服务间调用OpenAI兼容接口,请求头 Authorization: Bearer sk-..."`
+	Oidc    *OidcOption     `comment:"OIDC认证,按身份提供方的JWKS校验令牌签名"`
+}
+
+type JwtOption struct {
+	Issuer string `comment:"签发者,令牌的iss与之一致时由本地JWT认证"`
+	Secret string `comment:"签名密钥,为空时不启用"`
+	Expire int    `comment:"签发令牌的最长有效时长,单位分钟"`
+}
+
+type ApiKeyOption struct {
+	Name   string   `comment:"密钥名称,作为调用方的登录账号"`
+	Key    string   `comment:"密钥,以sk-开头"`
+	UserID string   `comment:"调用方的用户ID,会话与聊天记录归属该用户,为空时为 apikey:密钥名称"`
+	Roles  []string `comment:"角色名称,按权限配置中角色对应的权限授权"`
+}
+
+type OidcOption struct {
+	Issuer     string `comment:"身份提供方地址,令牌的iss与之一致时由OIDC认证,为空时不启用"`
+	JwksURL    string `comment:"JWKS地址,为空时从 身份提供方地址/.well-known/openid-configuration 获取"`
+	Audience   string `comment:"令牌的受众(aud),为空时不校验"`
+	UserClaim  string `comment:"用户ID的声明"`
+	NameClaim  string `comment:"用户名称的声明"`
+	RolesClaim string `comment:"角色名称列表的声明,如 roles、groups"`
+	Refresh    int    `comment:"JWKS刷新间隔,单位秒"`
+}
+
+func newAuthOption() *AuthOption {
+	return &AuthOption{
+		Chain: []string{AuthApiKey, AuthJwt, AuthOidc, AuthUserCenter},
+		Jwt: &JwtOption{
+			Issuer: AppName,
+			Secret: "",
+			Expire: 720,
+		},
+		ApiKeys: []*ApiKeyOption{},
+		Oidc: &OidcOption{
+			UserClaim:  "sub",
+			NameClaim:  "preferred_username",
+			RolesClaim: "roles",
+			Refresh:    3600,
+		},
+	}
+}
+
+type SessionOption struct {
+	AutoTitle   bool `comment:"第一轮对话后由模型生成会话标题,关闭时以用户的第一条消息作为标题"`
+	TitleLength int  `comment:"会话标题的最大长度,单位字符"`
+}
+
+func newSessionOption() *SessionOption {
+	return &SessionOption{
+		AutoTitle:   false,
+		TitleLength: 20,
+	}
+}
+
 type MsgOption struct {
 	MainWss *uwss.Option `comment:"主消息服务配置"`
 	Mqtt    *umqt.Option `comment:"MQTT消息服务配置"`
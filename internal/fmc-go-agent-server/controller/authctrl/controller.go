@@ -0,0 +1,82 @@
+package authctrl
+
+import (
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/authsrv"
+	"github.com/freedqo/fmc-go-agent/pkg/webapp"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type Controller struct {
+	service authsrv.If
+}
+
+func New(s authsrv.If) If {
+	return &Controller{service: s}
+}
+
+// Me 获取登录用户
+//
+//	@Summary		获取登录用户
+//	@Description	获取当前凭证认证的登录用户、角色、权限与认证方式
+//	@Tags			认证管理
+//	@Accept			json
+//	@Produce		json
+//	@Param			Tokenid			header		string	false	"Tokenid 用户中心登录令牌"
+//	@Param			Authorization	header		string	false	"Bearer <API密钥或JWT>"
+//	@Success		200				{object}	webapp.Response{data=authm.Principal}
+//	@Failure		401				{object}	webapp.Response
+//	@Router			/auth/me [get]
+func (c *Controller) Me(ctx *gin.Context) {
+	p, _ := authm.FromContext(ctx.Request.Context())
+	ctx.JSON(http.StatusOK, webapp.Response{
+		Code:    http.StatusOK,
+		Message: "",
+		Data:    p,
+	})
+}
+
+// IssueToken 签发本地JWT
+//
+//	@Summary		签发本地JWT
+//	@Description	为登录用户签发本地JWT,令牌携带用户的角色与权限,之后以 Authorization: Bearer <令牌> 调用接口,不再依赖用户中心
+//	@Tags			认证管理
+//	@Accept			json
+//	@Produce		json
+//	@Param			Tokenid			header		string					false	"Tokenid 用户中心登录令牌"
+//	@Param			Authorization	header		string					false	"Bearer <API密钥或JWT>"
+//	@Param			request			body		authm.IssueTokenReq		false	"请求参数"
+//	@Success		200				{object}	webapp.Response{data=authm.IssueTokenResp}
+//	@Failure		400				{object}	webapp.Response
+//	@Failure		500				{object}	webapp.Response
+//	@Router			/auth/token [post]
+func (c *Controller) IssueToken(ctx *gin.Context) {
+	var req authm.IssueTokenReq
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, webapp.Response{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+				Data:    nil,
+			})
+			return
+		}
+	}
+	resp, err := c.service.IssueToken(ctx.Request.Context(), req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, webapp.Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, webapp.Response{
+		Code:    http.StatusOK,
+		Message: "",
+		Data:    resp,
+	})
+}
+
+var _ If = &Controller{}
@@ -0,0 +1,8 @@
+package authctrl
+
+import "github.com/gin-gonic/gin"
+
+type If interface {
+	Me(ctx *gin.Context)
+	IssueToken(ctx *gin.Context)
+}
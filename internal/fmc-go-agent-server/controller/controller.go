@@ -1,14 +1,19 @@
 package controller
 
 import (
+	"fmt"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/controller/authctrl"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/controller/knowdbctrl"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/controller/openaictrl"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/controller/promptctrl"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/controller/sessionctrl"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/store/log"
 	"github.com/freedqo/fmc-go-agent/pkg/webapp"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strings"
 )
 
 func New(service service.If) If {
@@ -17,6 +22,7 @@ func New(service service.If) If {
 		session: sessionctrl.New(service),
 		knowdb:  knowdbctrl.New(service.KnowDb()),
 		prompt:  promptctrl.New(service.Prompt()),
+		auth:    authctrl.New(service.Auth()),
 		service: service,
 	}
 }
@@ -27,6 +33,7 @@ type Controller struct {
 	session sessionctrl.If
 	knowdb  knowdbctrl.If
 	prompt  promptctrl.If
+	auth    authctrl.If
 }
 
 func (ctrl *Controller) Prompt() promptctrl.If {
@@ -37,26 +44,62 @@ func (ctrl *Controller) KnowDb() knowdbctrl.If {
 	return ctrl.knowdb
 }
 
-func (ctrl *Controller) MidInvalidTokenIdByUserCenter() gin.HandlerFunc {
+func (ctrl *Controller) Auth() authctrl.If {
+	return ctrl.auth
+}
+
+func (ctrl *Controller) MidAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenId := c.GetHeader("Tokenid")
-		if tokenId == "" {
+		cred := authm.Credential{TokenId: c.GetHeader("Tokenid")}
+		if v := c.GetHeader("Authorization"); len(v) > 7 && strings.EqualFold(v[:7], "Bearer ") {
+			cred.Bearer = strings.TrimSpace(v[7:])
+		}
+		p, err := ctrl.service.Auth().Authenticate(c.Request.Context(), cred)
+		if err != nil {
 			res := &webapp.Response{
 				Code:    http.StatusUnauthorized,
-				Message: "Tokenid is empty",
+				Message: err.Error(),
 				Data:    nil,
 			}
+			c.Header("WWW-Authenticate", "Bearer")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, res)
 			return
 		}
-		err := ctrl.service.MidInvalidTokenIdByUserCenter(c.Request.Context(), tokenId)
-		if err != nil {
+		// 登录用户写入gin上下文与请求上下文,后续接口以其校验数据归属
+		c.Set(authm.PrincipalKey, p)
+		c.Request = c.Request.WithContext(authm.WithPrincipal(c.Request.Context(), p))
+		c.Next()
+		// 路由权限与服务中的数据归属、权限校验拒绝访问时均为403,统一记录审计日志
+		if c.Writer.Status() == http.StatusForbidden {
+			log.AuditLog().Warnw("拒绝访问",
+				"userId", p.ID,
+				"account", p.Account,
+				"authBy", p.AuthBy,
+				"roles", p.Roles,
+				"method", c.Request.Method,
+				"path", c.FullPath(),
+				"clientIp", c.ClientIP(),
+				"reason", c.Errors.String(),
+			)
+		}
+	}
+}
+
+func (ctrl *Controller) Require(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, _ := authm.FromContext(c.Request.Context())
+		for _, perm := range perms {
+			if p.Has(perm) {
+				continue
+			}
+			err := fmt.Errorf("%w: 需要权限 %s", authm.ErrForbidden, perm)
+			_ = c.Error(err)
 			res := &webapp.Response{
-				Code:    http.StatusUnauthorized,
+				Code:    http.StatusForbidden,
 				Message: err.Error(),
 				Data:    nil,
 			}
-			c.AbortWithStatusJSON(http.StatusUnauthorized, res)
+			c.AbortWithStatusJSON(http.StatusForbidden, res)
 			return
 		}
 		c.Next()
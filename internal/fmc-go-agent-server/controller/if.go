@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/controller/authctrl"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/controller/knowdbctrl"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/controller/openaictrl"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/controller/promptctrl"
@@ -13,5 +14,9 @@ type If interface {
 	Session() sessionctrl.If
 	KnowDb() knowdbctrl.If
 	Prompt() promptctrl.If
-	MidInvalidTokenIdByUserCenter() gin.HandlerFunc
+	Auth() authctrl.If
+	// MidAuth 认证请求凭证(用户中心令牌、本地JWT、API密钥或OIDC令牌),登录用户写入请求上下文
+	MidAuth() gin.HandlerFunc
+	// Require 路由需要的权限,登录用户缺少任一权限时返回403
+	Require(perms ...string) gin.HandlerFunc
 }
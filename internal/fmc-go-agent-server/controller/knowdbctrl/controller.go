@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/knowdbm"
 	"github.com/gin-gonic/gin"
 )
@@ -32,6 +33,7 @@ func New(service knowdbsrv.If) If {
 //	@Produce		json
 //	@Param			Tokenid		header		string	true	"Tokenid 用户登录令牌"
 //	@Param			type		query		string	false	"文件类型"
+//	@Param			collection	query		string	false	"集合名称"
 //	@Param			page		query		int		false	"页码"	default(1)
 //	@Param			pageSize	query		int		false	"每页数量"	default(20)
 //	@Success		200			{object}	knowdbm.GetFileListResp
@@ -49,6 +51,8 @@ func (c *Controller) GetFileList(ctx *gin.Context) {
 	if fileType := ctx.Query("type"); fileType != "" {
 		req.Type = fileType
 	}
+	req.Collection = ctx.Query("collection")
+	req.UserID = authm.UserID(ctx.Request.Context())
 
 	if pageStr := ctx.Query("page"); pageStr != "" {
 		if page, err := strconv.Atoi(pageStr); err == nil {
@@ -83,6 +87,7 @@ func (c *Controller) GetFileList(ctx *gin.Context) {
 //	@Param			ids		body		knowdbm.DeleteFilesReq	true	"文件ID列表"
 //	@Success		200		{object}	knowdbm.DeleteFilesResp
 //	@Failure		400		{object}	webapp.Response
+//	@Failure		403		{object}	webapp.Response
 //	@Failure		500		{object}	webapp.Response
 //	@Router			/knowdb/files [delete]
 func (c *Controller) DeleteFiles(ctx *gin.Context) {
@@ -99,11 +104,12 @@ func (c *Controller) DeleteFiles(ctx *gin.Context) {
 		return
 	}
 
-	resp, err := c.service.DeleteFiles(req)
+	req.UserID = authm.UserID(ctx.Request.Context())
+	resp, err := c.service.DeleteFiles(ctx.Request.Context(), req)
 	if err != nil {
-		res.Code = http.StatusInternalServerError
+		res.Code = authm.HttpStatus(err)
 		res.Message = err.Error()
-		ctx.JSON(http.StatusInternalServerError, res)
+		ctx.JSON(res.Code, res)
 		return
 	}
 	res.Data = resp
@@ -138,7 +144,7 @@ func (c *Controller) DownloadFile(ctx *gin.Context) {
 		return
 	}
 
-	realPath, err := c.service.Download(id)
+	realPath, err := c.service.Download(id, authm.UserID(ctx.Request.Context()))
 	if err != nil {
 		if strings.Contains(err.Error(), "不存在") {
 			res.Code = http.StatusNotFound
@@ -167,10 +173,12 @@ func (c *Controller) DownloadFile(ctx *gin.Context) {
 //	@Tags			知识库管理
 //	@Accept			multipart/form-data
 //	@Produce		json
-//	@Param			Tokenid	header		string	true	"Tokenid 用户登录令牌"
-//	@Param			files	formData	file	true	"文件列表"
+//	@Param			Tokenid		header		string	true	"Tokenid 用户登录令牌"
+//	@Param			files		formData	file	true	"文件列表"
+//	@Param			collection	formData	string	false	"集合名称,为空时为默认集合"
 //	@Success		200		{object}	map[string][]string
 //	@Failure		400		{object}	webapp.Response
+//	@Failure		403		{object}	webapp.Response
 //	@Failure		500		{object}	webapp.Response
 //	@Router			/knowdb/files [post]
 func (c *Controller) UploadFiles(ctx *gin.Context) {
@@ -179,14 +187,18 @@ func (c *Controller) UploadFiles(ctx *gin.Context) {
 		Message: "",
 		Data:    nil,
 	}
+	req := knowdbm.UploadFilesReq{
+		Collection: ctx.PostForm("collection"),
+		UserID:     authm.UserID(ctx.Request.Context()),
+	}
 	// 单文件上传
 	file, err := ctx.FormFile("file")
 	if err == nil {
-		err := c.service.UploadFiles([]*multipart.FileHeader{file})
+		err := c.service.UploadFiles(ctx.Request.Context(), req, []*multipart.FileHeader{file})
 		if err != nil {
-			res.Code = http.StatusInternalServerError
+			res.Code = authm.HttpStatus(err)
 			res.Message = err.Error()
-			ctx.JSON(http.StatusInternalServerError, res)
+			ctx.JSON(res.Code, res)
 			return
 		}
 		res.Code = http.StatusOK
@@ -213,11 +225,11 @@ func (c *Controller) UploadFiles(ctx *gin.Context) {
 		return
 	}
 
-	err = c.service.UploadFiles(files)
+	err = c.service.UploadFiles(ctx.Request.Context(), req, files)
 	if err != nil {
-		res.Code = http.StatusInternalServerError
+		res.Code = authm.HttpStatus(err)
 		res.Message = err.Error()
-		ctx.JSON(http.StatusInternalServerError, res)
+		ctx.JSON(res.Code, res)
 		return
 	}
 
@@ -230,3 +242,224 @@ func (c *Controller) UploadFiles(ctx *gin.Context) {
 	res.Data = map[string][]string{"filename": filenames}
 	ctx.JSON(http.StatusOK, res)
 }
+
+// GetCollections godoc
+//
+//	@Summary		获取集合列表
+//	@Description	获取公开集合与用户的私有集合
+//	@Tags			知识库管理
+//	@Accept			json
+//	@Produce		json
+//	@Param			Tokenid	header		string	true	"Tokenid 用户登录令牌"
+//	@Success		200		{object}	knowdbm.GetCollectionsResp
+//	@Failure		500		{object}	webapp.Response
+//	@Router			/knowdb/collections [get]
+func (c *Controller) GetCollections(ctx *gin.Context) {
+	req := knowdbm.GetCollectionsReq{UserID: authm.UserID(ctx.Request.Context())}
+	res := webapp.Response{
+		Code:    200,
+		Message: "",
+		Data:    nil,
+	}
+	resp, err := c.service.GetCollections(ctx.Request.Context(), req)
+	if err != nil {
+		res.Code = http.StatusInternalServerError
+		res.Message = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+	res.Data = resp
+	ctx.JSON(http.StatusOK, res)
+}
+
+// CreateCollection godoc
+//
+//	@Summary		创建集合
+//	@Description	创建知识库集合,私有集合只有创建用户可上传与检索,提示词模板按集合名称指定可检索的集合
+//	@Tags			知识库管理
+//	@Accept			json
+//	@Produce		json
+//	@Param			Tokenid	header		string						true	"Tokenid 用户登录令牌"
+//	@Param			req		body		knowdbm.CreateCollectionReq	true	"集合信息"
+//	@Success		200		{object}	knowdbm.CollectionInfo
+//	@Failure		400		{object}	webapp.Response
+//	@Failure		403		{object}	webapp.Response
+//	@Failure		500		{object}	webapp.Response
+//	@Router			/knowdb/collections [post]
+func (c *Controller) CreateCollection(ctx *gin.Context) {
+	var req knowdbm.CreateCollectionReq
+	res := webapp.Response{
+		Code:    200,
+		Message: "",
+		Data:    nil,
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	req.UserID = authm.UserID(ctx.Request.Context())
+	resp, err := c.service.CreateCollection(ctx.Request.Context(), req)
+	if err != nil {
+		res.Code = authm.HttpStatus(err)
+		res.Message = err.Error()
+		ctx.JSON(res.Code, res)
+		return
+	}
+	res.Data = resp
+	ctx.JSON(http.StatusOK, res)
+}
+
+// DeleteCollection godoc
+//
+//	@Summary		删除集合
+//	@Description	删除知识库集合及其所有文件与分片,默认集合不能删除
+//	@Tags			知识库管理
+//	@Accept			json
+//	@Produce		json
+//	@Param			Tokenid	header		string						true	"Tokenid 用户登录令牌"
+//	@Param			req		body		knowdbm.DeleteCollectionReq	true	"集合名称"
+//	@Success		200		{object}	knowdbm.DeleteCollectionResp
+//	@Failure		400		{object}	webapp.Response
+//	@Failure		403		{object}	webapp.Response
+//	@Failure		500		{object}	webapp.Response
+//	@Router			/knowdb/collections [delete]
+func (c *Controller) DeleteCollection(ctx *gin.Context) {
+	var req knowdbm.DeleteCollectionReq
+	res := webapp.Response{
+		Code:    200,
+		Message: "",
+		Data:    nil,
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	req.UserID = authm.UserID(ctx.Request.Context())
+	resp, err := c.service.DeleteCollection(ctx.Request.Context(), req)
+	if err != nil {
+		res.Code = authm.HttpStatus(err)
+		res.Message = err.Error()
+		ctx.JSON(res.Code, res)
+		return
+	}
+	res.Data = resp
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Search godoc
+//
+//	@Summary		知识库检索
+//	@Description	按检索文本在用户可见的集合中进行向量检索,返回分片内容、相似度与元数据,用于排查回答质量问题
+//	@Tags			知识库管理
+//	@Accept			json
+//	@Produce		json
+//	@Param			Tokenid	header		string				true	"Tokenid 用户登录令牌"
+//	@Param			req		body		knowdbm.SearchReq	true	"检索条件"
+//	@Success		200		{object}	knowdbm.SearchResp
+//	@Failure		400		{object}	webapp.Response
+//	@Failure		500		{object}	webapp.Response
+//	@Router			/knowdb/search [post]
+func (c *Controller) Search(ctx *gin.Context) {
+	var req knowdbm.SearchReq
+	res := webapp.Response{
+		Code:    200,
+		Message: "",
+		Data:    nil,
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = err.Error()
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	req.UserID = authm.UserID(ctx.Request.Context())
+	resp, err := c.service.Search(ctx.Request.Context(), req)
+	if err != nil {
+		res.Code = http.StatusInternalServerError
+		res.Message = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+	res.Data = resp
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetChunks godoc
+//
+//	@Summary		获取文件分片
+//	@Description	按索引顺序返回文件在向量数据库中的分片,用于查看文件的分片结果
+//	@Tags			知识库管理
+//	@Accept			json
+//	@Produce		json
+//	@Param			Tokenid	header		string	true	"Tokenid 用户登录令牌"
+//	@Param			fileId	query		string	true	"文件Id"
+//	@Success		200		{object}	knowdbm.GetChunksResp
+//	@Failure		400		{object}	webapp.Response
+//	@Failure		404		{object}	webapp.Response
+//	@Failure		500		{object}	webapp.Response
+//	@Router			/knowdb/chunks [get]
+func (c *Controller) GetChunks(ctx *gin.Context) {
+	req := knowdbm.GetChunksReq{
+		FileID: ctx.Query("fileId"),
+		UserID: authm.UserID(ctx.Request.Context()),
+	}
+	res := webapp.Response{
+		Code:    200,
+		Message: "",
+		Data:    nil,
+	}
+	if req.FileID == "" {
+		res.Code = http.StatusBadRequest
+		res.Message = "文件Id不能为空"
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	resp, err := c.service.GetChunks(ctx.Request.Context(), req)
+	if err != nil {
+		if strings.Contains(err.Error(), "不存在") {
+			res.Code = http.StatusNotFound
+			res.Message = err.Error()
+			ctx.JSON(http.StatusNotFound, res)
+		} else {
+			res.Code = http.StatusInternalServerError
+			res.Message = err.Error()
+			ctx.JSON(http.StatusInternalServerError, res)
+		}
+		return
+	}
+	res.Data = resp
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetStats godoc
+//
+//	@Summary		获取知识库统计信息
+//	@Description	已索引的文件与分片数量、各集合的分片数量、嵌入模型与向量索引大小
+//	@Tags			知识库管理
+//	@Accept			json
+//	@Produce		json
+//	@Param			Tokenid	header		string	true	"Tokenid 用户登录令牌"
+//	@Success		200		{object}	knowdbm.GetStatsResp
+//	@Failure		403		{object}	webapp.Response
+//	@Failure		500		{object}	webapp.Response
+//	@Router			/knowdb/stats [get]
+func (c *Controller) GetStats(ctx *gin.Context) {
+	res := webapp.Response{
+		Code:    200,
+		Message: "",
+		Data:    nil,
+	}
+	resp, err := c.service.GetStats(ctx.Request.Context())
+	if err != nil {
+		res.Code = http.StatusInternalServerError
+		res.Message = err.Error()
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+	res.Data = resp
+	ctx.JSON(http.StatusOK, res)
+}
@@ -7,4 +7,10 @@ type If interface {
 	DeleteFiles(ctx *gin.Context)
 	DownloadFile(ctx *gin.Context)
 	UploadFiles(ctx *gin.Context)
+	GetCollections(ctx *gin.Context)
+	CreateCollection(ctx *gin.Context)
+	DeleteCollection(ctx *gin.Context)
+	Search(ctx *gin.Context)
+	GetChunks(ctx *gin.Context)
+	GetStats(ctx *gin.Context)
 }
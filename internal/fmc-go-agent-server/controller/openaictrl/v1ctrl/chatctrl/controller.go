@@ -2,11 +2,15 @@ package chatctrl
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/cloudwego/eino/schema"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/openaim/v1m/chatm"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/store/log"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaiagent"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaicharmodel"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"io"
@@ -52,18 +56,21 @@ func (ctrl *Controller) Completions(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "sessionId is required"})
 		return
 	}
-	//
-	tokenId := c.GetHeader("Tokenid")
-	if tokenId == "" {
-		c.JSON(400, gin.H{"error": "Tokenid is required"})
+	// 会话须由登录用户创建
+	if err := ctrl.service.Session().CheckSession(sessionId, authm.UserID(c.Request.Context())); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		return
 	}
 	c.Writer.Header()
 
 	// 调用OpenAI的ChatCompletion接口，传入请求参数和上下文
 	res, err := ctrl.service.OpenAi().V1().Chat().Completions(c.Request.Context(), sessionId, req)
-	// 如果调用失败，返回500错误
+	// 如果调用失败，返回500错误,模型均不可用时返回503
 	if err != nil {
+		if errors.Is(err, uaicharmodel.ErrModelUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
@@ -81,6 +88,8 @@ func (ctrl *Controller) Completions(c *gin.Context) {
 		// 强制刷新头信息
 		c.Writer.(http.Flusher).Flush()
 		msgs := res.(*schema.StreamReader[*schema.Message])
+		// 关闭输出流,客户端断开时代理停止运行并保存已输出的部分回答
+		defer msgs.Close()
 		for {
 			v, err := msgs.Recv()
 			index++
@@ -95,8 +104,43 @@ func (ctrl *Controller) Completions(c *gin.Context) {
 				c.Writer.(http.Flusher).Flush()
 				break
 			}
-			// 提取delta内容并格式化为SSE
-			if len(v.Content) > 0 {
+			// 工具事件,以具名SSE事件输出
+			if e, ok := uaiagent.GetEvent(v); ok {
+				str, err := json.Marshal(e)
+				if err != nil {
+					return
+				}
+				c.Writer.Write([]byte(fmt.Sprintf("event: %s\ndata: %s\n\n", e.Type, str)))
+				c.Writer.(http.Flusher).Flush()
+				// 检查客户端连接是否关闭
+				if c.Request.Context().Err() != nil {
+					break
+				}
+				continue
+			}
+			// 回答引用的文档,以choices为空的分片输出
+			if cs, ok := uaiagent.GetCitations(v); ok {
+				str, err := json.Marshal(chatm.ResChatCompletionsStream{
+					ID:                id.String(),
+					Object:            "chat.completion.chunk",
+					Created:           int(time.Now().Unix()),
+					Model:             req.Model,
+					SystemFingerprint: "fp_8802369eaa_prod0623_fp8_kvcache",
+					Choices:           make([]chatm.Choices, 0),
+					Citations:         toChatCitations(cs),
+				})
+				if err != nil {
+					return
+				}
+				if req.StreamEvents {
+					c.Writer.Write([]byte(fmt.Sprintf("event: %s\n", uaiagent.EventCitations)))
+				}
+				c.Writer.Write([]byte(fmt.Sprintf("data: %s\n\n", str)))
+				c.Writer.(http.Flusher).Flush()
+				continue
+			}
+			// 提取delta内容并格式化为SSE,工具调用没有文本内容也需要输出,只有结束原因的分片(如cancelled)也需要输出
+			if len(v.Content) > 0 || len(v.ToolCalls) > 0 || (v.ResponseMeta != nil && v.ResponseMeta.FinishReason != "") {
 				resMsg := chatm.ResChatCompletionsStream{
 					Choices:           make([]chatm.Choices, 0),
 					Created:           int(time.Now().Unix()),
@@ -119,7 +163,8 @@ func (ctrl *Controller) Completions(c *gin.Context) {
 				choice := chatm.Choices{
 					Index: int(index),
 					Delta: chatm.Delta{
-						Content: v.Content,
+						Content:   v.Content,
+						ToolCalls: toChatToolCalls(v.ToolCalls),
 					},
 				}
 				if v.ResponseMeta != nil {
@@ -131,6 +176,9 @@ func (ctrl *Controller) Completions(c *gin.Context) {
 				if err != nil {
 					return
 				}
+				if req.StreamEvents {
+					c.Writer.Write([]byte(fmt.Sprintf("event: %s\n", uaiagent.EventAnswer)))
+				}
 				c.Writer.Write([]byte(fmt.Sprintf("data: %s\n\n", str)))
 
 				c.Writer.(http.Flusher).Flush()
@@ -168,10 +216,14 @@ func (ctrl *Controller) Completions(c *gin.Context) {
 				FinishReason: &v1.ResponseMeta.FinishReason,
 				Index:        &index,
 				Message: &chatm.Message{
-					Content: v1.Content,
-					Role:    string(v1.Role),
+					Content:   v1.Content,
+					Role:      string(v1.Role),
+					ToolCalls: toChatToolCalls(v1.ToolCalls),
 				},
 			})
+			if cs, ok := uaiagent.GetCitations(v1); ok {
+				resMsg.Citations = toChatCitations(cs)
+			}
 			c.JSON(200, resMsg)
 			break
 		}
@@ -184,6 +236,76 @@ func (ctrl *Controller) Completions(c *gin.Context) {
 	return
 }
 
+// Cancel 取消会话中进行中的Ai Agent聊天
+//
+//	@Summary		取消Ai Agent聊天
+//	@Description	取消会话中进行中的Ai Agent聊天,停止模型输出与未完成的工具调用,已输出的部分回答以cancelled结束原因保存
+//	@Tags			Openai Api 接口管理
+//	@Produce		json
+//	@Param			Tokenid		header		string	true	"Tokenid 用户登录令牌"
+//	@Param			SessionId	header		string	true	"用户与模型对话令牌"
+//	@Success		200			{object}	object	"成功响应"
+//	@Router			/openai/v1/chat/cancel [post]
+func (ctrl *Controller) Cancel(c *gin.Context) {
+	sessionId := c.GetHeader("SessionId")
+	if sessionId == "" {
+		c.JSON(400, gin.H{"error": "sessionId is required"})
+		return
+	}
+	// 会话须由登录用户创建
+	if err := ctrl.service.Session().CheckSession(sessionId, authm.UserID(c.Request.Context())); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	n, err := ctrl.service.OpenAi().V1().Chat().Cancel(c.Request.Context(), sessionId)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"session_id": sessionId, "cancelled": n})
+}
+
+// toChatToolCalls 将eino的工具调用转换为OpenAi格式
+func toChatToolCalls(toolCalls []schema.ToolCall) []chatm.ToolCall {
+	if len(toolCalls) == 0 {
+		return nil
+	}
+	res := make([]chatm.ToolCall, 0, len(toolCalls))
+	for _, v := range toolCalls {
+		res = append(res, chatm.ToolCall{
+			Index: v.Index,
+			ID:    v.ID,
+			Type:  v.Type,
+			Function: chatm.ToolCallFunction{
+				Name:      v.Function.Name,
+				Arguments: v.Function.Arguments,
+			},
+		})
+	}
+	return res
+}
+
+// toChatCitations 将代理的引用列表转换为响应格式
+func toChatCitations(cs []*uaiagent.Citation) []chatm.Citation {
+	res := make([]chatm.Citation, 0, len(cs))
+	for _, v := range cs {
+		res = append(res, chatm.Citation{
+			Index:      v.Index,
+			ChunkID:    v.ChunkID,
+			FileID:     v.FileID,
+			FileName:   v.FileName,
+			Collection: v.Collection,
+			Title:      v.Title,
+			Chapter:    v.Chapter,
+			Section:    v.Section,
+			Subsection: v.Subsection,
+			Row:        v.Row,
+			Score:      v.Score,
+		})
+	}
+	return res
+}
+
 func (ctrl *Controller) Models(c *gin.Context) {
 	models, err := ctrl.service.OpenAi().V1().Models(c.Request.Context())
 	if err != nil {
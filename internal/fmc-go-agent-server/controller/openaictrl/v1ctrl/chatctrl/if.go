@@ -4,4 +4,5 @@ import "github.com/gin-gonic/gin"
 
 type If interface {
 	Completions(c *gin.Context)
+	Cancel(c *gin.Context)
 }
@@ -25,12 +25,12 @@ func (ctrl *Controller) Chat() chatctrl.If {
 // Models 获取可用模型列表
 //
 //	@Summary		获取可用模型列表
-//	@Description	获取可用模型列表
+//	@Description	获取配置的模型目录,请求的model字段按名称选择
 //	@Tags			Openai Api 接口管理
 //	@Accept			json
 //	@Produce		json
-//	@Param			Tokenid	header		string				true	"Tokenid 用户登录令牌"
-//	@Success		200		{object}	webapp.Response{}	"成功响应"
+//	@Param			Tokenid	header		string			true	"Tokenid 用户登录令牌"
+//	@Success		200		{object}	v1m.ResModels	"成功响应"
 //	@Router			/openai/v1/models [get]
 func (ctrl *Controller) Models(c *gin.Context) {
 	models, err := ctrl.service.OpenAi().V1().Models(c.Request.Context())
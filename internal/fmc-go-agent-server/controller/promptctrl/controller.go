@@ -1,6 +1,7 @@
 package promptctrl
 
 import (
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/promptm"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/promptsrv"
 	"github.com/freedqo/fmc-go-agent/pkg/webapp"
@@ -31,8 +32,8 @@ func New(s promptsrv.If) If {
 func (c *Controller) GetPromptTemplate(ctx *gin.Context) {
 	resp, err := c.service.GetPromptTemplate(ctx.Request.Context(), struct{}{})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, webapp.Response{
-			Code:    http.StatusInternalServerError,
+		ctx.JSON(authm.HttpStatus(err), webapp.Response{
+			Code:    authm.HttpStatus(err),
 			Message: err.Error(),
 			Data:    nil,
 		})
@@ -68,10 +69,11 @@ func (c *Controller) Creat(ctx *gin.Context) {
 		})
 		return
 	}
+	req.UserID = authm.UserID(ctx.Request.Context())
 	resp, err := c.service.Creat(ctx.Request.Context(), req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, webapp.Response{
-			Code:    http.StatusInternalServerError,
+		ctx.JSON(authm.HttpStatus(err), webapp.Response{
+			Code:    authm.HttpStatus(err),
 			Message: err.Error(),
 			Data:    nil,
 		})
@@ -108,10 +110,53 @@ func (c *Controller) Delete(ctx *gin.Context) {
 		})
 		return
 	}
+	req.UserID = authm.UserID(ctx.Request.Context())
 	resp, err := c.service.Delete(ctx.Request.Context(), req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, webapp.Response{
-			Code:    http.StatusInternalServerError,
+		ctx.JSON(authm.HttpStatus(err), webapp.Response{
+			Code:    authm.HttpStatus(err),
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, webapp.Response{
+		Code:    http.StatusOK,
+		Message: "",
+		Data:    resp,
+	})
+}
+
+// ModifySessionPrompt 切换会话提示词
+//
+//	@Summary		切换会话提示词
+//	@Description	切换会话使用的提示词模板与模型,下一轮对话起生效,聊天记录中追加切换标记
+//	@Tags			提示词管理
+//	@Accept			json
+//	@Produce		json
+//	@Param			Tokenid							header		string							true	"Tokenid 用户登录令牌"
+//	@Param			promptm.ModifySessionPromptReq	body		promptm.ModifySessionPromptReq	true	"请求参数"
+//	@Success		200								{object}	webapp.Response{data=promptm.ModifySessionPromptResp}
+//	@Failure		400								{object}	webapp.Response
+//	@Failure		403								{object}	webapp.Response
+//	@Failure		500								{object}	webapp.Response
+//	@Router			/prompt/modifySessionPrompt [post]
+func (c *Controller) ModifySessionPrompt(ctx *gin.Context) {
+	req := promptm.ModifySessionPromptReq{}
+	err := ctx.ShouldBind(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, webapp.Response{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+	req.UserID = authm.UserID(ctx.Request.Context())
+	resp, err := c.service.ModifySessionPrompt(ctx.Request.Context(), req)
+	if err != nil {
+		ctx.JSON(authm.HttpStatus(err), webapp.Response{
+			Code:    authm.HttpStatus(err),
 			Message: err.Error(),
 			Data:    nil,
 		})
@@ -148,10 +193,12 @@ func (c *Controller) Query(ctx *gin.Context) {
 		})
 		return
 	}
+	userID := authm.UserID(ctx.Request.Context())
+	req.UserID = &userID
 	resp, err := c.service.Query(ctx.Request.Context(), req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, webapp.Response{
-			Code:    http.StatusInternalServerError,
+		ctx.JSON(authm.HttpStatus(err), webapp.Response{
+			Code:    authm.HttpStatus(err),
 			Message: err.Error(),
 			Data:    nil,
 		})
@@ -187,10 +234,11 @@ func (c *Controller) Update(ctx *gin.Context) {
 		})
 		return
 	}
+	req.UserID = authm.UserID(ctx.Request.Context())
 	resp, err := c.service.Update(ctx.Request.Context(), req)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, webapp.Response{
-			Code:    http.StatusInternalServerError,
+		ctx.JSON(authm.HttpStatus(err), webapp.Response{
+			Code:    authm.HttpStatus(err),
 			Message: err.Error(),
 			Data:    nil,
 		})
@@ -6,6 +6,7 @@ type If interface {
 	GetPromptTemplate(ctx *gin.Context)
 	Creat(ctx *gin.Context)
 	Delete(ctx *gin.Context)
+	ModifySessionPrompt(ctx *gin.Context)
 	Query(ctx *gin.Context)
 	Update(ctx *gin.Context)
 }
@@ -1,11 +1,11 @@
 package sessionctrl
 
 import (
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/sessionm"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service"
 	"github.com/freedqo/fmc-go-agent/pkg/webapp"
 	"github.com/gin-gonic/gin"
-	"net/http"
 )
 
 func New(service service.If) If {
@@ -40,10 +40,11 @@ func (ctrl *Controller) CreatSession(c *gin.Context) {
 		})
 		return
 	}
+	req.UserId = authm.UserID(c.Request.Context())
 	resp, err := ctrl.service.Session().CreatSession(c.Request.Context(), *req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, webapp.Response{
-			Code:    http.StatusInternalServerError,
+		c.JSON(authm.HttpStatus(err), webapp.Response{
+			Code:    authm.HttpStatus(err),
 			Message: err.Error(),
 			Data:    nil,
 		})
@@ -79,10 +80,11 @@ func (ctrl *Controller) UserSessionList(c *gin.Context) {
 		})
 		return
 	}
+	req.UserId = authm.UserID(c.Request.Context())
 	resp, err := ctrl.service.Session().UserSessionList(c.Request.Context(), *req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, webapp.Response{
-			Code:    http.StatusInternalServerError,
+		c.JSON(authm.HttpStatus(err), webapp.Response{
+			Code:    authm.HttpStatus(err),
 			Message: err.Error(),
 			Data:    nil,
 		})
@@ -118,10 +120,52 @@ func (ctrl *Controller) SessionChatLogList(c *gin.Context) {
 		})
 		return
 	}
+	req.UserId = authm.UserID(c.Request.Context())
 	resp, err := ctrl.service.Session().SessionChatLogList(c.Request.Context(), *req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, webapp.Response{
-			Code:    http.StatusInternalServerError,
+		c.JSON(authm.HttpStatus(err), webapp.Response{
+			Code:    authm.HttpStatus(err),
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+	c.JSON(200, &webapp.Response{
+		Code:    200,
+		Message: "",
+		Data:    resp,
+	})
+	return
+}
+
+// UpdateSession 修改会话
+//
+//	@Summary		修改会话
+//	@Description	修改会话的标题、置顶、归档与元数据,只修改请求中提供的字段
+//	@Tags			会话管理
+//	@Accept			json
+//	@Produce		json
+//	@Param			Tokenid						header		string												true	"Tokenid 用户登录令牌"
+//	@Param			sessionm.UpdateSessionReq	body		sessionm.UpdateSessionReq							true	"请求参数"
+//	@Success		200							{object}	webapp.Response{data=sessionm.UpdateSessionResp}	"成功响应"
+//	@Failure		403							{object}	webapp.Response
+//	@Router			/session/updateSession [post]
+func (ctrl *Controller) UpdateSession(c *gin.Context) {
+	req := &sessionm.UpdateSessionReq{}
+	err := c.ShouldBind(req)
+	if err != nil {
+		c.JSON(400, &webapp.Response{
+			Code:    400,
+			Message: err.Error(),
+			Data:    nil,
+		})
+		return
+	}
+	req.UserId = authm.UserID(c.Request.Context())
+	resp, err := ctrl.service.Session().UpdateSession(c.Request.Context(), *req)
+	if err != nil {
+		c.JSON(authm.HttpStatus(err), webapp.Response{
+			Code:    authm.HttpStatus(err),
 			Message: err.Error(),
 			Data:    nil,
 		})
@@ -157,10 +201,11 @@ func (ctrl *Controller) DeleteSessions(c *gin.Context) {
 		})
 		return
 	}
+	req.UserId = authm.UserID(c.Request.Context())
 	resp, err := ctrl.service.Session().DeleteSessions(c.Request.Context(), *req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, webapp.Response{
-			Code:    http.StatusInternalServerError,
+		c.JSON(authm.HttpStatus(err), webapp.Response{
+			Code:    authm.HttpStatus(err),
 			Message: err.Error(),
 			Data:    nil,
 		})
@@ -196,10 +241,11 @@ func (ctrl *Controller) DeleteChatLogs(c *gin.Context) {
 		})
 		return
 	}
+	req.UserId = authm.UserID(c.Request.Context())
 	resp, err := ctrl.service.Session().DeleteChatLogs(c.Request.Context(), *req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, webapp.Response{
-			Code:    http.StatusInternalServerError,
+		c.JSON(authm.HttpStatus(err), webapp.Response{
+			Code:    authm.HttpStatus(err),
 			Message: err.Error(),
 			Data:    nil,
 		})
@@ -235,10 +281,11 @@ func (ctrl *Controller) QuerySessionChatLogsByUser(c *gin.Context) {
 		})
 		return
 	}
+	req.UserId = authm.UserID(c.Request.Context())
 	resp, err := ctrl.service.Session().QuerySessionChatLogsByUser(c.Request.Context(), *req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, webapp.Response{
-			Code:    http.StatusInternalServerError,
+		c.JSON(authm.HttpStatus(err), webapp.Response{
+			Code:    authm.HttpStatus(err),
 			Message: err.Error(),
 			Data:    nil,
 		})
@@ -8,6 +8,7 @@ type If interface {
 	CreatSession(c *gin.Context)
 	UserSessionList(c *gin.Context)
 	SessionChatLogList(c *gin.Context)
+	UpdateSession(c *gin.Context)
 	DeleteSessions(c *gin.Context)
 	DeleteChatLogs(c *gin.Context)
 	QuerySessionChatLogsByUser(c *gin.Context)
@@ -5,19 +5,20 @@ import (
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/dal/db"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/dal/ext"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/store/log"
 	ucharmodel2 "github.com/freedqo/fmc-go-agent/pkg/uai/uaicharmodel"
 )
 
 func New(ctx context.Context, opt *config.Config) If {
 	return &Dal{
-		ucm: ucharmodel2.New(ctx, opt.UCM),
+		ucm: ucharmodel2.NewModels(ctx, opt.UCM, opt.Models, opt.Resilient, log.SysLog()),
 		db:  db.New(opt.Db),
 		ext: ext.New(ctx, opt.Ext),
 	}
 }
 
 type Dal struct {
-	ucm ucharmodel2.If
+	ucm ucharmodel2.ModelsIf
 	db  db.If
 	ext ext.If
 }
@@ -30,7 +31,7 @@ func (d *Dal) Db() db.If {
 	return d.db
 }
 
-func (d *Dal) Cm() ucharmodel2.If {
+func (d *Dal) Cm() ucharmodel2.ModelsIf {
 	return d.ucm
 }
 
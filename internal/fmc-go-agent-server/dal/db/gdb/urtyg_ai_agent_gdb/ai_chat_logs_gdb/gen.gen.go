@@ -114,7 +114,7 @@ func (g *GenIF) Find(query *model.Ai_chat_logs_QueryReq) (data []*model.Ai_chat_
 		addQueryCondition("id", query.ID, query.IsLike)
 		// 消息顺序
 		addQueryCondition("order", query.Order, query.IsLike)
-		// 消息角色
+		// 消息角色(user,assistant,system,tool,memo-摘要备忘,marker-会话变更标记)
 		addQueryCondition("role", query.Role, query.IsLike)
 		// 会话唯一标识
 		addQueryCondition("session_id", query.SessionID, query.IsLike)
@@ -106,6 +106,8 @@ func (g *GenIF) Find(query *model.Ai_prompt_QueryReq) (data []*model.Ai_prompt,
 		}
 
 		// 调用通用函数添加各个字段的查询条件
+		// 可检索的知识库集合,逗号分隔,为空时检索默认集合
+		addQueryCondition("collections", query.Collections, query.IsLike)
 		// 模板内容(md格式)
 		addQueryCondition("content", query.Content, query.IsLike)
 		// 创建时间
@@ -116,10 +118,18 @@ func (g *GenIF) Find(query *model.Ai_prompt_QueryReq) (data []*model.Ai_prompt,
 		addQueryCondition("id", query.ID, query.IsLike)
 		// 是否分享(0-私有,1-公开)
 		addQueryCondition("is_shared", query.IsShared, query.IsLike)
+		// 对话记忆策略(空-全部历史,summary-按token预算摘要)
+		addQueryCondition("memory_mode", query.MemoryMode, query.IsLike)
+		// 检索文档的最低分数,为0时使用系统配置
+		addQueryCondition("min_score", query.MinScore, query.IsLike)
+		// 默认模型名称,为空时使用系统默认模型
+		addQueryCondition("model", query.Model, query.IsLike)
 		// 模板名称
 		addQueryCondition("name", query.Name, query.IsLike)
 		// 分享时间
 		addQueryCondition("shared_at", query.SharedAt, query.IsLike)
+		// 检索返回的文档数量,为0时使用系统配置
+		addQueryCondition("top_k", query.TopK, query.IsLike)
 		// 模板类型
 		addQueryCondition("type", query.Type, query.IsLike)
 		// 更新时间
@@ -106,12 +106,28 @@ func (g *GenIF) Find(query *model.Ai_session_logs_QueryReq) (data []*model.Ai_se
 		}
 
 		// 调用通用函数添加各个字段的查询条件
+		// 是否归档
+		addQueryCondition("archived", query.Archived, query.IsLike)
+		// 创建时间
+		addQueryCondition("created_at", query.CreatedAt, query.IsLike)
+		// 删除时间
+		addQueryCondition("deleted_at", query.DeletedAt, query.IsLike)
 		// 会话记录id
 		addQueryCondition("id", query.ID, query.IsLike)
 		// 最后时间
 		addQueryCondition("last_at", query.LastAt, query.IsLike)
+		// 会话元数据(JSON对象)
+		addQueryCondition("metadata", query.Metadata, query.IsLike)
+		// 会话指定的模型,为空时使用提示词模板的模型
+		addQueryCondition("model", query.Model, query.IsLike)
+		// 是否置顶
+		addQueryCondition("pinned", query.Pinned, query.IsLike)
 		// 提示词模板id
 		addQueryCondition("prompt_id", query.PromptID, query.IsLike)
+		// 会话标题
+		addQueryCondition("title", query.Title, query.IsLike)
+		// 标题来源(auto-首条消息,llm-模型生成,user-用户修改)
+		addQueryCondition("title_source", query.TitleSource, query.IsLike)
 		// 用户id
 		addQueryCondition("user_id", query.UserID, query.IsLike)
 
@@ -32,6 +32,10 @@ func New(opt *ugrom.Option) urtyg_ai_agent_if.If {
 	if opt.IsAutoMigrate{
 		model.AutoMigrate(gdb)
 	}
+	// 升级已有的表结构,不依赖自动迁移
+	if err = Migrate(gdb); err != nil {
+		panic(err)
+	}
 
 	genQ := query.Use(gdb)
 	db := Db{
@@ -0,0 +1,95 @@
+package urtyg_ai_agent_gdb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/dalm/dbm/urtyg_ai_agent/model"
+	"gorm.io/gorm"
+)
+
+// migration 表结构升级,与是否开启自动迁移无关,启动时按顺序执行;每一步需要可重复执行
+type migration struct {
+	name string
+	run  func(db *gorm.DB) error
+}
+
+var migrations = []migration{
+	// 对话记录的角色需要保存tool、memo等角色,原有的enum('user','assistant','system')无法写入
+	{name: "widen ai_chat_logs.role", run: widenChatLogRole},
+	// 提示词模板的记忆策略、模型与检索配置
+	{name: "add ai_prompt columns", run: addColumns(&model.Ai_prompt{}, "MemoryMode", "Model", "TopK", "MinScore", "Collections")},
+	// 会话的模型、标题、置顶、归档、元数据、创建与删除时间
+	{name: "add ai_session_logs columns", run: addColumns(&model.Ai_session_logs{},
+		"Model", "Title", "TitleSource", "Pinned", "Archived", "Metadata", "CreatedAt", "DeletedAt")},
+	{name: "add ai_session_logs indexes", run: addIndexes(&model.Ai_session_logs{}, "idx_user_last", "idx_deleted_at")},
+}
+
+// Migrate 执行表结构升级,表不存在时跳过(由自动迁移或建表脚本创建)
+func Migrate(db *gorm.DB) error {
+	for _, m := range migrations {
+		if err := m.run(db); err != nil {
+			return fmt.Errorf("migration %s failed: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+func widenChatLogRole(db *gorm.DB) error {
+	m := db.Migrator()
+	if !m.HasTable(&model.Ai_chat_logs{}) {
+		return nil
+	}
+	cols, err := m.ColumnTypes(&model.Ai_chat_logs{})
+	if err != nil {
+		return err
+	}
+	for _, c := range cols {
+		if c.Name() != "role" {
+			continue
+		}
+		if strings.EqualFold(c.DatabaseTypeName(), "enum") {
+			return m.AlterColumn(&model.Ai_chat_logs{}, "Role")
+		}
+		return nil
+	}
+	return nil
+}
+
+// addColumns 表存在时补充缺少的字段,字段定义取自模型
+func addColumns(table any, fields ...string) func(db *gorm.DB) error {
+	return func(db *gorm.DB) error {
+		m := db.Migrator()
+		if !m.HasTable(table) {
+			return nil
+		}
+		for _, f := range fields {
+			if m.HasColumn(table, f) {
+				continue
+			}
+			if err := m.AddColumn(table, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// addIndexes 表存在时补充缺少的索引,索引定义取自模型
+func addIndexes(table any, names ...string) func(db *gorm.DB) error {
+	return func(db *gorm.DB) error {
+		m := db.Migrator()
+		if !m.HasTable(table) {
+			return nil
+		}
+		for _, name := range names {
+			if m.HasIndex(table, name) {
+				continue
+			}
+			if err := m.CreateIndex(table, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
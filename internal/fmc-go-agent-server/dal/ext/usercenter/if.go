@@ -9,4 +9,6 @@ type If interface {
 	Sm2Login(ctx context.Context, req usercenterm.Sm2LoginReq) (res *usercenterm.Sm2LoginResp, err error)
 	GetMenuList(ctx context.Context, req usercenterm.GetMenuListReq) (res *usercenterm.GetMenuListResp, err error)
 	InvalidToken(ctx context.Context, req usercenterm.InvalidTokenReq) (res *usercenterm.InvalidTokenResp, err error)
+	// GetUserInfo 获取令牌对应的登录用户
+	GetUserInfo(ctx context.Context, req usercenterm.GetUserInfoReq) (res *usercenterm.GetUserInfoResp, err error)
 }
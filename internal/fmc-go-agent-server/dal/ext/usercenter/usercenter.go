@@ -83,6 +83,24 @@ func (u *UserCenter) InvalidToken(ctx context.Context, req usercenterm.InvalidTo
 	}
 	return res, nil
 }
+func (u *UserCenter) GetUserInfo(ctx context.Context, req usercenterm.GetUserInfoReq) (res *usercenterm.GetUserInfoResp, err error) {
+	res = &usercenterm.GetUserInfoResp{}
+	cReq := u.c.NewRequest(nil)
+	cRes := u.c.NewResponse(res)
+	cReq.Headers["Tokenid"] = req.TokenId
+	err = u.c.Get(ctx, "/usercenter/v2/sysUser/getUserInfo", cReq, cRes)
+	if err != nil {
+		return nil, err
+	}
+	if res.Code != 200 {
+		return nil, errors.New(res.Msg)
+	}
+	if res.Data == nil {
+		return nil, errors.New("用户中心未返回用户信息")
+	}
+	return res, nil
+}
+
 func (u *UserCenter) GetMenuList(ctx context.Context, req usercenterm.GetMenuListReq) (res *usercenterm.GetMenuListResp, err error) {
 	res = &usercenterm.GetMenuListResp{}
 	cReq := u.c.NewRequest(nil)
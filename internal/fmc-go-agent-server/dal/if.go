@@ -7,7 +7,7 @@ import (
 )
 
 type If interface {
-	Cm() uaicharmodel.If
+	Cm() uaicharmodel.ModelsIf
 	Db() db.If
 	Ext() ext.If
 }
@@ -0,0 +1,39 @@
+package authm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// 服务的权限编码,格式为 资源:操作;用户中心菜单/按钮的权限编码与之相同时直接生效
+const (
+	PermAll          = "*"             // 所有权限
+	PermKnowDbWrite  = "knowdb:write"  // 上传与删除知识库文件、创建与删除私有集合
+	PermKnowDbAdmin  = "knowdb:admin"  // 管理公开集合及其文件、查看索引统计
+	PermPromptAdmin  = "prompt:admin"  // 修改内置提示词模板,内置模板不能删除
+	PermSessionAdmin = "session:admin" // 删除其他用户的聊天记录
+)
+
+// Has 登录用户是否拥有权限,*为所有权限,资源:*为资源的所有操作
+func (p *Principal) Has(perm string) bool {
+	if p == nil {
+		return false
+	}
+	res, _, _ := strings.Cut(perm, ":")
+	for _, v := range p.Permissions {
+		if v == PermAll || v == perm || v == res+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Require 校验上下文中的登录用户拥有权限,否则返回ErrForbidden
+func Require(ctx context.Context, perm string) error {
+	p, _ := FromContext(ctx)
+	if !p.Has(perm) {
+		return fmt.Errorf("%w: 需要权限 %s", ErrForbidden, perm)
+	}
+	return nil
+}
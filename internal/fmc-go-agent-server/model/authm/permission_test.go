@@ -0,0 +1,55 @@
+package authm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPrincipalHas(t *testing.T) {
+	tests := []struct {
+		name  string
+		perms []string
+		perm  string
+		want  bool
+	}{
+		{"no permissions", nil, PermKnowDbAdmin, false},
+		{"exact", []string{PermKnowDbAdmin}, PermKnowDbAdmin, true},
+		{"other action", []string{PermKnowDbWrite}, PermKnowDbAdmin, false},
+		{"resource wildcard", []string{"knowdb:*"}, PermKnowDbAdmin, true},
+		{"other resource wildcard", []string{"prompt:*"}, PermKnowDbAdmin, false},
+		{"all", []string{PermAll}, PermSessionAdmin, true},
+		{"prefix is not a match", []string{"knowdb"}, PermKnowDbAdmin, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Principal{ID: "u1", Permissions: tt.perms}
+			if got := p.Has(tt.perm); got != tt.want {
+				t.Errorf("Has(%q) = %v, want %v", tt.perm, got, tt.want)
+			}
+		})
+	}
+	var p *Principal
+	if p.Has(PermAll) {
+		t.Error("nil principal has permission")
+	}
+}
+
+func TestRequire(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{"anonymous", context.Background(), ErrForbidden},
+		{"without permission", WithPrincipal(context.Background(), &Principal{ID: "u1"}), ErrForbidden},
+		{"with permission", WithPrincipal(context.Background(), &Principal{ID: "u1", Permissions: []string{PermPromptAdmin}}), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Require(tt.ctx, PermPromptAdmin); !errors.Is(err, tt.want) {
+				t.Errorf("Require() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package authm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// PrincipalKey gin上下文中登录用户的键
+const PrincipalKey = "principal"
+
+// Principal 由请求凭证认证的用户,会话、提示词、聊天记录与知识库按其用户ID校验归属
+type Principal struct {
+	ID          string   `json:"id"`          // 用户ID
+	Name        string   `json:"name"`        // 用户名称
+	Account     string   `json:"account"`     // 登录账号
+	Roles       []string `json:"roles"`       // 角色名称
+	Permissions []string `json:"permissions"` // 权限编码,由用户中心的权限与配置的角色权限合并
+	AuthBy      string   `json:"authBy"`      // 认证方式
+}
+
+type principalKey struct{}
+
+// WithPrincipal 将登录用户写入上下文
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext 获取上下文中的登录用户
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// UserID 上下文中登录用户的ID,未登录时为空
+func UserID(ctx context.Context) string {
+	if p, ok := FromContext(ctx); ok {
+		return p.ID
+	}
+	return ""
+}
+
+// ErrForbidden 数据不属于登录用户或登录用户没有权限
+var ErrForbidden = errors.New("无权访问")
+
+// HttpStatus 服务错误对应的HTTP状态码,无权访问为403,其他为500
+func HttpStatus(err error) int {
+	if errors.Is(err, ErrForbidden) {
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,23 @@
+package authm
+
+import "time"
+
+// Credential 请求携带的凭证
+type Credential struct {
+	TokenId string // 请求头 Tokenid,用户中心令牌
+	Bearer  string // 请求头 Authorization: Bearer <令牌>,API密钥或JWT
+}
+
+// Empty 请求是否未携带凭证
+func (c Credential) Empty() bool {
+	return c.TokenId == "" && c.Bearer == ""
+}
+
+type IssueTokenReq struct {
+	Expire int `json:"expire"` // 有效时长,单位分钟,为0或超过配置的最长时长时为最长时长
+}
+
+type IssueTokenResp struct {
+	Token     string    `json:"token"`     // 本地签发的JWT,请求头 Authorization: Bearer <令牌>
+	ExpiresAt time.Time `json:"expiresAt"` // 过期时间
+}
@@ -18,7 +18,7 @@ type Ai_chat_logs_QueryReq struct {
     ID *string `json:"ID" column:"id" form:"ID"`
     // 消息顺序
     Order *string `json:"Order" column:"order" form:"Order"`
-    // 消息角色
+    // 消息角色(user,assistant,system,tool,memo-摘要备忘,marker-会话变更标记)
     Role *string `json:"Role" column:"role" form:"Role"`
     // 会话唯一标识
     SessionID *string `json:"SessionID" column:"session_id" form:"SessionID"`
@@ -18,6 +18,11 @@ type Ai_prompt struct {
 	Name        string     `gorm:"column:name;type:varchar(100);not null;comment:模板名称" json:"name"`                                                                                  // 模板名称
 	Description string     `gorm:"column:description;type:varchar(500);comment:模板描述" json:"description"`                                                                             // 模板描述
 	Content     string     `gorm:"column:content;type:text;not null;comment:模板内容(md格式)" json:"content"`                                                                              // 模板内容(md格式)
+	MemoryMode  string     `gorm:"column:memory_mode;type:varchar(30);comment:对话记忆策略(空-全部历史,summary-按token预算摘要)" json:"memory_mode"`                                                 // 对话记忆策略(空-全部历史,summary-按token预算摘要)
+	Model       string     `gorm:"column:model;type:varchar(100);comment:默认模型名称,为空时使用系统默认模型" json:"model"`                                                                           // 默认模型名称,为空时使用系统默认模型
+	TopK        int32      `gorm:"column:top_k;type:int;comment:检索返回的文档数量,为0时使用系统配置" json:"top_k"`                                                                                   // 检索返回的文档数量,为0时使用系统配置
+	MinScore    float64    `gorm:"column:min_score;type:double;comment:检索文档的最低分数,为0时使用系统配置" json:"min_score"`                                                                        // 检索文档的最低分数,为0时使用系统配置
+	Collections string     `gorm:"column:collections;type:varchar(500);comment:可检索的知识库集合,逗号分隔,为空时检索默认集合" json:"collections"`                                                         // 可检索的知识库集合,逗号分隔,为空时检索默认集合
 	IsShared    bool       `gorm:"column:is_shared;type:tinyint(1);not null;index:idx_is_shared,priority:1;comment:是否分享(0-私有,1-公开)" json:"is_shared"`                                // 是否分享(0-私有,1-公开)
 	SharedAt    time.Time  `gorm:"column:shared_at;type:timestamp;comment:分享时间" json:"shared_at"`                                                                                    // 分享时间
 	CreatedAt   *time.Time `gorm:"column:created_at;type:timestamp;not null;index:idx_created_at,priority:1;default:CURRENT_TIMESTAMP;comment:创建时间" json:"created_at"`               // 创建时间
@@ -10,6 +10,8 @@ import (
 
 // Ai_prompt_QueryReq 是用于查询 Ai_prompt 表的请求结构体
 type Ai_prompt_QueryReq struct {
+    // 可检索的知识库集合,逗号分隔,为空时检索默认集合
+    Collections *string `json:"Collections" column:"collections" form:"Collections"`
     // 模板内容(md格式)
     Content *string `json:"Content" column:"content" form:"Content"`
     // 创建时间
@@ -20,10 +22,18 @@ type Ai_prompt_QueryReq struct {
     ID *string `json:"ID" column:"id" form:"ID"`
     // 是否分享(0-私有,1-公开)
     IsShared *string `json:"IsShared" column:"is_shared" form:"IsShared"`
+    // 对话记忆策略(空-全部历史,summary-按token预算摘要)
+    MemoryMode *string `json:"MemoryMode" column:"memory_mode" form:"MemoryMode"`
+    // 检索文档的最低分数,为0时使用系统配置
+    MinScore *string `json:"MinScore" column:"min_score" form:"MinScore"`
+    // 默认模型名称,为空时使用系统默认模型
+    Model *string `json:"Model" column:"model" form:"Model"`
     // 模板名称
     Name *string `json:"Name" column:"name" form:"Name"`
     // 分享时间
     SharedAt *string `json:"SharedAt" column:"shared_at" form:"SharedAt"`
+    // 检索返回的文档数量,为0时使用系统配置
+    TopK *string `json:"TopK" column:"top_k" form:"TopK"`
     // 模板类型
     Type *string `json:"Type" column:"type" form:"Type"`
     // 更新时间
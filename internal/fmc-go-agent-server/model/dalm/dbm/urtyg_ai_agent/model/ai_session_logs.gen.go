@@ -6,16 +6,26 @@ package model
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 const TableNameAi_session_logs = "ai_session_logs"
 
 // Ai_session_logs mapped from table <ai_session_logs>
 type Ai_session_logs struct {
-	ID       string    `gorm:"column:id;type:varchar(255);primaryKey;comment:会话记录id" json:"id"`             // 会话记录id
-	UserID   string    `gorm:"column:user_id;type:varchar(60);not null;comment:用户id" json:"user_id"`        // 用户id
-	PromptID string    `gorm:"column:prompt_id;type:varchar(60);not null;comment:提示词模板id" json:"prompt_id"` // 提示词模板id
-	LastAt   time.Time `gorm:"column:last_at;type:timestamp;comment:最后时间" json:"last_at"`                   // 最后时间
+	ID          string         `gorm:"column:id;type:varchar(255);primaryKey;comment:会话记录id" json:"id"`                                     // 会话记录id
+	UserID      string         `gorm:"column:user_id;type:varchar(60);not null;index:idx_user_last,priority:1;comment:用户id" json:"user_id"` // 用户id
+	PromptID    string         `gorm:"column:prompt_id;type:varchar(60);not null;comment:提示词模板id" json:"prompt_id"`                         // 提示词模板id
+	Model       string         `gorm:"column:model;type:varchar(100);comment:会话指定的模型,为空时使用提示词模板的模型" json:"model"`                           // 会话指定的模型,为空时使用提示词模板的模型
+	Title       string         `gorm:"column:title;type:varchar(200);comment:会话标题" json:"title"`                                            // 会话标题
+	TitleSource string         `gorm:"column:title_source;type:varchar(10);comment:标题来源(auto-首条消息,llm-模型生成,user-用户修改)" json:"title_source"` // 标题来源(auto-首条消息,llm-模型生成,user-用户修改)
+	Pinned      bool           `gorm:"column:pinned;type:tinyint(1);not null;default:0;comment:是否置顶" json:"pinned"`                         // 是否置顶
+	Archived    bool           `gorm:"column:archived;type:tinyint(1);not null;default:0;comment:是否归档" json:"archived"`                     // 是否归档
+	Metadata    string         `gorm:"column:metadata;type:text;comment:会话元数据(JSON对象)" json:"metadata"`                                     // 会话元数据(JSON对象)
+	CreatedAt   *time.Time     `gorm:"column:created_at;type:timestamp;comment:创建时间" json:"created_at"`                                     // 创建时间
+	LastAt      time.Time      `gorm:"column:last_at;type:timestamp;index:idx_user_last,priority:2;comment:最后时间" json:"last_at"`            // 最后时间
+	DeletedAt   gorm.DeletedAt `gorm:"column:deleted_at;type:timestamp;index:idx_deleted_at,priority:1;comment:删除时间" json:"deleted_at"`     // 删除时间
 }
 
 // TableName Ai_session_logs's table name
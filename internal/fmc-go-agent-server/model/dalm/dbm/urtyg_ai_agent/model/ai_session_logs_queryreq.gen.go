@@ -10,12 +10,28 @@ import (
 
 // Ai_session_logs_QueryReq 是用于查询 Ai_session_logs 表的请求结构体
 type Ai_session_logs_QueryReq struct {
+    // 是否归档
+    Archived *string `json:"Archived" column:"archived" form:"Archived"`
+    // 创建时间
+    CreatedAt *string `json:"CreatedAt" column:"created_at" form:"CreatedAt"`
+    // 删除时间
+    DeletedAt *string `json:"DeletedAt" column:"deleted_at" form:"DeletedAt"`
     // 会话记录id
     ID *string `json:"ID" column:"id" form:"ID"`
     // 最后时间
     LastAt *string `json:"LastAt" column:"last_at" form:"LastAt"`
+    // 会话元数据(JSON对象)
+    Metadata *string `json:"Metadata" column:"metadata" form:"Metadata"`
+    // 会话指定的模型,为空时使用提示词模板的模型
+    Model *string `json:"Model" column:"model" form:"Model"`
+    // 是否置顶
+    Pinned *string `json:"Pinned" column:"pinned" form:"Pinned"`
     // 提示词模板id
     PromptID *string `json:"PromptID" column:"prompt_id" form:"PromptID"`
+    // 会话标题
+    Title *string `json:"Title" column:"title" form:"Title"`
+    // 标题来源(auto-首条消息,llm-模型生成,user-用户修改)
+    TitleSource *string `json:"TitleSource" column:"title_source" form:"TitleSource"`
     // 用户id
     UserID *string `json:"UserID" column:"user_id" form:"UserID"`
     // 排序字段，例如 "字段名 asc" 或 "字段名 desc"
@@ -47,7 +47,7 @@ type ai_chat_logs struct {
 	ID        field.String // 记录唯一id
 	UserID    field.String // 用户ID
 	SessionID field.String // 会话唯一标识
-	Role      field.String // 消息角色
+	Role      field.String // 消息角色(user,assistant,system,tool,memo-摘要备忘,marker-会话变更标记)
 	Content   field.String // 消息内容
 	Order     field.Int32  // 消息顺序
 	CreatedAt field.Time   // 消息创建时间
@@ -33,6 +33,11 @@ func newAi_prompt(db *gorm.DB, opts ...gen.DOOption) ai_prompt {
 	_ai_prompt.Name = field.NewString(tableName, "name")
 	_ai_prompt.Description = field.NewString(tableName, "description")
 	_ai_prompt.Content = field.NewString(tableName, "content")
+	_ai_prompt.MemoryMode = field.NewString(tableName, "memory_mode")
+	_ai_prompt.Model = field.NewString(tableName, "model")
+	_ai_prompt.TopK = field.NewInt32(tableName, "top_k")
+	_ai_prompt.MinScore = field.NewFloat64(tableName, "min_score")
+	_ai_prompt.Collections = field.NewString(tableName, "collections")
 	_ai_prompt.IsShared = field.NewBool(tableName, "is_shared")
 	_ai_prompt.SharedAt = field.NewTime(tableName, "shared_at")
 	_ai_prompt.CreatedAt = field.NewTime(tableName, "created_at")
@@ -48,16 +53,21 @@ type ai_prompt struct {
 	ai_promptDo ai_promptDo
 
 	ALL         field.Asterisk
-	ID          field.String // 模板唯一ID
-	UserID      field.String // 创建用户ID
-	Type        field.String // 模板类型
-	Name        field.String // 模板名称
-	Description field.String // 模板描述
-	Content     field.String // 模板内容(md格式)
-	IsShared    field.Bool   // 是否分享(0-私有,1-公开)
-	SharedAt    field.Time   // 分享时间
-	CreatedAt   field.Time   // 创建时间
-	UpdatedAt   field.Time   // 更新时间
+	ID          field.String  // 模板唯一ID
+	UserID      field.String  // 创建用户ID
+	Type        field.String  // 模板类型
+	Name        field.String  // 模板名称
+	Description field.String  // 模板描述
+	Content     field.String  // 模板内容(md格式)
+	MemoryMode  field.String  // 对话记忆策略(空-全部历史,summary-按token预算摘要)
+	Model       field.String  // 默认模型名称,为空时使用系统默认模型
+	TopK        field.Int32   // 检索返回的文档数量,为0时使用系统配置
+	MinScore    field.Float64 // 检索文档的最低分数,为0时使用系统配置
+	Collections field.String  // 可检索的知识库集合,逗号分隔,为空时检索默认集合
+	IsShared    field.Bool    // 是否分享(0-私有,1-公开)
+	SharedAt    field.Time    // 分享时间
+	CreatedAt   field.Time    // 创建时间
+	UpdatedAt   field.Time    // 更新时间
 
 	fieldMap map[string]field.Expr
 }
@@ -80,6 +90,11 @@ func (a *ai_prompt) updateTableName(table string) *ai_prompt {
 	a.Name = field.NewString(table, "name")
 	a.Description = field.NewString(table, "description")
 	a.Content = field.NewString(table, "content")
+	a.MemoryMode = field.NewString(table, "memory_mode")
+	a.Model = field.NewString(table, "model")
+	a.TopK = field.NewInt32(table, "top_k")
+	a.MinScore = field.NewFloat64(table, "min_score")
+	a.Collections = field.NewString(table, "collections")
 	a.IsShared = field.NewBool(table, "is_shared")
 	a.SharedAt = field.NewTime(table, "shared_at")
 	a.CreatedAt = field.NewTime(table, "created_at")
@@ -110,13 +125,18 @@ func (a *ai_prompt) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
 }
 
 func (a *ai_prompt) fillFieldMap() {
-	a.fieldMap = make(map[string]field.Expr, 10)
+	a.fieldMap = make(map[string]field.Expr, 15)
 	a.fieldMap["id"] = a.ID
 	a.fieldMap["user_id"] = a.UserID
 	a.fieldMap["type"] = a.Type
 	a.fieldMap["name"] = a.Name
 	a.fieldMap["description"] = a.Description
 	a.fieldMap["content"] = a.Content
+	a.fieldMap["memory_mode"] = a.MemoryMode
+	a.fieldMap["model"] = a.Model
+	a.fieldMap["top_k"] = a.TopK
+	a.fieldMap["min_score"] = a.MinScore
+	a.fieldMap["collections"] = a.Collections
 	a.fieldMap["is_shared"] = a.IsShared
 	a.fieldMap["shared_at"] = a.SharedAt
 	a.fieldMap["created_at"] = a.CreatedAt
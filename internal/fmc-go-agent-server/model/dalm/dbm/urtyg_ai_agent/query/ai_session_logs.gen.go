@@ -30,7 +30,15 @@ func newAi_session_logs(db *gorm.DB, opts ...gen.DOOption) ai_session_logs {
 	_ai_session_logs.ID = field.NewString(tableName, "id")
 	_ai_session_logs.UserID = field.NewString(tableName, "user_id")
 	_ai_session_logs.PromptID = field.NewString(tableName, "prompt_id")
+	_ai_session_logs.Model = field.NewString(tableName, "model")
+	_ai_session_logs.Title = field.NewString(tableName, "title")
+	_ai_session_logs.TitleSource = field.NewString(tableName, "title_source")
+	_ai_session_logs.Pinned = field.NewBool(tableName, "pinned")
+	_ai_session_logs.Archived = field.NewBool(tableName, "archived")
+	_ai_session_logs.Metadata = field.NewString(tableName, "metadata")
+	_ai_session_logs.CreatedAt = field.NewTime(tableName, "created_at")
 	_ai_session_logs.LastAt = field.NewTime(tableName, "last_at")
+	_ai_session_logs.DeletedAt = field.NewField(tableName, "deleted_at")
 
 	_ai_session_logs.fillFieldMap()
 
@@ -40,11 +48,19 @@ func newAi_session_logs(db *gorm.DB, opts ...gen.DOOption) ai_session_logs {
 type ai_session_logs struct {
 	ai_session_logsDo ai_session_logsDo
 
-	ALL      field.Asterisk
-	ID       field.String // 会话记录id
-	UserID   field.String // 用户id
-	PromptID field.String // 提示词模板id
-	LastAt   field.Time   // 最后时间
+	ALL         field.Asterisk
+	ID          field.String // 会话记录id
+	UserID      field.String // 用户id
+	PromptID    field.String // 提示词模板id
+	Model       field.String // 会话指定的模型,为空时使用提示词模板的模型
+	Title       field.String // 会话标题
+	TitleSource field.String // 标题来源(auto-首条消息,llm-模型生成,user-用户修改)
+	Pinned      field.Bool   // 是否置顶
+	Archived    field.Bool   // 是否归档
+	Metadata    field.String // 会话元数据(JSON对象)
+	CreatedAt   field.Time   // 创建时间
+	LastAt      field.Time   // 最后时间
+	DeletedAt   field.Field  // 删除时间
 
 	fieldMap map[string]field.Expr
 }
@@ -64,7 +80,15 @@ func (a *ai_session_logs) updateTableName(table string) *ai_session_logs {
 	a.ID = field.NewString(table, "id")
 	a.UserID = field.NewString(table, "user_id")
 	a.PromptID = field.NewString(table, "prompt_id")
+	a.Model = field.NewString(table, "model")
+	a.Title = field.NewString(table, "title")
+	a.TitleSource = field.NewString(table, "title_source")
+	a.Pinned = field.NewBool(table, "pinned")
+	a.Archived = field.NewBool(table, "archived")
+	a.Metadata = field.NewString(table, "metadata")
+	a.CreatedAt = field.NewTime(table, "created_at")
 	a.LastAt = field.NewTime(table, "last_at")
+	a.DeletedAt = field.NewField(table, "deleted_at")
 
 	a.fillFieldMap()
 
@@ -93,11 +117,19 @@ func (a *ai_session_logs) GetFieldByName(fieldName string) (field.OrderExpr, boo
 }
 
 func (a *ai_session_logs) fillFieldMap() {
-	a.fieldMap = make(map[string]field.Expr, 4)
+	a.fieldMap = make(map[string]field.Expr, 12)
 	a.fieldMap["id"] = a.ID
 	a.fieldMap["user_id"] = a.UserID
 	a.fieldMap["prompt_id"] = a.PromptID
+	a.fieldMap["model"] = a.Model
+	a.fieldMap["title"] = a.Title
+	a.fieldMap["title_source"] = a.TitleSource
+	a.fieldMap["pinned"] = a.Pinned
+	a.fieldMap["archived"] = a.Archived
+	a.fieldMap["metadata"] = a.Metadata
+	a.fieldMap["created_at"] = a.CreatedAt
 	a.fieldMap["last_at"] = a.LastAt
+	a.fieldMap["deleted_at"] = a.DeletedAt
 }
 
 func (a ai_session_logs) clone(db *gorm.DB) ai_session_logs {
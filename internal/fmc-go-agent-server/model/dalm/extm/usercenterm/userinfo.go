@@ -0,0 +1,15 @@
+package usercenterm
+
+type GetUserInfoReq struct {
+	TokenId string `json:"token_id"`
+}
+
+// GetUserInfoResp 令牌对应的登录用户,用户信息与登录返回的用户信息一致
+type GetUserInfoResp struct {
+	Code      int               `json:"code"`
+	Msg       string            `json:"msg"`
+	Data      *Sm2LoginRespData `json:"data"`
+	Total     int               `json:"total"`
+	Timestamp int64             `json:"timestamp"`
+	Success   bool              `json:"success"`
+}
@@ -0,0 +1,35 @@
+package knowdbm
+
+type CreateCollectionReq struct {
+	Name        string `json:"name"`        // 集合名称,只允许字母、数字、下划线与中划线
+	Description string `json:"description"` // 集合描述
+	Private     bool   `json:"private"`     // 是否为用户私有集合,私有集合只有创建用户可检索
+	UserID      string `json:"-"`           // 登录用户ID,由令牌解析,为私有集合的所有者
+}
+
+type GetCollectionsReq struct {
+	UserID string `json:"-" form:"-"` // 登录用户ID,由令牌解析,返回公开集合与该用户的私有集合
+}
+
+type GetCollectionsResp struct {
+	List []*CollectionInfo `json:"list"`
+}
+
+type DeleteCollectionReq struct {
+	Name   string `json:"name"` // 集合名称
+	UserID string `json:"-"`    // 登录用户ID,由令牌解析,私有集合只有创建用户可删除
+}
+
+type DeleteCollectionResp struct {
+	Files  int `json:"files"`  // 删除的文件数量
+	Chunks int `json:"chunks"` // 删除的分片数量
+}
+
+// CollectionInfo 知识库集合
+type CollectionInfo struct {
+	Name        string `json:"name"`        // 集合名称
+	Description string `json:"description"` // 集合描述
+	Private     bool   `json:"private"`     // 是否为用户私有集合
+	Owner       string `json:"owner"`       // 私有集合的创建用户ID
+	Created     string `json:"created"`     // 创建时间
+}
@@ -1,7 +1,8 @@
 package knowdbm
 
 type DeleteFilesReq struct {
-	Ids []string `json:"ids"`
+	Ids    []string `json:"ids"`
+	UserID string   `json:"-"` // 登录用户ID,由令牌解析,其他用户私有集合的文件不可删除
 }
 type DeleteFilesResp struct {
 }
@@ -1,9 +1,11 @@
 package knowdbm
 
 type GetFileListReq struct {
-	Type     string `json:"type"`
-	Page     int32  `json:"page"`
-	PageSize int32  `json:"pageSize"`
+	Type       string `json:"type"`
+	Page       int32  `json:"page"`
+	PageSize   int32  `json:"pageSize"`
+	Collection string `json:"collection"` // 集合名称,为空时返回所有可见集合的文件
+	UserID     string `json:"-"`          // 登录用户ID,由令牌解析,其他用户私有集合的文件不返回
 }
 
 type GetFileListResp struct {
@@ -14,10 +16,25 @@ type TypeList struct {
 	FileList []*TFileInfo `json:"fileList"`
 }
 type TFileInfo struct {
-	Id   string `json:"id"`
-	Name string `json:"name"`
-	Type string `json:"type"`
-	Path string `json:"path"`
-	Size string `json:"size"`
-	Date string `json:"date"`
+	Id         string      `json:"id"`
+	Name       string      `json:"name"`
+	Type       string      `json:"type"`
+	Path       string      `json:"path"`
+	Size       string      `json:"size"`
+	Date       string      `json:"date"`
+	Status     IndexStatus `json:"status"`     // 向量索引状态
+	Chunks     int         `json:"chunks"`     // 向量数据库中的分片数量
+	Error      string      `json:"error"`      // 索引失败原因
+	Collection string      `json:"collection"` // 所属的知识库集合
 }
+
+// IndexStatus 文件的向量索引状态
+type IndexStatus string
+
+const (
+	IndexPending  IndexStatus = "pending"  // 等待索引
+	IndexIndexing IndexStatus = "indexing" // 索引中
+	IndexIndexed  IndexStatus = "indexed"  // 已索引
+	IndexFailed   IndexStatus = "failed"   // 索引失败
+	IndexSkipped  IndexStatus = "skipped"  // 文件类型不支持索引
+)
@@ -0,0 +1,67 @@
+package knowdbm
+
+type SearchReq struct {
+	Query      string `json:"query"`      // 检索文本
+	Collection string `json:"collection"` // 集合名称,为空时检索所有可见集合
+	TopK       int    `json:"topK"`       // 返回数量,默认5
+	FileType   string `json:"fileType"`   // 文件类型过滤,如 md、pdf
+	Header     string `json:"header"`     // 标题路径过滤,如 标题 > 章,与分片的标题路径完全一致
+	UserID     string `json:"-"`          // 登录用户ID,由令牌解析,可检索公开集合与该用户的私有集合
+}
+
+type SearchResp struct {
+	List []*ChunkInfo `json:"list"`
+}
+
+type GetChunksReq struct {
+	FileID string `json:"fileId" form:"fileId"` // 文件Id
+	UserID string `json:"-" form:"-"`           // 登录用户ID,由令牌解析,其他用户私有集合的文件不可查看
+}
+
+type GetChunksResp struct {
+	File *TFileInfo   `json:"file"` // 文件信息
+	List []*ChunkInfo `json:"list"` // 按索引顺序排列的分片
+}
+
+// ChunkInfo 向量数据库中的分片
+type ChunkInfo struct {
+	Id         string         `json:"id"`              // 分片Id
+	Content    string         `json:"content"`         // 分片内容
+	Score      float64        `json:"score,omitempty"` // 余弦相似度,只在检索结果中返回
+	FileId     string         `json:"fileId"`          // 来源文件Id
+	Collection string         `json:"collection"`      // 所属集合
+	FileType   string         `json:"fileType"`        // 文件类型
+	Header     string         `json:"header"`          // 标题路径
+	MetaData   map[string]any `json:"metaData"`        // 分片元数据
+}
+
+type GetStatsResp struct {
+	Files       int                `json:"files"`       // 已索引的文件数量
+	Chunks      int                `json:"chunks"`      // 文件清单记录的分片数量
+	Collections []*CollectionStats `json:"collections"` // 各集合的文件与分片数量
+	Embedding   *EmbeddingInfo     `json:"embedding"`   // 嵌入模型
+	Index       *IndexStats        `json:"index"`       // 向量索引
+}
+
+type CollectionStats struct {
+	Name   string `json:"name"`   // 集合名称
+	Files  int    `json:"files"`  // 已索引的文件数量
+	Chunks int    `json:"chunks"` // 分片数量
+}
+
+type EmbeddingInfo struct {
+	Provider  string `json:"provider"`  // 模型提供商
+	Model     string `json:"model"`     // 模型名称
+	Dimension int    `json:"dimension"` // 向量维度
+}
+
+// IndexStats 向量索引信息,RedisStack取自 FT.INFO
+type IndexStats struct {
+	Backend      string  `json:"backend"`      // 存储后端,redis或local
+	Docs         int     `json:"docs"`         // 索引中的分片数量
+	Dimension    int     `json:"dimension"`    // 向量维度
+	SizeMB       float64 `json:"sizeMB"`       // 索引大小
+	VectorSizeMB float64 `json:"vectorSizeMB"` // 向量索引大小
+	Failures     int     `json:"failures"`     // 索引失败的分片数量
+	Indexing     bool    `json:"indexing"`     // 是否正在建立索引
+}
@@ -0,0 +1,6 @@
+package knowdbm
+
+type UploadFilesReq struct {
+	Collection string `json:"collection" form:"collection"` // 上传到的集合,为空时为默认集合
+	UserID     string `json:"-" form:"-"`                   // 登录用户ID,由令牌解析,私有集合只有创建用户可上传
+}
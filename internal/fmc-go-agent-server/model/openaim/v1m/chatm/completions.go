@@ -1,5 +1,10 @@
 package chatm
 
+import (
+	"encoding/json"
+	"errors"
+)
+
 type ChatCompletionsReq struct {
 	// 默认为 0 -2.0 到 2.0 之间的数字。正值根据文本目前的存在频率惩罚新标记,降低模型重复相同行的可能性。  有关频率和存在惩罚的更多信息。
 	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
@@ -15,7 +20,12 @@ type ChatCompletionsReq struct {
 	MaxTokens *int64 `json:"max_tokens,omitempty"`
 	// 至今为止对话所包含的消息列表。Python 代码示例。
 	Messages []Message `json:"messages"`
-	// 要使用的模型的 ID。有关哪些模型可与聊天 API 一起使用的详细信息,请参阅模型端点兼容性表。
+	// 扩展字段：客户端消息列表与服务端会话历史的组合方式。
+	// 为空时自动判断：客户端携带多轮对话则以客户端为准(replace)，否则使用服务端会话历史(merge)；
+	// server 仅使用服务端会话历史并只取最后一条消息；merge 服务端历史在前、客户端历史在后；replace 忽略服务端会话历史。
+	// 客户端的 system 消息在 merge/replace 模式下追加到提示词模板之后。
+	HistoryMode string `json:"history_mode,omitempty"`
+	// 要使用的模型的 ID,取值为 /openai/v1/models 返回的模型名称,为空时使用提示词模板指定的模型或默认模型。
 	Model string `json:"model"`
 	// 默认为 1
 	// 为每个输入消息生成多少个聊天补全选择。
@@ -30,35 +40,82 @@ type ChatCompletionsReq struct {
 	ResponseFormat map[string]interface{} `json:"response_format,omitempty"`
 	// 此功能处于测试阶段。如果指定,我们的系统将尽最大努力确定性地进行采样,以便使用相同的种子和参数进行重复请求应返回相同的结果。不能保证确定性,您应该参考
 	// system_fingerprint 响应参数来监控后端的更改。
-	Seen *int64 `json:"seen,omitempty"`
+	Seed *int64 `json:"seed,omitempty"`
 	// 默认为 null 最多 4 个序列,API 将停止进一步生成标记。
-	Stop *string `json:"stop,omitempty"`
+	Stop StopWords `json:"stop,omitempty"`
 	// 默认为 false 如果设置,则像在 ChatGPT 中一样会发送部分消息增量。标记将以仅数据的服务器发送事件的形式发送,这些事件在可用时,并在 data: [DONE]
 	// 消息终止流。Python 代码示例。
 	Stream *bool `json:"stream,omitempty"`
+	// 扩展字段：流式输出时是否推送代理执行过程的结构化事件。
+	// 开启后以具名SSE事件输出：event: tool_call 模型决定调用工具及参数，event: tool_result 工具返回结果，event: answer 最终回答的增量(数据为标准的 chat.completion.chunk)，event: citations 回答引用的知识库文档。
+	StreamEvents bool `json:"stream_events,omitempty"`
 	// 使用什么采样温度，介于 0 和 2 之间。较高的值（如 0.8）将使输出更加随机，而较低的值（如 0.2）将使输出更加集中和确定。
 	// 我们通常建议改变这个或`top_p`但不是两者。
 	Temperature *float32 `json:"temperature,omitempty"`
 	// 控制模型调用哪个函数(如果有的话)。none 表示模型不会调用函数,而是生成消息。auto 表示模型可以在生成消息和调用函数之间进行选择。通过 {"type":
 	// "function", "function": {"name": "my_function"}} 强制模型调用该函数。  如果没有函数存在,默认为
 	// none。如果有函数存在,默认为 auto。  显示可能的类型
-	ToolChoice map[string]interface{} `json:"tool_choice"`
+	ToolChoice interface{} `json:"tool_choice,omitempty"`
 	// 模型可以调用的一组工具列表。目前,只支持作为工具的函数。使用此功能来提供模型可以为之生成 JSON 输入的函数列表。
-	Tools []string `json:"tools"`
+	Tools []Tool `json:"tools,omitempty"`
 	// 一种替代温度采样的方法，称为核采样，其中模型考虑具有 top_p 概率质量的标记的结果。所以 0.1 意味着只考虑构成前 10% 概率质量的标记。
 	// 我们通常建议改变这个或`temperature`但不是两者。
-	TopP *int64 `json:"top_p,omitempty"`
+	TopP *float32 `json:"top_p,omitempty"`
 	// 代表您的最终用户的唯一标识符，可以帮助 OpenAI
 	// 监控和检测滥用行为。[了解更多](https://platform.openai.com/docs/guides/safety-best-practices/end-user-ids)。
 	User *string `json:"user,omitempty"`
 }
 
+// StopWords 停止词,兼容OpenAI的字符串与字符串数组两种写法
+type StopWords []string
+
+func (s *StopWords) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		if one != "" {
+			*s = StopWords{one}
+		}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return errors.New("stop must be a string or an array of strings")
+	}
+	*s = many
+	return nil
+}
+
 type ResChatCompletions struct {
 	Choices []Choice              `json:"choices"`
 	Created int64                 `json:"created"`
 	ID      string                `json:"id"`
 	Object  string                `json:"object"`
 	Usage   *ChatCompletionsUsage `json:"usage"`
+	// 扩展字段：回答引用的知识库文档，回答中以 [序号] 标注
+	Citations []Citation `json:"citations,omitempty"`
+}
+
+// Citation 回答引用的知识库文档
+type Citation struct {
+	// 引用序号，与回答中的 [序号] 对应
+	Index int `json:"index"`
+	// 分片ID
+	ChunkID string `json:"chunk_id"`
+	// 文件ID，可通过 /knowdb/files/download?id= 下载
+	FileID string `json:"file_id,omitempty"`
+	// 文件名称
+	FileName string `json:"file_name,omitempty"`
+	// 文件所属集合
+	Collection string `json:"collection,omitempty"`
+	// 文档中的标题、章、节、小节
+	Title      string `json:"title,omitempty"`
+	Chapter    string `json:"chapter,omitempty"`
+	Section    string `json:"section,omitempty"`
+	Subsection string `json:"subsection,omitempty"`
+	// CSV文件按行索引时的行号
+	Row int `json:"row,omitempty"`
+	// 重排序分数或向量相似度，只由全文检索命中时为0
+	Score float64 `json:"score"`
 }
 
 type Choice struct {
@@ -70,6 +127,42 @@ type Choice struct {
 type Message struct {
 	Content string `json:"content"`
 	Role    string `json:"role"`
+	// 发送者名称,可选
+	Name string `json:"name,omitempty"`
+	// assistant 消息中模型发起的工具调用
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// tool 消息对应的工具调用ID
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// Tool 客户端声明的工具,目前只支持函数
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction 函数工具的定义
+type ToolFunction struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// 函数参数的 JSON Schema
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+	Strict     *bool           `json:"strict,omitempty"`
+}
+
+// ToolCall 模型发起的工具调用
+type ToolCall struct {
+	// 流式增量中工具调用的序号
+	Index    *int             `json:"index,omitempty"`
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction 工具调用的函数名与参数
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
 }
 
 type ChatCompletionsUsage struct {
@@ -86,9 +179,13 @@ type ResChatCompletionsStream struct {
 	SystemFingerprint string    `json:"system_fingerprint"` //该指纹表示模型运行的后端配置
 	Choices           []Choices `json:"choices"`
 	Usage             *Usage    `json:"usage"`
+	// 扩展字段：回答引用的知识库文档，在最终回答之后以 choices 为空的分片输出
+	Citations []Citation `json:"citations,omitempty"`
 }
 type Delta struct {
-	Content string `json:"content"`
+	Role      string     `json:"role,omitempty"`
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 }
 type Choices struct {
 	Index        int         `json:"index"`
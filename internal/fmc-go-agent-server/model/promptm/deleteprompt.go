@@ -1,7 +1,8 @@
 package promptm
 
 type DeleteReq struct {
-	Ids []string `json:"ids"`
+	Ids    []string `json:"ids"`
+	UserID string   `json:"-"` // 登录用户ID,由令牌解析,只能删除自己创建的模板
 }
 type DeleteResp struct {
 }
@@ -1,6 +1,14 @@
 package promptm
 
 type ModifySessionPromptReq struct {
+	SessionId string `json:"sessionId"` // 会话Id
+	PromptId  string `json:"promptId"`  // 切换到的提示词模板Id,可使用内置模板、共享模板与自己创建的模板
+	Model     string `json:"model"`     // 会话使用的模型,为空时使用提示词模板指定的模型
+	UserID    string `json:"-"`         // 登录用户ID,由令牌解析,只能修改自己的会话
 }
 type ModifySessionPromptResp struct {
+	SessionId  string `json:"sessionId"`  // 会话Id
+	PromptId   string `json:"promptId"`   // 提示词模板Id
+	PromptName string `json:"promptName"` // 提示词模板名称
+	Model      string `json:"model"`      // 会话使用的模型,为空时使用默认模型
 }
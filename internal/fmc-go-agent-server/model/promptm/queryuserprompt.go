@@ -20,8 +20,8 @@ type QueryReq struct {
 	Type *string `json:"Type" column:"type" form:"Type"`
 	// 更新时间
 	UpdatedAt *string `json:"UpdatedAt" column:"updated_at" form:"UpdatedAt"`
-	// 创建用户ID
-	UserID *string `json:"UserID" column:"user_id" form:"UserID"`
+	// 创建用户ID,为登录用户ID,由令牌解析
+	UserID *string `json:"-" column:"user_id" form:"-"`
 	// 排序字段，例如 "字段名 asc" 或 "字段名 desc"
 	OrderBy *string `json:"OrderBy" form:"OrderBy"`
 	// 是否模糊查询
@@ -42,6 +42,11 @@ type QueryRespData struct {
 	Name        string     `json:"name"`        // 模板名称
 	Description string     `json:"description"` // 模板描述
 	Content     string     `json:"content"`     // 模板内容
+	MemoryMode  string     `json:"memoryMode"`  // 对话记忆策略
+	Model       string     `json:"model"`       // 默认模型名称
+	TopK        int        `json:"topK"`        // 检索返回的文档数量
+	MinScore    float64    `json:"minScore"`    // 检索文档的最低分数
+	Collections []string   `json:"collections"` // 可检索的知识库集合
 	CreatedAt   *time.Time `json:"created_at"`  // 创建时间
 	UpdatedAt   *time.Time `json:"updated_at"`  // 更新时间
 }
@@ -3,12 +3,17 @@ package promptm
 import "time"
 
 type UpdateReq struct {
-	ID          string `json:"id"`          // 模板唯一ID
-	UserID      string `json:"userID"`      // 创建用户ID
-	Type        string `json:"type"`        // 模板类型
-	Name        string `json:"name"`        // 模板名称
-	Description string `json:"description"` // 模板描述
-	Content     string `json:"content"`     // 模板内容
+	ID          string   `json:"id"`          // 模板唯一ID
+	UserID      string   `json:"-"`           // 登录用户ID,由令牌解析,只能修改自己创建的模板
+	Type        string   `json:"type"`        // 模板类型
+	Name        string   `json:"name"`        // 模板名称
+	Description string   `json:"description"` // 模板描述
+	Content     string   `json:"content"`     // 模板内容
+	MemoryMode  string   `json:"memoryMode"`  // 对话记忆策略:空-全部历史,summary-按token预算摘要
+	Model       string   `json:"model"`       // 默认模型名称,为空时使用系统默认模型
+	TopK        int      `json:"topK"`        // 检索返回的文档数量,为0时使用系统配置
+	MinScore    float64  `json:"minScore"`    // 检索文档的最低分数,为0时使用系统配置
+	Collections []string `json:"collections"` // 可检索的知识库集合,为空时检索默认集合
 }
 type UpdateResp struct {
 	ID          string     `json:"id"`          // 模板唯一ID
@@ -17,6 +22,11 @@ type UpdateResp struct {
 	Name        string     `json:"name"`        // 模板名称
 	Description string     `json:"description"` // 模板描述
 	Content     string     `json:"content"`     // 模板内容
+	MemoryMode  string     `json:"memoryMode"`  // 对话记忆策略
+	Model       string     `json:"model"`       // 默认模型名称
+	TopK        int        `json:"topK"`        // 检索返回的文档数量
+	MinScore    float64    `json:"minScore"`    // 检索文档的最低分数
+	Collections []string   `json:"collections"` // 可检索的知识库集合
 	CreatedAt   *time.Time `json:"created_at"`  // 创建时间
 	UpdatedAt   *time.Time `json:"updated_at"`  // 更新时间
 }
@@ -1,7 +1,7 @@
 package sessionm
 
 type CreatSessionReq struct {
-	UserId string `json:"userId"`
+	UserId string `json:"-"` // 登录用户ID,由令牌解析
 }
 type CreatSessionResp struct {
 	SessionId string `json:"sessionId"`
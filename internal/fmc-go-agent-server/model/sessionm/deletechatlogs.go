@@ -1,7 +1,8 @@
 package sessionm
 
 type DeleteChatLogsReq struct {
-	IDs []string `json:"ids"`
+	IDs    []string `json:"ids"`
+	UserId string   `json:"-"` // 登录用户ID,由令牌解析
 }
 type DeleteChatLogsResp struct {
 }
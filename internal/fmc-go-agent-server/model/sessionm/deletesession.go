@@ -2,6 +2,7 @@ package sessionm
 
 type DeleteSessionsReq struct {
 	SessionIds []string `json:"sessionIds"`
+	UserId     string   `json:"-"` // 登录用户ID,由令牌解析
 }
 
 type DeleteSessionsResp struct {
@@ -3,7 +3,7 @@ package sessionm
 import "github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/dalm/dbm/urtyg_ai_agent/model"
 
 type QuerySessionChatLogsByUserReq struct {
-	UserId     string  `json:"userId"`     // 用户id
+	UserId     string  `json:"-"`          // 登录用户ID,由令牌解析
 	PromptType *string `json:"promptType"` // 提示词类型
 }
 
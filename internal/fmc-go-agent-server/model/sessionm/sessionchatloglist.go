@@ -6,6 +6,7 @@ import (
 
 type SessionChatLogListReq struct {
 	SessionId string `json:"sessionId"`
+	UserId    string `json:"-"` // 登录用户ID,由令牌解析
 }
 type SessionChatLogListResp struct {
 	SessionId string                `json:"sessionId"`
@@ -0,0 +1,12 @@
+package sessionm
+
+type UpdateSessionReq struct {
+	SessionId string         `json:"sessionId"` // 会话Id
+	Title     *string        `json:"title"`     // 会话标题,修改后不再由模型生成
+	Pinned    *bool          `json:"pinned"`    // 是否置顶
+	Archived  *bool          `json:"archived"`  // 是否归档,归档的会话不在默认的会话列表中
+	Metadata  map[string]any `json:"metadata"`  // 会话元数据,整体替换,为空时不修改
+	UserId    string         `json:"-"`         // 登录用户ID,由令牌解析
+}
+
+type UpdateSessionResp = UserSessionListRespData
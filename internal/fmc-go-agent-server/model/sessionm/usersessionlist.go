@@ -6,15 +6,23 @@ import (
 )
 
 type UserSessionListReq struct {
-	UserId string    `json:"userId"`
-	Page   *dbm.Page `json:"page"`
+	UserId   string    `json:"-"`        // 登录用户ID,由令牌解析
+	Archived bool      `json:"archived"` // 是否列出已归档的会话,默认列出未归档的会话
+	Keyword  string    `json:"keyword"`  // 按标题模糊查询
+	Page     *dbm.Page `json:"page"`     // 按会话分页,为空时返回所有会话
 }
 type UserSessionListResp struct {
-	SessionList []*UserSessionListRespData `json:"sessionList"`
+	SessionList []*UserSessionListRespData `json:"sessionList"` // 置顶的会话在前,其余按最后对话时间倒序
 	Page        *dbm.Page                  `json:"page"`
 }
 type UserSessionListRespData struct {
-	SessionId string     `json:"sessionId"`
-	Title     string     `json:"title"`
-	CreatAt   *time.Time `json:"creatAt"`
+	SessionId string         `json:"sessionId"` // 会话Id
+	Title     string         `json:"title"`     // 会话标题
+	PromptId  string         `json:"promptId"`  // 提示词模板Id
+	Model     string         `json:"model"`     // 会话指定的模型,为空时使用提示词模板的模型
+	Pinned    bool           `json:"pinned"`    // 是否置顶
+	Archived  bool           `json:"archived"`  // 是否归档
+	Metadata  map[string]any `json:"metadata"`  // 会话元数据
+	CreatAt   *time.Time     `json:"creatAt"`   // 创建时间
+	LastAt    time.Time      `json:"lastAt"`    // 最后对话时间
 }
@@ -2,12 +2,18 @@ package router
 
 import (
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/controller"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
 	"github.com/gin-gonic/gin"
 )
 
 func BindRoute(g *gin.Engine, c controller.If) {
-	// 注入 用户中心Tokenid的校验
-	g.Use(c.MidInvalidTokenIdByUserCenter())
+	// 注入 请求凭证的认证,认证方式见认证配置
+	g.Use(c.MidAuth())
+
+	// auth
+	auth := g.Group("/auth")
+	auth.GET("/me", c.Auth().Me)
+	auth.POST("/token", c.Auth().IssueToken)
 
 	// openai
 	openai := g.Group("/openai")
@@ -17,28 +23,38 @@ func BindRoute(g *gin.Engine, c controller.If) {
 	// openai/chat
 	chat := openaiv1.Group("/chat")
 	chat.POST("completions", c.OpenAi().V1().Chat().Completions)
+	chat.POST("cancel", c.OpenAi().V1().Chat().Cancel)
 
 	// session
 	session := g.Group("/session")
 	session.POST("creatSession", c.Session().CreatSession)
 	session.POST("userSessionList", c.Session().UserSessionList)
 	session.POST("sessionChatLogList", c.Session().SessionChatLogList)
+	session.POST("updateSession", c.Session().UpdateSession)
 	session.DELETE("deleteSessions", c.Session().DeleteSessions)
 	session.DELETE("deleteChatLogs", c.Session().DeleteChatLogs)
 	session.POST("querySessionChatLogsByUser", c.Session().QuerySessionChatLogsByUser)
 
 	// knowdb
+	// 修改知识库需要knowdb:write权限,公开集合及其文件另需knowdb:admin权限(由服务校验)
 	knowdb := g.Group("/knowdb")
 	knowdb.GET("/files", c.KnowDb().GetFileList)
-	knowdb.DELETE("/files", c.KnowDb().DeleteFiles)
+	knowdb.DELETE("/files", c.Require(authm.PermKnowDbWrite), c.KnowDb().DeleteFiles)
 	knowdb.GET("/files/download", c.KnowDb().DownloadFile)
-	knowdb.POST("/files", c.KnowDb().UploadFiles)
+	knowdb.POST("/files", c.Require(authm.PermKnowDbWrite), c.KnowDb().UploadFiles)
+	knowdb.GET("/collections", c.KnowDb().GetCollections)
+	knowdb.POST("/collections", c.Require(authm.PermKnowDbWrite), c.KnowDb().CreateCollection)
+	knowdb.DELETE("/collections", c.Require(authm.PermKnowDbWrite), c.KnowDb().DeleteCollection)
+	knowdb.POST("/search", c.KnowDb().Search)
+	knowdb.GET("/chunks", c.KnowDb().GetChunks)
+	knowdb.GET("/stats", c.Require(authm.PermKnowDbAdmin), c.KnowDb().GetStats)
 
 	prompt := g.Group("/prompt")
 	prompt.GET("/getPromptTemplate", c.Prompt().GetPromptTemplate)
 	prompt.POST("/creat", c.Prompt().Creat)
 	prompt.POST("/delete", c.Prompt().Delete)
 	prompt.POST("/update", c.Prompt().Update)
+	prompt.POST("/modifySessionPrompt", c.Prompt().ModifySessionPrompt)
 	prompt.POST("/query", c.Prompt().Query)
 
 }
@@ -0,0 +1,59 @@
+package authsrv
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
+	"strings"
+)
+
+// ApiKeyPrefix API密钥的前缀,与OpenAI的密钥格式一致,OpenAI SDK可直接使用
+const ApiKeyPrefix = "sk-"
+
+// apiKeyAuth API密钥认证,供服务间调用,密钥取自Bearer令牌
+type apiKeyAuth struct {
+	keys []*config.ApiKeyOption
+}
+
+func newApiKeyAuth(keys []*config.ApiKeyOption) *apiKeyAuth {
+	res := &apiKeyAuth{keys: make([]*config.ApiKeyOption, 0, len(keys))}
+	for _, k := range keys {
+		if k == nil || k.Key == "" {
+			continue
+		}
+		if !strings.HasPrefix(k.Key, ApiKeyPrefix) {
+			panic("API密钥须以" + ApiKeyPrefix + "开头: " + k.Name)
+		}
+		res.keys = append(res.keys, k)
+	}
+	return res
+}
+
+func (a *apiKeyAuth) name() string {
+	return config.AuthApiKey
+}
+
+func (a *apiKeyAuth) authenticate(ctx context.Context, cred authm.Credential) (*authm.Principal, error) {
+	if !strings.HasPrefix(cred.Bearer, ApiKeyPrefix) {
+		return nil, errSkip
+	}
+	for _, k := range a.keys {
+		// 按常量时间比较,避免按耗时猜测密钥
+		if subtle.ConstantTimeCompare([]byte(k.Key), []byte(cred.Bearer)) != 1 {
+			continue
+		}
+		id := k.UserID
+		if id == "" {
+			id = config.AuthApiKey + ":" + k.Name
+		}
+		return &authm.Principal{
+			ID:      id,
+			Name:    k.Name,
+			Account: k.Name,
+			Roles:   k.Roles,
+		}, nil
+	}
+	return nil, errors.New("API密钥无效")
+}
@@ -0,0 +1,13 @@
+package authsrv
+
+import (
+	"context"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
+)
+
+type If interface {
+	// Authenticate 按配置的认证方式依次认证请求凭证,返回登录用户及其权限
+	Authenticate(ctx context.Context, cred authm.Credential) (*authm.Principal, error)
+	// IssueToken 为登录用户签发本地JWT,供不依赖用户中心的客户端使用
+	IssueToken(ctx context.Context, req authm.IssueTokenReq) (*authm.IssueTokenResp, error)
+}
@@ -0,0 +1,111 @@
+package authsrv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
+	"github.com/freedqo/fmc-go-agent/pkg/ujwt"
+	"github.com/golang-jwt/jwt/v5"
+	"strings"
+)
+
+// jwtAuth 本地签发的JWT认证,签发者与配置一致的Bearer令牌由其认证
+type jwtAuth struct {
+	opt  *config.JwtOption
+	uJwt ujwt.If
+}
+
+// tokenOption 本地JWT携带的角色与权限编码,记录在令牌的option声明中
+type tokenOption struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+	Account     string   `json:"account,omitempty"` // 用户中心账号,只为用户中心认证的用户记录,用于识别管理员
+}
+
+func newJwtAuth(opt *config.JwtOption) *jwtAuth {
+	if opt.Issuer == "" {
+		opt.Issuer = config.AppName
+	}
+	return &jwtAuth{
+		opt:  opt,
+		uJwt: ujwt.New(opt.Issuer, opt.Secret),
+	}
+}
+
+func (a *jwtAuth) name() string {
+	return config.AuthJwt
+}
+
+func (a *jwtAuth) authenticate(ctx context.Context, cred authm.Credential) (*authm.Principal, error) {
+	if cred.Bearer == "" || issuer(cred.Bearer) != a.opt.Issuer {
+		return nil, errSkip
+	}
+	claims, err := a.uJwt.VerifyToken(cred.Bearer)
+	if err != nil {
+		return nil, err
+	}
+	p := &authm.Principal{
+		ID:   claims.UserId,
+		Name: claims.UserName,
+	}
+	if claims.Option != "" {
+		opt := &tokenOption{}
+		if err = json.Unmarshal([]byte(claims.Option), opt); err != nil {
+			return nil, errors.New("令牌的角色与权限格式错误")
+		}
+		p.Roles = opt.Roles
+		p.Permissions = opt.Permissions
+		p.Account = opt.Account
+	}
+	return p, nil
+}
+
+// issue 签发令牌,有效时长为0或超过配置的最长时长时为最长时长;所有权限不写入令牌,由认证时的权限配置决定
+func (a *jwtAuth) issue(p *authm.Principal, expire int) (*authm.IssueTokenResp, error) {
+	if a.opt.Expire > 0 && (expire <= 0 || expire > a.opt.Expire) {
+		expire = a.opt.Expire
+	}
+	opt := &tokenOption{
+		Roles:       p.Roles,
+		Permissions: make([]string, 0, len(p.Permissions)),
+	}
+	if p.AuthBy == config.AuthUserCenter || p.AuthBy == config.AuthJwt {
+		opt.Account = p.Account
+	}
+	for _, v := range p.Permissions {
+		if v != authm.PermAll {
+			opt.Permissions = append(opt.Permissions, v)
+		}
+	}
+	b, err := json.Marshal(opt)
+	if err != nil {
+		return nil, err
+	}
+	// 管理员按option声明中的用户中心账号识别,令牌的用户名只用于展示
+	token, err := a.uJwt.GenTokenWithOption(p.Account, p.ID, string(b), expire)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := a.uJwt.VerifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return &authm.IssueTokenResp{
+		Token:     token,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// issuer 不校验签名读取JWT的签发者,不是JWT时为空
+func issuer(token string) string {
+	if strings.Count(token, ".") != 2 {
+		return ""
+	}
+	claims := &jwt.RegisteredClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return ""
+	}
+	return claims.Issuer
+}
@@ -0,0 +1,38 @@
+package authsrv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
+)
+
+func TestJwtAccount(t *testing.T) {
+	tests := []struct {
+		name   string
+		authBy string
+		want   string // 本地JWT认证后的账号
+	}{
+		{"user center", config.AuthUserCenter, "root"},
+		{"reissued local jwt", config.AuthJwt, "root"},
+		{"oidc", config.AuthOidc, ""},
+		{"api key", config.AuthApiKey, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newJwtAuth(&config.JwtOption{Secret: "secret", Expire: 3600})
+			resp, err := a.issue(&authm.Principal{ID: "1", Name: "root", Account: "root", AuthBy: tt.authBy}, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := a.authenticate(context.Background(), authm.Credential{Bearer: resp.Token})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Account != tt.want {
+				t.Errorf("Account = %q, want %q", p.Account, tt.want)
+			}
+		})
+	}
+}
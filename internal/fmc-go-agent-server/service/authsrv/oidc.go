@@ -0,0 +1,125 @@
+package authsrv
+
+import (
+	"context"
+	"fmt"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
+	"github.com/freedqo/fmc-go-agent/pkg/ujwt"
+	"github.com/golang-jwt/jwt/v5"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oidcAuth OIDC认证,签发者与配置一致的Bearer令牌按身份提供方的JWKS校验签名
+type oidcAuth struct {
+	opt    *config.OidcOption
+	parser *jwt.Parser
+	mu     sync.Mutex
+	jwks   *ujwt.JWKS // 首次认证时创建,身份提供方不可用时下次认证重试
+}
+
+func newOidcAuth(opt *config.OidcOption) *oidcAuth {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(opt.Issuer),
+		jwt.WithExpirationRequired(),
+	}
+	if opt.Audience != "" {
+		opts = append(opts, jwt.WithAudience(opt.Audience))
+	}
+	if opt.UserClaim == "" {
+		opt.UserClaim = "sub"
+	}
+	return &oidcAuth{
+		opt:    opt,
+		parser: jwt.NewParser(opts...),
+	}
+}
+
+func (a *oidcAuth) name() string {
+	return config.AuthOidc
+}
+
+func (a *oidcAuth) authenticate(ctx context.Context, cred authm.Credential) (*authm.Principal, error) {
+	if cred.Bearer == "" || issuer(cred.Bearer) != a.opt.Issuer {
+		return nil, errSkip
+	}
+	jwks, err := a.keySet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	if _, err = a.parser.ParseWithClaims(cred.Bearer, claims, jwks.Keyfunc); err != nil {
+		return nil, fmt.Errorf("令牌校验失败: %w", err)
+	}
+	p := &authm.Principal{
+		ID:    claimString(claims, a.opt.UserClaim),
+		Name:  claimString(claims, a.opt.NameClaim),
+		Roles: claimStrings(claims, a.opt.RolesClaim),
+	}
+	if p.ID == "" {
+		return nil, fmt.Errorf("令牌缺少用户ID声明: %s", a.opt.UserClaim)
+	}
+	if p.Name == "" {
+		p.Name = p.ID
+	}
+	p.Account = p.Name
+	return p, nil
+}
+
+// keySet 获取JWKS,未配置JWKS地址时从身份提供方的OIDC配置获取
+func (a *oidcAuth) keySet(ctx context.Context) (*ujwt.JWKS, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.jwks != nil {
+		return a.jwks, nil
+	}
+	url := a.opt.JwksURL
+	if url == "" {
+		var err error
+		if url, err = ujwt.DiscoverJWKS(ctx, a.opt.Issuer); err != nil {
+			return nil, err
+		}
+	}
+	a.jwks = ujwt.NewJWKS(url, time.Duration(a.opt.Refresh)*time.Second)
+	return a.jwks, nil
+}
+
+func claimString(claims jwt.MapClaims, name string) string {
+	if name == "" {
+		return ""
+	}
+	switch v := claims[name].(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	default:
+		return ""
+	}
+}
+
+// claimStrings 角色声明可为字符串数组,或以空格、逗号分隔的字符串
+func claimStrings(claims jwt.MapClaims, name string) []string {
+	if name == "" {
+		return nil
+	}
+	switch v := claims[name].(type) {
+	case []any:
+		res := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				res = append(res, s)
+			}
+		}
+		return res
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,33 @@
+package authsrv
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestClaimStrings(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		claim  string
+		want   []string
+	}{
+		{"claim not configured", jwt.MapClaims{"roles": "a"}, "", nil},
+		{"missing", jwt.MapClaims{}, "roles", nil},
+		{"array", jwt.MapClaims{"roles": []any{"a", "b"}}, "roles", []string{"a", "b"}},
+		{"array skips empty and non string", jwt.MapClaims{"roles": []any{"a", "", 1, "b"}}, "roles", []string{"a", "b"}},
+		{"space separated", jwt.MapClaims{"scope": "a b"}, "scope", []string{"a", "b"}},
+		{"comma separated", jwt.MapClaims{"roles": "a,b, c"}, "roles", []string{"a", "b", "c"}},
+		{"empty string", jwt.MapClaims{"roles": ""}, "roles", []string{}},
+		{"unsupported type", jwt.MapClaims{"roles": 1.0}, "roles", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := claimStrings(tt.claims, tt.claim); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("claimStrings() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package authsrv
+
+import (
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
+	"slices"
+	"sort"
+)
+
+// permissions 登录用户的权限:凭证自带的权限编码、角色对应的权限与默认权限合并;未启用权限校验或为管理员账号时拥有所有权限
+func (s *Service) permissions(p *authm.Principal) []string {
+	rbac := s.opt.Ext.Rbac
+	if rbac == nil || !rbac.Enable || isAdmin(rbac, p) {
+		return []string{authm.PermAll}
+	}
+	perms := make(map[string]struct{})
+	add := func(list ...string) {
+		for _, v := range list {
+			if v != "" {
+				perms[v] = struct{}{}
+			}
+		}
+	}
+	add(rbac.Default...)
+	add(p.Permissions...)
+	for _, r := range p.Roles {
+		add(rbac.Roles[r]...)
+	}
+	res := make([]string, 0, len(perms))
+	for v := range perms {
+		res = append(res, v)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// isAdmin 管理员为用户中心账号,只认用户中心认证的用户与为其签发的本地JWT;
+// OIDC与API密钥的账号由外部或配置决定,同名也不是管理员
+func isAdmin(rbac *config.RbacOption, p *authm.Principal) bool {
+	if p.Account == "" || (p.AuthBy != config.AuthUserCenter && p.AuthBy != config.AuthJwt) {
+		return false
+	}
+	return slices.Contains(rbac.Admins, p.Account)
+}
@@ -0,0 +1,99 @@
+package authsrv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/dal"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
+	"strings"
+	"time"
+)
+
+// ErrUnauthenticated 请求未携带任何认证方式可识别的凭证
+var ErrUnauthenticated = errors.New("未提供有效的认证凭证")
+
+// errSkip 凭证不属于该认证方式,交给下一种认证方式
+var errSkip = errors.New("credential not applicable")
+
+// authenticator 一种认证方式,返回的登录用户权限为凭证自带的权限编码,由Service按权限配置补全
+type authenticator interface {
+	name() string
+	authenticate(ctx context.Context, cred authm.Credential) (*authm.Principal, error)
+}
+
+// New 按认证配置创建认证服务,未配置认证时只使用用户中心认证
+func New(opt *config.Config, dal dal.If) If {
+	s := &Service{opt: opt}
+	auth := opt.Auth
+	if auth == nil {
+		auth = &config.AuthOption{Chain: []string{config.AuthUserCenter}}
+	}
+	for _, name := range auth.Chain {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case config.AuthApiKey:
+			s.chain = append(s.chain, newApiKeyAuth(auth.ApiKeys))
+		case config.AuthJwt:
+			if auth.Jwt == nil || auth.Jwt.Secret == "" {
+				// 未配置签名密钥时不启用
+				continue
+			}
+			s.jwt = newJwtAuth(auth.Jwt)
+			s.chain = append(s.chain, s.jwt)
+		case config.AuthOidc:
+			if auth.Oidc == nil || auth.Oidc.Issuer == "" {
+				continue
+			}
+			s.chain = append(s.chain, newOidcAuth(auth.Oidc))
+		case config.AuthUserCenter:
+			s.chain = append(s.chain, newUserCenterAuth(dal, time.Duration(opt.Ext.UserCacheTTL)*time.Second))
+		default:
+			panic(fmt.Sprintf("不支持的认证方式: %s", name))
+		}
+	}
+	if len(s.chain) == 0 {
+		panic("没有可用的认证方式,请检查认证配置")
+	}
+	return s
+}
+
+type Service struct {
+	opt   *config.Config
+	chain []authenticator // 按配置顺序尝试的认证方式
+	jwt   *jwtAuth        // 本地JWT,未启用时为nil
+}
+
+func (s *Service) Authenticate(ctx context.Context, cred authm.Credential) (*authm.Principal, error) {
+	if cred.Empty() {
+		return nil, ErrUnauthenticated
+	}
+	for _, a := range s.chain {
+		p, err := a.authenticate(ctx, cred)
+		if errors.Is(err, errSkip) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s认证失败: %w", a.name(), err)
+		}
+		// 认证方式可能缓存登录用户,复制后补全权限
+		res := *p
+		res.AuthBy = a.name()
+		res.Permissions = s.permissions(&res)
+		return &res, nil
+	}
+	return nil, ErrUnauthenticated
+}
+
+func (s *Service) IssueToken(ctx context.Context, req authm.IssueTokenReq) (*authm.IssueTokenResp, error) {
+	if s.jwt == nil {
+		return nil, errors.New("未启用本地JWT认证,请配置签名密钥")
+	}
+	p, ok := authm.FromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	return s.jwt.issue(p, req.Expire)
+}
+
+var _ If = &Service{}
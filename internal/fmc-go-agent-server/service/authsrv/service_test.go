@@ -0,0 +1,110 @@
+package authsrv
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
+)
+
+// fakeAuth 按Bearer令牌认证的测试认证方式
+type fakeAuth struct {
+	n     string
+	token string // 属于该认证方式的令牌,其他令牌交给下一种认证方式
+	err   error  // 认证失败时返回的错误
+	calls int
+}
+
+func (a *fakeAuth) name() string {
+	return a.n
+}
+
+func (a *fakeAuth) authenticate(_ context.Context, cred authm.Credential) (*authm.Principal, error) {
+	a.calls++
+	if cred.Bearer != a.token {
+		return nil, errSkip
+	}
+	if a.err != nil {
+		return nil, a.err
+	}
+	return &authm.Principal{ID: a.n, Account: a.n, Roles: []string{"viewer"}, Permissions: []string{"own:read"}}, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	errInvalid := errors.New("invalid")
+	tests := []struct {
+		name      string
+		bearer    string
+		failOn    string // 认证失败的认证方式
+		wantBy    string
+		wantErr   error
+		wantCalls []int // 各认证方式的调用次数
+	}{
+		{"empty credential", "", "", "", ErrUnauthenticated, []int{0, 0}},
+		{"first", "a", "", "a", nil, []int{1, 0}},
+		{"fall through to second", "b", "", "b", nil, []int{1, 1}},
+		{"no authenticator applies", "c", "", "", ErrUnauthenticated, []int{1, 1}},
+		{"failure stops the chain", "a", "a", "", errInvalid, []int{1, 0}},
+		{"failure of second", "b", "b", "", errInvalid, []int{1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := []*fakeAuth{{n: "a", token: "a"}, {n: "b", token: "b"}}
+			s := &Service{opt: &config.Config{Ext: &config.ExtOption{}}}
+			for _, a := range chain {
+				if a.n == tt.failOn {
+					a.err = errInvalid
+				}
+				s.chain = append(s.chain, a)
+			}
+			p, err := s.Authenticate(context.Background(), authm.Credential{Bearer: tt.bearer})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && p.AuthBy != tt.wantBy {
+				t.Errorf("AuthBy = %q, want %q", p.AuthBy, tt.wantBy)
+			}
+			for i, a := range chain {
+				if a.calls != tt.wantCalls[i] {
+					t.Errorf("authenticator %s calls = %d, want %d", a.n, a.calls, tt.wantCalls[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	rbac := &config.RbacOption{
+		Enable:  true,
+		Admins:  []string{"root"},
+		Roles:   map[string][]string{"viewer": {authm.PermKnowDbWrite}},
+		Default: []string{"session:read"},
+	}
+	tests := []struct {
+		name string
+		rbac *config.RbacOption
+		p    *authm.Principal
+		want []string
+	}{
+		{"rbac disabled", nil, &authm.Principal{Account: "u1"}, []string{authm.PermAll}},
+		{"admin account", rbac, &authm.Principal{Account: "root", AuthBy: config.AuthUserCenter}, []string{authm.PermAll}},
+		{"admin account by local jwt", rbac, &authm.Principal{Account: "root", AuthBy: config.AuthJwt}, []string{authm.PermAll}},
+		{"local jwt without account", rbac, &authm.Principal{Name: "root", AuthBy: config.AuthJwt}, []string{"session:read"}},
+		{"oidc user named as admin", rbac, &authm.Principal{Name: "root", Account: "root", AuthBy: config.AuthOidc}, []string{"session:read"}},
+		{"api key named as admin", rbac, &authm.Principal{Name: "root", Account: "root", AuthBy: config.AuthApiKey}, []string{"session:read"}},
+		{"default only", rbac, &authm.Principal{Account: "u1"}, []string{"session:read"}},
+		{"merged", rbac, &authm.Principal{Account: "u1", Roles: []string{"viewer", "unknown"}, Permissions: []string{"own:read", authm.PermKnowDbWrite}},
+			[]string{authm.PermKnowDbWrite, "own:read", "session:read"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{opt: &config.Config{Ext: &config.ExtOption{Rbac: tt.rbac}}}
+			if got := s.permissions(tt.p); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("permissions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
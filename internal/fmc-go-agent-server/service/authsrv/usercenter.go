@@ -0,0 +1,129 @@
+package authsrv
+
+import (
+	"context"
+	"errors"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/dal"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/dalm/extm/usercenterm"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// userCenterAuth 用户中心令牌认证,令牌取自请求头Tokenid,未携带时取Bearer令牌
+type userCenterAuth struct {
+	dal   dal.If
+	cache *principalCache
+}
+
+func newUserCenterAuth(dal dal.If, ttl time.Duration) *userCenterAuth {
+	return &userCenterAuth{
+		dal:   dal,
+		cache: newPrincipalCache(ttl),
+	}
+}
+
+func (a *userCenterAuth) name() string {
+	return config.AuthUserCenter
+}
+
+// authenticate 校验令牌并从用户中心解析登录用户,结果按配置的时间缓存;未启用用户中心时不认证
+func (a *userCenterAuth) authenticate(ctx context.Context, cred authm.Credential) (*authm.Principal, error) {
+	tokenId := cred.TokenId
+	if tokenId == "" {
+		tokenId = cred.Bearer
+	}
+	if tokenId == "" {
+		return nil, errSkip
+	}
+	if p, ok := a.cache.get(tokenId); ok {
+		return p, nil
+	}
+	userCenter, err := a.dal.Ext().UserCenter()
+	if err != nil {
+		return nil, errSkip
+	}
+	_, err = userCenter.InvalidToken(ctx, usercenterm.InvalidTokenReq{TokenId: tokenId})
+	if err != nil {
+		return nil, err
+	}
+	info, err := userCenter.GetUserInfo(ctx, usercenterm.GetUserInfoReq{TokenId: tokenId})
+	if err != nil {
+		return nil, err
+	}
+	p := toPrincipal(info.Data)
+	if p.ID == "" || p.ID == "0" {
+		return nil, errors.New("用户中心返回的用户ID为空")
+	}
+	a.cache.set(tokenId, p)
+	return p, nil
+}
+
+// toPrincipal 用户中心的用户信息转换为登录用户,权限为用户中心菜单与按钮的权限编码
+func toPrincipal(u *usercenterm.Sm2LoginRespData) *authm.Principal {
+	p := &authm.Principal{
+		ID:          strconv.Itoa(u.UserID),
+		Name:        u.UserName,
+		Account:     u.Account,
+		Roles:       make([]string, 0, len(u.RoleList)),
+		Permissions: make([]string, 0, len(u.Permissions)),
+	}
+	for _, r := range u.RoleList {
+		p.Roles = append(p.Roles, r.RoleName)
+	}
+	for _, v := range u.Permissions {
+		p.Permissions = append(p.Permissions, v.PermissionCode)
+	}
+	return p
+}
+
+// principalCache 令牌解析的登录用户缓存,缓存期内不重复请求用户中心
+type principalCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	items map[string]*cachedPrincipal
+}
+
+type cachedPrincipal struct {
+	p       *authm.Principal
+	expires time.Time
+}
+
+func newPrincipalCache(ttl time.Duration) *principalCache {
+	return &principalCache{
+		ttl:   ttl,
+		items: make(map[string]*cachedPrincipal),
+	}
+}
+
+func (c *principalCache) get(tokenId string) (*authm.Principal, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.items[tokenId]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(v.expires) {
+		delete(c.items, tokenId)
+		return nil, false
+	}
+	return v.p, true
+}
+
+func (c *principalCache) set(tokenId string, p *authm.Principal) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	// 写入时清理过期的令牌
+	for k, v := range c.items {
+		if now.After(v.expires) {
+			delete(c.items, k)
+		}
+	}
+	c.items[tokenId] = &cachedPrincipal{p: p, expires: now.Add(c.ttl)}
+}
@@ -6,5 +6,8 @@ import (
 )
 
 type If interface {
-	EinoTools(ctx context.Context) []tool.BaseTool
+	// EinoTools 获取所有MCP服务的工具,获取失败的服务沿用上次获取的工具,并返回失败的错误
+	EinoTools(ctx context.Context) ([]tool.BaseTool, error)
+	// OnToolsChanged 任一MCP服务的工具列表变更时回调
+	OnToolsChanged(f func())
 }
@@ -2,9 +2,11 @@ package extmcp
 
 import (
 	"context"
+	"errors"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/freedqo/fmc-go-agent/pkg/uai/uaimcp"
 	"github.com/freedqo/fmc-go-agent/pkg/uai/uaimcp/uaimcpclient"
+	"sync"
 )
 
 func New(ctx context.Context, mapMcp *map[string]*uaimcp.Option) If {
@@ -13,22 +15,40 @@ func New(ctx context.Context, mapMcp *map[string]*uaimcp.Option) If {
 		mcp[k] = uaimcpclient.New(ctx, k, v)
 	}
 	return &ExtMCP{
-		mcp: mcp,
+		mcp:  mcp,
+		last: make(map[string][]tool.BaseTool),
 	}
 }
 
 type ExtMCP struct {
-	mcp map[string]uaimcpclient.If
+	mcp  map[string]uaimcpclient.If
+	mu   sync.Mutex
+	last map[string][]tool.BaseTool // 各MCP服务上次成功获取的工具
 }
 
-func (e *ExtMCP) EinoTools(ctx context.Context) []tool.BaseTool {
+func (e *ExtMCP) EinoTools(ctx context.Context) ([]tool.BaseTool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	tools := make([]tool.BaseTool, 0)
+	errs := make([]error, 0)
+	for k, v := range e.mcp {
+		list, err := v.DToEinoTools(ctx)
+		if err != nil {
+			// 一次获取失败不移除该服务的工具
+			errs = append(errs, err)
+			list = e.last[k]
+		} else {
+			e.last[k] = list
+		}
+		tools = append(tools, list...)
+	}
+	return tools, errors.Join(errs...)
+}
+
+func (e *ExtMCP) OnToolsChanged(f func()) {
 	for _, v := range e.mcp {
-		var v1 uaimcpclient.If
-		v1 = v
-		tools = append(tools, v1.DToEinoTools(ctx)...)
+		v.OnToolsChanged(f)
 	}
-	return tools
 }
 
 var _ If = &ExtMCP{}
@@ -0,0 +1,107 @@
+package extmcp
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaimcp/uaimcpclient"
+)
+
+// fakeTool 只有名称的测试工具
+type fakeTool string
+
+func (t fakeTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: string(t)}, nil
+}
+
+// fakeClient 返回固定工具列表或错误的测试MCP客户端
+type fakeClient struct {
+	uaimcpclient.If
+	tools []string
+	err   error
+}
+
+func (c *fakeClient) DToEinoTools(context.Context) ([]tool.BaseTool, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	res := make([]tool.BaseTool, 0, len(c.tools))
+	for _, v := range c.tools {
+		res = append(res, fakeTool(v))
+	}
+	return res, nil
+}
+
+func TestEinoTools(t *testing.T) {
+	errList := errors.New("list tools failed")
+	tests := []struct {
+		name    string
+		steps   []map[string]*fakeClient // 每次获取时各MCP服务的返回
+		want    []string                 // 最后一次获取的工具
+		wantErr bool
+	}{
+		{
+			name:  "all succeed",
+			steps: []map[string]*fakeClient{{"a": {tools: []string{"a1"}}, "b": {tools: []string{"b1", "b2"}}}},
+			want:  []string{"a1", "b1", "b2"},
+		},
+		{
+			name: "failed server keeps last tools",
+			steps: []map[string]*fakeClient{
+				{"a": {tools: []string{"a1"}}, "b": {tools: []string{"b1"}}},
+				{"a": {tools: []string{"a2"}}, "b": {err: errList}},
+			},
+			want:    []string{"a2", "b1"},
+			wantErr: true,
+		},
+		{
+			name:    "failed server without previous tools",
+			steps:   []map[string]*fakeClient{{"a": {tools: []string{"a1"}}, "b": {err: errList}}},
+			want:    []string{"a1"},
+			wantErr: true,
+		},
+		{
+			name: "recovered server replaces tools",
+			steps: []map[string]*fakeClient{
+				{"a": {tools: []string{"a1"}}},
+				{"a": {err: errList}},
+				{"a": {tools: []string{"a2"}}},
+			},
+			want: []string{"a2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &ExtMCP{mcp: map[string]uaimcpclient.If{}, last: map[string][]tool.BaseTool{}}
+			var tools []tool.BaseTool
+			var err error
+			for _, step := range tt.steps {
+				for k, v := range step {
+					e.mcp[k] = v
+				}
+				tools, err = e.EinoTools(context.Background())
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EinoTools() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := make([]string, 0, len(tools))
+			for _, v := range tools {
+				info, _ := v.Info(context.Background())
+				got = append(got, info.Name)
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("tools = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("tools = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
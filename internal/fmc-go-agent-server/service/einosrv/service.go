@@ -10,16 +10,19 @@ import (
 	"github.com/freedqo/fmc-go-agent/pkg/uai/uaiagent/mem"
 	"github.com/freedqo/fmc-go-agent/pkg/uai/uaivectordb"
 	"go.uber.org/zap"
+	"time"
 )
 
 // New 函数用于创建一个新的Service实例
 func New(ctx context.Context, opt *config.Config, dal dal.If, mem mem.MemoryIf) If {
 	// 创建一个新的Service实例
 	s := &Service{
+		ctx:      ctx,
 		vectorDb: uaivectordb.New(ctx, opt.UiRv, log.SysLog()), // 初始化iRVector字段，使用uaiirvector.New函数创建一个新的uaiirvector实例
 		dal:      dal,
 		mem:      mem,
 		log:      log.SysLog(),
+		reload:   make(chan struct{}, 1),
 	}
 	// 添加工具集合
 	extMCP := extmcp.New(ctx, opt.Ext.McpServer)
@@ -29,25 +32,102 @@ func New(ctx context.Context, opt *config.Config, dal dal.If, mem mem.MemoryIf)
 	}
 	s.extMcp = extMCP
 
+	// 创建代理,运行图只编译一次,所有会话复用
+	tools, err := s.extMcp.EinoTools(ctx)
+	if err != nil {
+		s.log.Errorf("获取MCP工具失败: %s", err.Error())
+	}
+	s.agent = uaiagent.New(ctx, opt.Agent, s.log, s.dal.Cm(), s.vectorDb, s.mem, tools)
+	if s.agent == nil {
+		panic("uaiagent is nil")
+	}
+	// MCP服务通知工具列表变更时刷新,多次通知合并为一次刷新
+	go s.reloadToolsLoop(time.Duration(opt.Ext.McpToolsReload) * time.Second)
+	s.extMcp.OnToolsChanged(s.requestReload)
+
 	// 返回Service实例
 	return s
 }
 
 type Service struct {
+	ctx      context.Context
 	log      *zap.SugaredLogger
 	vectorDb uaivectordb.If //ai向量（数据库）知识库-热载对象
 	dal      dal.If
 	mem      mem.MemoryIf
 	extMcp   extmcp.If
+	agent    uaiagent.If   // 复用的代理
+	reload   chan struct{} // 待执行的MCP工具刷新,容量为1,重复的刷新请求合并
 }
 
-// UAiAgent 定义Service结构体的UAiAgent方法，返回uaiagent.If类型，可能需要给每个会话peek toolNode里面堵塞的流，所以不能复用了
-func (s *Service) UAiAgent(ctx context.Context, sessionId string) uaiagent.If {
-	// 使用uaiagent.New方法创建一个新的uaiagent实例，传入context、s.log、s.dal.Cm()、s.vectorDb.Embedder()、s.vectorDb.Redis()、s.mem、s.extMcp.EinoTools(ctx)作为参数
-	return uaiagent.New(ctx, s.log, s.dal.Cm(), s.vectorDb.Embedder(), s.vectorDb.Redis(), s.mem, sessionId, s.extMcp.EinoTools(ctx))
+// UAiAgent 返回复用的代理,会话ID、提示词与历史消息在调用时注入
+func (s *Service) UAiAgent() uaiagent.If {
+	return s.agent
 }
 
 // VectorDb 意图识别向量数据库，动态输入
 func (s *Service) VectorDb() uaivectordb.If {
 	return s.vectorDb
 }
+
+// mcpReloadDebounce 收到工具列表变更通知后等待的时间,期间的通知合并为一次刷新
+const mcpReloadDebounce = 500 * time.Millisecond
+
+// mcpReloadTimeout 一次刷新MCP工具列表的超时时间
+const mcpReloadTimeout = 30 * time.Second
+
+// requestReload 请求刷新MCP工具列表,已有待执行的刷新时忽略,不阻塞调用方
+func (s *Service) requestReload() {
+	select {
+	case s.reload <- struct{}{}:
+	default:
+	}
+}
+
+// reloadTools 重新获取MCP工具列表并更新代理,获取失败的MCP服务沿用上次的工具
+func (s *Service) reloadTools() {
+	ctx, cancel := context.WithTimeout(s.ctx, mcpReloadTimeout)
+	defer cancel()
+	tools, fetchErr := s.extMcp.EinoTools(ctx)
+	if fetchErr != nil {
+		s.log.Errorf("刷新MCP工具失败,沿用上次的工具: %s", fetchErr.Error())
+	}
+	err := s.agent.SetTools(tools)
+	if err != nil {
+		s.log.Errorf("刷新MCP工具失败: %s", err.Error())
+		return
+	}
+	if fetchErr == nil {
+		s.log.Infof("MCP工具已刷新")
+	}
+}
+
+// reloadToolsLoop 串行执行MCP工具列表的刷新,interval大于0时定时刷新
+func (s *Service) reloadToolsLoop(interval time.Duration) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-tick:
+			s.reloadTools()
+		case <-s.reload:
+			// 等待短时间,合并连续的变更通知
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(mcpReloadDebounce):
+			}
+			select {
+			case <-s.reload:
+			default:
+			}
+			s.reloadTools()
+		}
+	}
+}
@@ -1,7 +1,7 @@
 package service
 
 import (
-	"context"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/authsrv"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/knowdbsrv"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/openaisrv"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/promptsrv"
@@ -14,6 +14,7 @@ type If interface {
 	OpenAi() openaisrv.If
 	Session() sessionsrv.If
 	KnowDb() knowdbsrv.If
-	MidInvalidTokenIdByUserCenter(ctx context.Context, tokenId string) error
+	// Auth 认证服务
+	Auth() authsrv.If
 	Prompt() promptsrv.If
 }
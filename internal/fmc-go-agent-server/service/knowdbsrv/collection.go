@@ -0,0 +1,123 @@
+package knowdbsrv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/knowdbm"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaivectordb"
+)
+
+func (s *Service) GetCollections(ctx context.Context, req knowdbm.GetCollectionsReq) (*knowdbm.GetCollectionsResp, error) {
+	cs, err := s.vdb.Collections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := &knowdbm.GetCollectionsResp{List: make([]*knowdbm.CollectionInfo, 0, len(cs))}
+	for _, c := range cs {
+		if c.Owner != "" && c.Owner != req.UserID {
+			continue
+		}
+		res.List = append(res.List, toCollectionInfo(c))
+	}
+	return res, nil
+}
+
+func (s *Service) CreateCollection(ctx context.Context, req knowdbm.CreateCollectionReq) (*knowdbm.CollectionInfo, error) {
+	c := &uaivectordb.Collection{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if req.Private {
+		if req.UserID == "" {
+			return nil, errors.New("私有集合的创建用户ID不能为空")
+		}
+		c.Owner = req.UserID
+	}
+	if err := checkWritable(ctx, c, req.UserID); err != nil {
+		return nil, fmt.Errorf("%w: 创建集合 %s", err, c.Name)
+	}
+	if err := s.vdb.CreateCollection(ctx, c); err != nil {
+		if errors.Is(err, uaivectordb.ErrCollectionExists) {
+			return nil, fmt.Errorf("集合已存在: %s", req.Name)
+		}
+		return nil, err
+	}
+	return toCollectionInfo(c), nil
+}
+
+// DeleteCollection 删除集合目录下的所有文件与集合在向量数据库中的分片
+func (s *Service) DeleteCollection(ctx context.Context, req knowdbm.DeleteCollectionReq) (*knowdbm.DeleteCollectionResp, error) {
+	c, err := s.vdb.Collection(ctx, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("集合不存在: %s", req.Name)
+	}
+	if c.Name == uaivectordb.DefaultCollection {
+		return nil, errors.New("默认集合不能删除")
+	}
+	if err = checkWritable(ctx, c, req.UserID); err != nil {
+		return nil, fmt.Errorf("%w: 删除集合 %s", err, c.Name)
+	}
+	res := &knowdbm.DeleteCollectionResp{}
+	s.mu.Lock()
+	for _, f := range s.files {
+		if f.Collection == c.Name {
+			res.Files++
+		}
+	}
+	err = os.RemoveAll(filepath.Join(s.docsDir, uaivectordb.CollectionPath(c.Name)))
+	s.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("删除集合目录失败: %v", err)
+	}
+	// 刷新文件列表,已删除文件的索引任务随之取消
+	if _, err = s.GetFileList(knowdbm.GetFileListReq{}); err != nil {
+		return nil, fmt.Errorf("刷新文件列表缓存失败: %v", err)
+	}
+	res.Chunks, err = s.vdb.DeleteCollection(ctx, c.Name)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// visibleCollections 用户可见的集合:公开集合与用户的私有集合
+func (s *Service) visibleCollections(userID string) (map[string]*uaivectordb.Collection, error) {
+	cs, err := s.vdb.Collections(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]*uaivectordb.Collection, len(cs))
+	for _, c := range cs {
+		if c.Owner == "" || c.Owner == userID {
+			res[c.Name] = c
+		}
+	}
+	return res, nil
+}
+
+// checkWritable 校验用户可修改集合及其文件:私有集合只有创建用户可修改,公开集合需要knowdb:admin权限
+func checkWritable(ctx context.Context, c *uaivectordb.Collection, userID string) error {
+	if c.Owner != "" {
+		if c.Owner != userID {
+			return authm.ErrForbidden
+		}
+		return nil
+	}
+	return authm.Require(ctx, authm.PermKnowDbAdmin)
+}
+
+func toCollectionInfo(c *uaivectordb.Collection) *knowdbm.CollectionInfo {
+	return &knowdbm.CollectionInfo{
+		Name:        c.Name,
+		Description: c.Description,
+		Private:     c.Owner != "",
+		Owner:       c.Owner,
+		Created:     time.Unix(c.Created, 0).Format(time.RFC3339),
+	}
+}
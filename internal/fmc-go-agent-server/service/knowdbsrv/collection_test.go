@@ -0,0 +1,35 @@
+package knowdbsrv
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaivectordb"
+)
+
+func TestCheckWritable(t *testing.T) {
+	admin := authm.WithPrincipal(context.Background(), &authm.Principal{ID: "u1", Permissions: []string{authm.PermKnowDbAdmin}})
+	user := authm.WithPrincipal(context.Background(), &authm.Principal{ID: "u1", Permissions: []string{authm.PermKnowDbWrite}})
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		owner string
+		want  error
+	}{
+		{"own private collection", user, "u1", nil},
+		{"private collection of others", user, "u2", authm.ErrForbidden},
+		{"private collection of others by admin", admin, "u2", authm.ErrForbidden},
+		{"public collection by user", user, "", authm.ErrForbidden},
+		{"public collection by admin", admin, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &uaivectordb.Collection{Name: "c1", Owner: tt.owner}
+			if err := checkWritable(tt.ctx, c, "u1"); !errors.Is(err, tt.want) {
+				t.Errorf("checkWritable() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,29 @@
 package knowdbsrv
 
 import (
+	"context"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/knowdbm"
 	"mime/multipart"
 )
 
 type If interface {
 	GetFileList(req knowdbm.GetFileListReq) (*knowdbm.GetFileListResp, error)
-	DeleteFiles(req knowdbm.DeleteFilesReq) (res *knowdbm.DeleteFilesResp, err error)
-	Download(file string) (string, error)
-	UploadFiles(files []*multipart.FileHeader) error
+	// DeleteFiles 删除文件,公开集合的文件需要knowdb:admin权限
+	DeleteFiles(ctx context.Context, req knowdbm.DeleteFilesReq) (res *knowdbm.DeleteFilesResp, err error)
+	// Download 文件的本地路径,其他用户私有集合的文件不可下载
+	Download(id, userID string) (string, error)
+	// UploadFiles 上传文件到集合,公开集合需要knowdb:admin权限
+	UploadFiles(ctx context.Context, req knowdbm.UploadFilesReq, files []*multipart.FileHeader) error
+	// GetCollections 公开集合与用户的私有集合
+	GetCollections(ctx context.Context, req knowdbm.GetCollectionsReq) (*knowdbm.GetCollectionsResp, error)
+	// CreateCollection 创建知识库集合,私有集合只有创建用户可上传与检索,公开集合需要knowdb:admin权限
+	CreateCollection(ctx context.Context, req knowdbm.CreateCollectionReq) (*knowdbm.CollectionInfo, error)
+	// DeleteCollection 删除集合及其所有文件
+	DeleteCollection(ctx context.Context, req knowdbm.DeleteCollectionReq) (*knowdbm.DeleteCollectionResp, error)
+	// Search 在用户可见的集合中检索分片
+	Search(ctx context.Context, req knowdbm.SearchReq) (*knowdbm.SearchResp, error)
+	// GetChunks 文件在向量数据库中的分片
+	GetChunks(ctx context.Context, req knowdbm.GetChunksReq) (*knowdbm.GetChunksResp, error)
+	// GetStats 知识库统计信息:文件与分片数量、嵌入模型与索引大小
+	GetStats(ctx context.Context) (*knowdbm.GetStatsResp, error)
 }
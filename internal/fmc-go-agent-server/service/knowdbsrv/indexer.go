@@ -0,0 +1,190 @@
+package knowdbsrv
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/knowdbm"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaivectordb"
+)
+
+// indexOp 索引任务类型
+type indexOp int
+
+const (
+	opIndex  indexOp = iota // 索引文件
+	opDelete                // 删除文件的分片
+)
+
+// indexTask 索引任务
+type indexTask struct {
+	op         indexOp
+	id         string // 文件Id(MD5)
+	path       string // 文件绝对路径
+	collection string // 文件所属的集合
+}
+
+// indexJob 文件的索引状态
+type indexJob struct {
+	path   string
+	status knowdbm.IndexStatus
+	chunks int
+	err    string
+}
+
+// enqueue 提交索引任务,不阻塞
+func (s *Service) enqueue(t indexTask) {
+	s.qmu.Lock()
+	s.queue = append(s.queue, t)
+	s.qmu.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// dequeue 取出所有待处理的索引任务
+func (s *Service) dequeue() []indexTask {
+	s.qmu.Lock()
+	defer s.qmu.Unlock()
+	ts := s.queue
+	s.queue = nil
+	return ts
+}
+
+// reconcile 对比文件列表与索引状态:新文件提交索引,已不存在的文件提交删除分片
+func (s *Service) reconcile(files map[string]*knowdbm.TFileInfo) {
+	tasks := make([]indexTask, 0)
+	s.jmu.Lock()
+	for id, f := range files {
+		if _, ok := s.jobs[id]; ok {
+			continue
+		}
+		path := filepath.Join(s.docsDir, f.Path)
+		s.jobs[id] = &indexJob{path: path, status: knowdbm.IndexPending}
+		tasks = append(tasks, indexTask{op: opIndex, id: id, path: path, collection: f.Collection})
+	}
+	for id, j := range s.jobs {
+		if _, ok := files[id]; ok {
+			continue
+		}
+		delete(s.jobs, id)
+		tasks = append(tasks, indexTask{op: opDelete, id: id, path: j.path})
+	}
+	s.jmu.Unlock()
+	for _, t := range tasks {
+		s.enqueue(t)
+	}
+}
+
+// fillStatus 填充文件的索引状态
+func (s *Service) fillStatus(f *knowdbm.TFileInfo) {
+	s.jmu.RLock()
+	defer s.jmu.RUnlock()
+	if j, ok := s.jobs[f.Id]; ok {
+		f.Status = j.status
+		f.Chunks = j.chunks
+		f.Error = j.err
+	}
+}
+
+// setJob 更新索引状态,文件已删除时忽略并返回false
+func (s *Service) setJob(id string, status knowdbm.IndexStatus, chunks int, err string) bool {
+	s.jmu.Lock()
+	defer s.jmu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return false
+	}
+	j.status = status
+	j.chunks = chunks
+	j.err = err
+	return true
+}
+
+// runIndexer 索引任务处理协程,按提交顺序逐个处理
+// 启动时读取向量数据库中已索引的文件,未变化的文件不重复索引,已删除文件遗留的分片被清理
+func (s *Service) runIndexer(ctx context.Context) {
+	known, err := s.vdb.Sources(ctx)
+	if err != nil {
+		s.log.Errorf("读取向量数据库已索引文件失败,将重新索引所有文件: %s", err.Error())
+		known = make(map[string]*uaivectordb.Manifest)
+	}
+	s.cleanOrphans(ctx, known)
+	for {
+		for _, t := range s.dequeue() {
+			if ctx.Err() != nil {
+				return
+			}
+			// 内容未变化的文件不重复索引
+			if m, ok := known[t.path]; ok && t.op == opIndex && m.MD5 == t.id && m.Collection == t.collection {
+				delete(known, t.path)
+				s.setJob(t.id, knowdbm.IndexIndexed, len(m.Chunks), "")
+				continue
+			}
+			delete(known, t.path)
+			s.handle(ctx, t)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.notify:
+		}
+	}
+}
+
+// cleanOrphans 清理知识库目录下已不存在的文件遗留的分片
+func (s *Service) cleanOrphans(ctx context.Context, known map[string]*uaivectordb.Manifest) {
+	paths := make(map[string]struct{})
+	s.jmu.RLock()
+	for _, j := range s.jobs {
+		paths[j.path] = struct{}{}
+	}
+	s.jmu.RUnlock()
+	prefix := s.docsDir + string(filepath.Separator)
+	for src := range known {
+		if _, ok := paths[src]; ok || !strings.HasPrefix(src, prefix) {
+			continue
+		}
+		delete(known, src)
+		s.handle(ctx, indexTask{op: opDelete, path: src})
+	}
+}
+
+// handle 处理索引任务
+func (s *Service) handle(ctx context.Context, t indexTask) {
+	switch t.op {
+	case opIndex:
+		if !s.setJob(t.id, knowdbm.IndexIndexing, 0, "") {
+			return
+		}
+		n, err := s.vdb.IndexFile(ctx, t.path, t.collection)
+		switch {
+		case errors.Is(err, uaivectordb.ErrUnsupportedFile), errors.Is(err, uaivectordb.ErrCollectionNotFound):
+			s.setJob(t.id, knowdbm.IndexSkipped, 0, err.Error())
+		case err != nil:
+			s.log.Errorf("索引文件%s失败: %s", t.path, err.Error())
+			s.setJob(t.id, knowdbm.IndexFailed, 0, err.Error())
+		default:
+			s.log.Infof("索引文件%s完成,分片数量: %d", t.path, n)
+			s.setJob(t.id, knowdbm.IndexIndexed, n, "")
+		}
+	case opDelete:
+		var n int
+		var err error
+		if t.id != "" {
+			n, err = s.vdb.RemoveFile(ctx, t.id)
+		} else {
+			n, err = s.vdb.DeleteFile(ctx, t.path)
+		}
+		if err != nil {
+			s.log.Errorf("删除文件%s的分片失败: %s", t.path, err.Error())
+			return
+		}
+		if n > 0 {
+			s.log.Infof("删除文件%s的分片,数量: %d", t.path, n)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package knowdbsrv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/knowdbm"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaivectordb"
+)
+
+// Search 在用户可见的集合中检索,返回分片内容、相似度与元数据
+func (s *Service) Search(ctx context.Context, req knowdbm.SearchReq) (*knowdbm.SearchResp, error) {
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, fmt.Errorf("检索文本不能为空")
+	}
+	visible, err := s.visibleCollections(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	collections := make([]string, 0, len(visible))
+	if req.Collection != "" {
+		if _, ok := visible[req.Collection]; !ok {
+			return nil, fmt.Errorf("集合不存在: %s", req.Collection)
+		}
+		collections = append(collections, req.Collection)
+	} else {
+		for name := range visible {
+			collections = append(collections, name)
+		}
+	}
+	owners := []string{uaivectordb.PublicOwner}
+	if req.UserID != "" {
+		owners = append(owners, req.UserID)
+	}
+	filter := uaivectordb.Filter{
+		uaivectordb.CollectionField: collections,
+		uaivectordb.OwnerField:      owners,
+	}
+	if req.FileType != "" {
+		filter[uaivectordb.FileTypeField] = []string{strings.ToLower(strings.TrimPrefix(req.FileType, "."))}
+	}
+	if req.Header != "" {
+		filter[uaivectordb.HeaderField] = []string{req.Header}
+	}
+	hits, err := s.vdb.Search(ctx, req.Query, filter, req.TopK)
+	if err != nil {
+		return nil, fmt.Errorf("检索失败: %v", err)
+	}
+	res := &knowdbm.SearchResp{List: make([]*knowdbm.ChunkInfo, 0, len(hits))}
+	for _, h := range hits {
+		c := toChunkInfo(h.Chunk)
+		c.Score = h.Score
+		res.List = append(res.List, c)
+	}
+	return res, nil
+}
+
+// GetChunks 文件在向量数据库中的分片,用于查看文件的分片结果
+func (s *Service) GetChunks(ctx context.Context, req knowdbm.GetChunksReq) (*knowdbm.GetChunksResp, error) {
+	s.mu.RLock()
+	f, ok := s.files[req.FileID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("文件不存在，ID: %s", req.FileID)
+	}
+	visible, err := s.visibleCollections(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok = visible[f.Collection]; !ok {
+		return nil, fmt.Errorf("文件不存在，ID: %s", req.FileID)
+	}
+	file := *f
+	s.fillStatus(&file)
+	chunks, err := s.vdb.FileChunks(ctx, req.FileID)
+	if err != nil {
+		return nil, err
+	}
+	res := &knowdbm.GetChunksResp{
+		File: &file,
+		List: make([]*knowdbm.ChunkInfo, 0, len(chunks)),
+	}
+	for _, c := range chunks {
+		res.List = append(res.List, toChunkInfo(c))
+	}
+	return res, nil
+}
+
+// GetStats 知识库统计信息
+func (s *Service) GetStats(ctx context.Context) (*knowdbm.GetStatsResp, error) {
+	st, err := s.vdb.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := &knowdbm.GetStatsResp{
+		Files:       st.Files,
+		Chunks:      st.Chunks,
+		Collections: make([]*knowdbm.CollectionStats, 0, len(st.Collections)),
+	}
+	for _, c := range st.Collections {
+		res.Collections = append(res.Collections, &knowdbm.CollectionStats{
+			Name:   c.Name,
+			Files:  c.Files,
+			Chunks: c.Chunks,
+		})
+	}
+	if st.Embedding != nil {
+		res.Embedding = &knowdbm.EmbeddingInfo{
+			Provider:  st.Embedding.Provider,
+			Model:     st.Embedding.Model,
+			Dimension: st.Embedding.Dimension,
+		}
+	}
+	if st.Store != nil {
+		res.Index = &knowdbm.IndexStats{
+			Backend:      st.Store.Backend,
+			Docs:         st.Store.Chunks,
+			Dimension:    st.Store.Dimension,
+			SizeMB:       st.Store.IndexSizeMB,
+			VectorSizeMB: st.Store.VectorSizeMB,
+			Failures:     st.Store.Failures,
+			Indexing:     st.Store.Indexing,
+		}
+	}
+	return res, nil
+}
+
+func toChunkInfo(c *uaivectordb.Chunk) *knowdbm.ChunkInfo {
+	res := &knowdbm.ChunkInfo{
+		Id:         c.ID,
+		Content:    c.Content,
+		Collection: c.Tags[uaivectordb.CollectionField],
+		FileType:   c.Tags[uaivectordb.FileTypeField],
+		Header:     c.Tags[uaivectordb.HeaderField],
+		MetaData:   c.MetaData,
+	}
+	res.FileId, _ = c.MetaData[uaivectordb.MetaKeyFileID].(string)
+	return res
+}
@@ -1,37 +1,60 @@
 package knowdbsrv
 
 import (
+	"context"
 	"fmt"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/knowdbm"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/store/log"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaivectordb"
 	"github.com/freedqo/fmc-go-agent/pkg/utils"
+	"go.uber.org/zap"
 	"io"
 	"mime/multipart"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
-func New() If {
+// New 创建知识库服务,上传、删除与替换文件时自动更新向量数据库中的索引
+func New(ctx context.Context, vdb uaivectordb.If) If {
 	dir, err := os.Getwd()
 	dir = filepath.Join(dir, "knowdb")
 	if err != nil {
 		panic(err)
 	}
 	s := &Service{
+		ctx:     ctx,
 		docsDir: dir,
 		files:   make(map[string]*knowdbm.TFileInfo),
+		vdb:     vdb,
+		log:     log.SysLog(),
+		jobs:    make(map[string]*indexJob),
+		notify:  make(chan struct{}, 1),
 	}
 	if _, err := s.GetFileList(knowdbm.GetFileListReq{}); err != nil {
 		panic(fmt.Sprintf("初始化文件列表失败: %v", err))
 	}
+	go s.runIndexer(ctx)
 	return s
 }
 
 type Service struct {
+	ctx     context.Context
+	mu      sync.RWMutex // 文件列表锁
 	docsDir string
 	files   map[string]*knowdbm.TFileInfo
+	vdb     uaivectordb.If       // 向量数据库
+	log     *zap.SugaredLogger   // 日志
+	jmu     sync.RWMutex         // 索引状态锁
+	jobs    map[string]*indexJob // 各文件的索引状态,键为文件Id
+	qmu     sync.Mutex           // 索引任务队列锁
+	queue   []indexTask          // 待处理的索引任务
+	notify  chan struct{}        // 有新的索引任务
 }
 
 var _ If = &Service{}
@@ -42,6 +65,8 @@ func (s *Service) GetFileList(req knowdbm.GetFileListReq) (*knowdbm.GetFileListR
 		return nil, fmt.Errorf("初始化文件目录失败: %v", err)
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.files = make(map[string]*knowdbm.TFileInfo) // 重置文件缓存
 	var fileList []*knowdbm.TFileInfo
 
@@ -78,12 +103,13 @@ func (s *Service) GetFileList(req knowdbm.GetFileListReq) (*knowdbm.GetFileListR
 		}
 
 		f := &knowdbm.TFileInfo{
-			Id:   md5Hash,
-			Name: d.Name(),
-			Type: fileType,
-			Path: relPath,
-			Size: utils.FormatFileSize(fileInfo.Size()),
-			Date: fileInfo.ModTime().Format(time.RFC3339),
+			Id:         md5Hash,
+			Name:       d.Name(),
+			Type:       fileType,
+			Path:       relPath,
+			Size:       utils.FormatFileSize(fileInfo.Size()),
+			Date:       fileInfo.ModTime().Format(time.RFC3339),
+			Collection: uaivectordb.CollectionOf(relPath),
 		}
 		fileList = append(fileList, f)
 		s.files[f.Id] = f
@@ -93,10 +119,32 @@ func (s *Service) GetFileList(req knowdbm.GetFileListReq) (*knowdbm.GetFileListR
 	if err != nil {
 		return nil, err
 	}
+	// 新文件提交索引,已不存在的文件删除分片
+	s.reconcile(s.files)
+	for _, f := range fileList {
+		s.fillStatus(f)
+	}
+
+	// 只返回用户可见集合的文件
+	visible, err := s.visibleCollections(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Collection != "" {
+		if _, ok := visible[req.Collection]; !ok {
+			return nil, fmt.Errorf("集合不存在: %s", req.Collection)
+		}
+	}
 
 	// 按文件类型分组并排序
 	dataFiles := make(map[string][]*knowdbm.TFileInfo)
 	for _, file := range fileList {
+		if _, ok := visible[file.Collection]; !ok {
+			continue
+		}
+		if req.Collection != "" && file.Collection != req.Collection {
+			continue
+		}
 		dataFiles[file.Type] = append(dataFiles[file.Type], file)
 	}
 
@@ -118,12 +166,27 @@ func (s *Service) GetFileList(req knowdbm.GetFileListReq) (*knowdbm.GetFileListR
 	return &knowdbm.GetFileListResp{List: data}, nil
 }
 
-func (s *Service) DeleteFiles(req knowdbm.DeleteFilesReq) (res *knowdbm.DeleteFilesResp, err error) {
+func (s *Service) DeleteFiles(ctx context.Context, req knowdbm.DeleteFilesReq) (res *knowdbm.DeleteFilesResp, err error) {
+	visible, err := s.visibleCollections(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 删除文件后同步删除向量数据库中的分片
+	defer s.reconcile(s.files)
 	for _, id := range req.Ids {
 		file, ok := s.files[id]
 		if !ok {
 			return nil, fmt.Errorf("文件不存在，ID: %s", id)
 		}
+		c, ok := visible[file.Collection]
+		if !ok {
+			return nil, fmt.Errorf("%w: 文件 %s", authm.ErrForbidden, file.Name)
+		}
+		if err = checkWritable(ctx, c, req.UserID); err != nil {
+			return nil, fmt.Errorf("%w: 删除文件 %s", err, file.Name)
+		}
 
 		filePath := filepath.Join(s.docsDir, file.Path)
 		if err := os.Remove(filePath); err != nil {
@@ -139,22 +202,40 @@ func (s *Service) DeleteFiles(req knowdbm.DeleteFilesReq) (res *knowdbm.DeleteFi
 	return &knowdbm.DeleteFilesResp{}, nil
 }
 
-func (s *Service) Download(id string) (string, error) {
+func (s *Service) Download(id, userID string) (string, error) {
+	visible, err := s.visibleCollections(userID)
+	if err != nil {
+		return "", err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	file, ok := s.files[id]
 	if !ok {
 		return "", fmt.Errorf("文件不存在，ID: %s", id)
 	}
+	if _, ok = visible[file.Collection]; !ok {
+		return "", fmt.Errorf("文件不存在，ID: %s", id)
+	}
 	filePath := filepath.Join(s.docsDir, file.Path)
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return "", fmt.Errorf("文件不存在: %s", file)
+		return "", fmt.Errorf("文件不存在: %s", file.Name)
 	}
 	return filePath, nil
 }
 
-func (s *Service) UploadFiles(files []*multipart.FileHeader) error {
+func (s *Service) UploadFiles(ctx context.Context, req knowdbm.UploadFilesReq, files []*multipart.FileHeader) error {
 	if err := s.ensureUploadDirExists(); err != nil {
 		return err
 	}
+	c, err := s.vdb.Collection(ctx, req.Collection)
+	if err != nil {
+		return fmt.Errorf("集合不存在: %s", req.Collection)
+	}
+	if err = checkWritable(ctx, c, req.UserID); err != nil {
+		return fmt.Errorf("%w: 上传文件到集合 %s", err, c.Name)
+	}
+	// 文件按集合存放,默认集合为知识库目录本身
+	baseDir := filepath.Join(s.docsDir, uaivectordb.CollectionPath(c.Name))
 	for _, file := range files {
 		// 1. 打开上传的文件，读取内容并计算MD5
 		src, err := file.Open()
@@ -170,7 +251,10 @@ func (s *Service) UploadFiles(files []*multipart.FileHeader) error {
 		}
 		// 2. 计算文件内容的MD5（判断是否已存在）
 		md5Hash := utils.CalculateMD5(content) // 需确保utils有此方法：计算字节数组的MD5
-		if fileInfo, exists := s.files[md5Hash]; exists {
+		s.mu.RLock()
+		fileInfo, exists := s.files[md5Hash]
+		s.mu.RUnlock()
+		if exists {
 			return fmt.Errorf("文件已存在（内容重复）,文件名称: %s", fileInfo.Name)
 		}
 		// 3. 处理文件类型目录
@@ -179,7 +263,7 @@ func (s *Service) UploadFiles(files []*multipart.FileHeader) error {
 		if fileType == "" {
 			fileType = "unknown" // 无扩展名文件归类到unknown目录
 		}
-		typeDir := filepath.Join(s.docsDir, fileType)
+		typeDir := filepath.Join(baseDir, fileType)
 		if err := os.MkdirAll(typeDir, 0755); err != nil {
 			return fmt.Errorf("创建类型目录 %s 失败: %v", typeDir, err)
 		}
@@ -193,8 +277,12 @@ func (s *Service) UploadFiles(files []*multipart.FileHeader) error {
 		if err := os.WriteFile(dstPath, content, 0644); err != nil { // 0644：所有者可读写，其他只读
 			return fmt.Errorf("保存文件到 %s 失败: %v", dstPath, err)
 		}
+		// 6. 替换同名文件,旧文件的分片在刷新文件列表时删除
+		if err := s.removeReplaced(typeDir, file.Filename, uniqueName); err != nil {
+			return err
+		}
 	}
-	// 刷新文件列表缓存
+	// 刷新文件列表缓存,新文件提交索引
 	if _, err := s.GetFileList(knowdbm.GetFileListReq{}); err != nil {
 		return fmt.Errorf("刷新文件列表缓存失败: %v", err)
 	}
@@ -202,6 +290,31 @@ func (s *Service) UploadFiles(files []*multipart.FileHeader) error {
 	return nil
 }
 
+// uniqueSuffix 上传时附加在文件名后的时间戳
+var uniqueSuffix = regexp.MustCompile(`_\d+$`)
+
+// removeReplaced 删除类型目录下与上传文件同名(去除时间戳后)的旧文件
+func (s *Service) removeReplaced(typeDir, filename, keep string) error {
+	entries, err := os.ReadDir(typeDir)
+	if err != nil {
+		return fmt.Errorf("读取类型目录 %s 失败: %v", typeDir, err)
+	}
+	ext := filepath.Ext(filename)
+	for _, e := range entries {
+		if e.IsDir() || e.Name() == keep || filepath.Ext(e.Name()) != ext {
+			continue
+		}
+		base := uniqueSuffix.ReplaceAllString(strings.TrimSuffix(e.Name(), ext), "")
+		if base+ext != filename {
+			continue
+		}
+		if err := os.Remove(filepath.Join(typeDir, e.Name())); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("替换文件 %s 失败: %v", e.Name(), err)
+		}
+	}
+	return nil
+}
+
 func (s *Service) ensureUploadDirExists() error {
 	if _, err := os.Stat(s.docsDir); os.IsNotExist(err) {
 		return os.MkdirAll(s.docsDir, 0755)
@@ -7,4 +7,6 @@ import (
 
 type If interface {
 	Completions(ctx context.Context, sessionId string, req chatm.ChatCompletionsReq) (res interface{}, err error)
+	// Cancel 取消会话中进行中的代理运行,返回取消的数量
+	Cancel(ctx context.Context, sessionId string) (int, error)
 }
@@ -0,0 +1,65 @@
+package chatsrv
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/openaim/v1m/chatm"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaicharmodel"
+)
+
+// modelOptions 将请求中的采样参数转换为模型调用参数
+func modelOptions(req chatm.ChatCompletionsReq) ([]model.Option, error) {
+	opts := make([]model.Option, 0)
+	if req.Temperature != nil {
+		if *req.Temperature < 0 || *req.Temperature > 2 {
+			return nil, errors.New("temperature must be between 0 and 2")
+		}
+		opts = append(opts, model.WithTemperature(*req.Temperature))
+	}
+	if req.TopP != nil {
+		if *req.TopP < 0 || *req.TopP > 1 {
+			return nil, errors.New("top_p must be between 0 and 1")
+		}
+		opts = append(opts, model.WithTopP(*req.TopP))
+	}
+	if req.MaxTokens != nil {
+		if *req.MaxTokens <= 0 {
+			return nil, errors.New("max_tokens must be greater than 0")
+		}
+		opts = append(opts, model.WithMaxTokens(int(*req.MaxTokens)))
+	}
+	if len(req.Stop) > 0 {
+		if len(req.Stop) > 4 {
+			return nil, errors.New("stop supports up to 4 sequences")
+		}
+		opts = append(opts, model.WithStop(req.Stop))
+	}
+	if req.Seed != nil {
+		opts = append(opts, uaicharmodel.WithSeed(int(*req.Seed)))
+	}
+	if req.FrequencyPenalty != nil {
+		if *req.FrequencyPenalty < -2 || *req.FrequencyPenalty > 2 {
+			return nil, errors.New("frequency_penalty must be between -2 and 2")
+		}
+		opts = append(opts, uaicharmodel.WithFrequencyPenalty(float32(*req.FrequencyPenalty)))
+	}
+	if req.PresencePenalty != nil {
+		if *req.PresencePenalty < -2 || *req.PresencePenalty > 2 {
+			return nil, errors.New("presence_penalty must be between -2 and 2")
+		}
+		opts = append(opts, uaicharmodel.WithPresencePenalty(float32(*req.PresencePenalty)))
+	}
+	if req.ResponseFormat != nil {
+		t, _ := req.ResponseFormat["type"].(string)
+		switch t {
+		case "", string(uaicharmodel.ResponseFormatText):
+		case string(uaicharmodel.ResponseFormatJSON), "json_schema":
+			// json_schema 暂不校验结构,按JSON模式处理
+			opts = append(opts, uaicharmodel.WithResponseFormat(uaicharmodel.ResponseFormatJSON))
+		default:
+			return nil, fmt.Errorf("unsupported response_format type: %s", t)
+		}
+	}
+	return opts, nil
+}
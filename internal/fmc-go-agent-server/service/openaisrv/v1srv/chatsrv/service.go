@@ -3,11 +3,16 @@ package chatsrv
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/cloudwego/eino/schema"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/dal"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/openaim/v1m/chatm"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/einosrv"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaiagent"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaicharmodel"
+	"io"
+	"sync"
 )
 
 // New函数用于创建一个新的Service实例
@@ -18,6 +23,7 @@ func New(ctx context.Context, opt *config.Config, dal dal.If, eino einosrv.If) I
 		opt:  opt,
 		dal:  dal,
 		eino: eino,
+		runs: make(map[string]map[*run]struct{}),
 	}
 }
 
@@ -26,48 +32,143 @@ type Service struct {
 	opt  *config.Config
 	dal  dal.If
 	eino einosrv.If
+	mu   sync.Mutex                   // 运行登记锁
+	runs map[string]map[*run]struct{} // 各会话进行中的代理运行
+}
+
+// run 一次进行中的代理运行
+type run struct {
+	cancel context.CancelFunc
+}
+
+// register 登记会话的代理运行,返回运行的上下文与注销函数;代理运行返回后调用注销函数,请求结束或被取消时也会自动注销
+func (s *Service) register(ctx context.Context, sessionId string) (context.Context, func()) {
+	runCtx, cancel := context.WithCancel(ctx)
+	r := &run{cancel: cancel}
+	s.mu.Lock()
+	if s.runs[sessionId] == nil {
+		s.runs[sessionId] = make(map[*run]struct{})
+	}
+	s.runs[sessionId][r] = struct{}{}
+	s.mu.Unlock()
+	unregister := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.runs[sessionId], r)
+		if len(s.runs[sessionId]) == 0 {
+			delete(s.runs, sessionId)
+		}
+	}
+	context.AfterFunc(runCtx, unregister)
+	return runCtx, func() {
+		cancel()
+		unregister()
+	}
+}
+
+// releaseOnEnd 转发输出流,输出流结束或读取方关闭后调用release
+func releaseOnEnd(sr *schema.StreamReader[*schema.Message], release func()) *schema.StreamReader[*schema.Message] {
+	out, w := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer release()
+		defer w.Close()
+		defer sr.Close()
+		for {
+			chunk, err := sr.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if closed := w.Send(chunk, err); closed || err != nil {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Cancel 取消会话中进行中的代理运行,返回取消的数量
+func (s *Service) Cancel(ctx context.Context, sessionId string) (int, error) {
+	if sessionId == "" {
+		return 0, errors.New("sessionId is empty")
+	}
+	s.mu.Lock()
+	rs := make([]*run, 0, len(s.runs[sessionId]))
+	for r := range s.runs[sessionId] {
+		rs = append(rs, r)
+	}
+	s.mu.Unlock()
+	for _, r := range rs {
+		r.cancel()
+	}
+	return len(rs), nil
 }
 
 func (s *Service) Completions(ctx context.Context, sessionId string, req chatm.ChatCompletionsReq) (res interface{}, err error) {
 	msg := make([]*schema.Message, 0)
 	if req.Messages != nil {
 		for _, v := range req.Messages {
-			m := &schema.Message{
-				Role:         schema.RoleType(v.Role),
-				Content:      v.Content,
-				MultiContent: nil,
-				Name:         "",
-				ToolCalls:    nil,
-				ToolCallID:   "",
-				ToolName:     "",
-				ResponseMeta: nil,
-				Extra:        nil,
-			}
-			msg = append(msg, m)
+			msg = append(msg, toSchemaMessage(v))
 		}
 	}
 	if len(msg) == 0 {
 		return nil, errors.New("messages is empty")
 	}
+	opts, err := modelOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	// 按名称选择模型,未指定时使用提示词模板指定的模型或默认模型
+	if req.Model != "" {
+		if !s.dal.Cm().Has(req.Model) {
+			return nil, fmt.Errorf("model %s is not configured", req.Model)
+		}
+		opts = append(opts, uaicharmodel.WithModel(req.Model))
+	}
+	tools, err := toToolInfos(req.Tools)
+	if err != nil {
+		return nil, err
+	}
+	toolChoice, err := toToolChoice(req.ToolChoice)
+	if err != nil {
+		return nil, err
+	}
+	in := &uaiagent.ChatInput{
+		SessionId:   sessionId,
+		Messages:    msg,
+		HistoryMode: uaiagent.HistoryMode(req.HistoryMode),
+		Options:     opts,
+		Tools:       tools,
+		ToolChoice:  toolChoice,
+		Events:      req.StreamEvents,
+	}
+	if !in.HistoryMode.Valid() {
+		return nil, errors.New("invalid history_mode: " + req.HistoryMode)
+	}
+	// 代理运行绑定请求上下文,客户端断开或调用取消接口时停止
+	ctx, release := s.register(ctx, sessionId)
 	if req.Stream != nil {
 		if *req.Stream {
 			// TODO: 实现流式处理,在控制器回写
-			stream, err := s.eino.UAiAgent(ctx, sessionId).Stream(msg[len(msg)-1].Content)
+			stream, err := s.eino.UAiAgent().Stream(ctx, in)
 			if err != nil {
+				release()
 				return nil, err
 			}
-			return stream, nil
+			// 输出流在控制器中读取,读取结束后注销
+			return releaseOnEnd(stream, release), nil
 		} else {
+			defer release()
 			// TODO: 实现非流式处理，在控制器识别与返回
-			generate, err := s.eino.UAiAgent(ctx, sessionId).Invoke(msg[len(msg)-1].Content)
+			generate, err := s.eino.UAiAgent().Invoke(ctx, in)
 			if err != nil {
 				return nil, err
 			}
 			return generate, nil
 		}
 	} else {
+		defer release()
 		// TODO: 实现非流式处理
-		generate, err := s.eino.UAiAgent(ctx, sessionId).Invoke(msg[len(msg)-1].Content)
+		generate, err := s.eino.UAiAgent().Invoke(ctx, in)
 		if err != nil {
 			return nil, err
 		}
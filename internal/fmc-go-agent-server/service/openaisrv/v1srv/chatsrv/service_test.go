@@ -0,0 +1,62 @@
+package chatsrv
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name string
+		end  func(s *Service, release func(), stream *schema.StreamReader[*schema.Message]) // 结束代理运行
+	}{
+		{"released after invoke", func(s *Service, release func(), _ *schema.StreamReader[*schema.Message]) {
+			release()
+		}},
+		{"stream read to the end", func(s *Service, release func(), stream *schema.StreamReader[*schema.Message]) {
+			out := releaseOnEnd(stream, release)
+			defer out.Close()
+			for {
+				if _, err := out.Recv(); err != nil {
+					return
+				}
+			}
+		}},
+		{"stream closed by reader", func(s *Service, release func(), stream *schema.StreamReader[*schema.Message]) {
+			releaseOnEnd(stream, release).Close()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{runs: make(map[string]map[*run]struct{})}
+			ctx, release := s.register(context.Background(), "s1")
+			if n := s.count("s1"); n != 1 {
+				t.Fatalf("runs = %d, want 1", n)
+			}
+			stream := schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage("a", nil)})
+			tt.end(s, release, stream)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+				t.Fatal("run context is not cancelled")
+			}
+			deadline := time.Now().Add(time.Second)
+			for s.count("s1") != 0 {
+				if time.Now().After(deadline) {
+					t.Fatal("run is still registered")
+				}
+				time.Sleep(time.Millisecond)
+			}
+		})
+	}
+}
+
+// count 会话中登记的代理运行数量
+func (s *Service) count(sessionId string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.runs[sessionId])
+}
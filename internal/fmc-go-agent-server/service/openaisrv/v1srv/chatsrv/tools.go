@@ -0,0 +1,92 @@
+package chatsrv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cloudwego/eino/schema"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/openaim/v1m/chatm"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaiagent"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// toSchemaMessage 将请求消息转换为eino消息,保留工具调用与工具结果
+func toSchemaMessage(v chatm.Message) *schema.Message {
+	m := &schema.Message{
+		Role:       schema.RoleType(v.Role),
+		Content:    v.Content,
+		Name:       v.Name,
+		ToolCallID: v.ToolCallID,
+	}
+	for _, tc := range v.ToolCalls {
+		m.ToolCalls = append(m.ToolCalls, schema.ToolCall{
+			ID:   tc.ID,
+			Type: tc.Type,
+			Function: schema.FunctionCall{
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			},
+		})
+	}
+	return m
+}
+
+// toToolInfos 将客户端声明的函数工具转换为eino工具信息
+func toToolInfos(tools []chatm.Tool) ([]*schema.ToolInfo, error) {
+	infos := make([]*schema.ToolInfo, 0, len(tools))
+	names := make(map[string]struct{}, len(tools))
+	for _, v := range tools {
+		if v.Type != "" && v.Type != "function" {
+			return nil, fmt.Errorf("unsupported tool type: %s", v.Type)
+		}
+		if v.Function.Name == "" {
+			return nil, errors.New("tool function name is required")
+		}
+		if _, ok := names[v.Function.Name]; ok {
+			return nil, fmt.Errorf("duplicate tool function: %s", v.Function.Name)
+		}
+		names[v.Function.Name] = struct{}{}
+		info := &schema.ToolInfo{
+			Name: v.Function.Name,
+			Desc: v.Function.Description,
+		}
+		if len(v.Function.Parameters) > 0 && string(v.Function.Parameters) != "null" {
+			params := &openapi3.Schema{}
+			err := json.Unmarshal(v.Function.Parameters, params)
+			if err != nil {
+				return nil, fmt.Errorf("invalid parameters of tool %s: %w", v.Function.Name, err)
+			}
+			info.ParamsOneOf = schema.NewParamsOneOfByOpenAPIV3(params)
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// toToolChoice 解析tool_choice,支持 "none"、"auto"、"required" 与 {"type":"function","function":{"name":"..."}}
+func toToolChoice(v interface{}) (*uaiagent.ToolChoice, error) {
+	switch tc := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		switch tc {
+		case "none":
+			return &uaiagent.ToolChoice{Choice: schema.ToolChoiceForbidden}, nil
+		case "auto":
+			return &uaiagent.ToolChoice{Choice: schema.ToolChoiceAllowed}, nil
+		case "required":
+			return &uaiagent.ToolChoice{Choice: schema.ToolChoiceForced}, nil
+		default:
+			return nil, fmt.Errorf("unsupported tool_choice: %s", tc)
+		}
+	case map[string]interface{}:
+		fn, _ := tc["function"].(map[string]interface{})
+		name, _ := fn["name"].(string)
+		if name == "" {
+			return nil, errors.New("tool_choice function name is required")
+		}
+		return &uaiagent.ToolChoice{Choice: schema.ToolChoiceForced, Name: name}, nil
+	default:
+		return nil, errors.New("tool_choice must be a string or an object")
+	}
+}
@@ -2,13 +2,12 @@ package v1srv
 
 import (
 	"context"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/openaim/v1m"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/openaisrv/v1srv/chatsrv"
-	"github.com/openai/openai-go"
-	"github.com/openai/openai-go/option"
-	"github.com/openai/openai-go/packages/pagination"
 )
 
 type If interface {
 	Chat() chatsrv.If
-	Models(ctx context.Context, opts ...option.RequestOption) (res *pagination.Page[openai.Model], err error)
+	// Models 返回配置的模型目录
+	Models(ctx context.Context) (res *v1m.ResModels, err error)
 }
@@ -5,45 +5,44 @@ import (
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/dal"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/iconsts"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/openaim/v1m"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/einosrv"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/msgsrv"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/openaisrv/v1srv/chatsrv"
 	"github.com/freedqo/fmc-go-agent/pkg/umsg"
-	"github.com/openai/openai-go"
-	"github.com/openai/openai-go/option"
-	"github.com/openai/openai-go/packages/pagination"
+	"time"
 )
 
 // New函数用于创建一个新的Service实例
 func New(ctx context.Context, opt *config.Config, dal dal.If, eino einosrv.If, msg msgsrv.If) If {
 	// 返回一个新的Service实例，传入的参数包括上下文、配置和数据库访问层
 	return &Service{
-		ctx:  ctx,
-		opt:  opt,
-		dal:  dal,
-		chat: chatsrv.New(ctx, opt, dal, eino),
-		eino: eino,
-		msg:  msg,
+		ctx:     ctx,
+		opt:     opt,
+		dal:     dal,
+		chat:    chatsrv.New(ctx, opt, dal, eino),
+		eino:    eino,
+		msg:     msg,
+		created: time.Now(),
 	}
 }
 
 type Service struct {
-	ctx  context.Context
-	opt  *config.Config
-	dal  dal.If
-	chat chatsrv.If
-	eino einosrv.If
-	msg  msgsrv.If
+	ctx     context.Context
+	opt     *config.Config
+	dal     dal.If
+	chat    chatsrv.If
+	eino    einosrv.If
+	msg     msgsrv.If
+	created time.Time // 服务启动时间,作为模型目录的创建时间
 }
 
 func (s *Service) Chat() chatsrv.If {
 	return s.chat
 }
 
-// Models 方法用于获取 openai.Model 的分页数据
-// Models 函数用于获取 openai.Model 类型的分页数据
-func (s *Service) Models(ctx context.Context, opts ...option.RequestOption) (res *pagination.Page[openai.Model], err error) {
-	// 调用 s.dal.Cm().V1().Models.List 函数，传入 ctx 和 opts 参数，返回分页数据 res 和错误信息 err
+// Models 返回配置的模型目录,默认模型在前
+func (s *Service) Models(ctx context.Context) (res *v1m.ResModels, err error) {
 	msg := &umsg.Message{
 		MessageBase: umsg.MessageBase{
 			ClientID:        "342432432423",
@@ -56,7 +55,19 @@ func (s *Service) Models(ctx context.Context, opts ...option.RequestOption) (res
 	}
 	uMsg := umsg.NewUMsg(msg, "McpServer", []string{umsg.ToWsServer, umsg.ToMqtt})
 	s.msg.Publish(uMsg)
-	return s.dal.Cm().V1().Models.List(ctx, opts...)
+	res = &v1m.ResModels{
+		Data:   make([]v1m.ResModel, 0),
+		Object: "list",
+	}
+	for _, v := range s.dal.Cm().Models() {
+		res.Data = append(res.Data, v1m.ResModel{
+			Id:      v.Name,
+			Created: int(s.created.Unix()),
+			Object:  "model",
+			OwnedBy: string(v.Provider),
+		})
+	}
+	return res, nil
 }
 
 var _ If = &Service{}
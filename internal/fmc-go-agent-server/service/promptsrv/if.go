@@ -11,7 +11,7 @@ type If interface {
 	Creat(ctx context.Context, req promptm.CreatReq) (*promptm.CreatResp, error)
 	// Delete 删除提示词模板,删除用户自定义的模板
 	Delete(ctx context.Context, req promptm.DeleteReq) (*promptm.DeleteResp, error)
-	// ModifySessionPrompt 修改会话提示词模板,修改指定的会话使用的提示词模板
+	// ModifySessionPrompt 修改会话提示词模板,修改指定的会话使用的提示词模板与模型,下一轮对话起生效
 	ModifySessionPrompt(ctx context.Context, req promptm.ModifySessionPromptReq) (*promptm.ModifySessionPromptResp, error)
 	// Query 查询用户可用的提示词模板,固化的模板、其他用户共享的模板、用户自定义的提示词模板
 	Query(ctx context.Context, req promptm.QueryReq) (*promptm.QueryResp, error)
@@ -3,12 +3,19 @@ package promptsrv
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/cloudwego/eino/schema"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/dal"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/iconsts"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/dalm/dbm/urtyg_ai_agent/model"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/dalm/dbm/urtyg_ai_agent/query"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/promptm"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/sessionsrv/dbconversation"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaiagent/mem"
 	"github.com/freedqo/fmc-go-agent/pkg/utils"
+	"strings"
 	"time"
 )
 
@@ -40,6 +47,20 @@ func (s *Service) GetPromptTemplate(ctx context.Context, req struct{}) (*promptm
 }
 
 func (s *Service) Creat(ctx context.Context, req promptm.CreatReq) (*promptm.CreatResp, error) {
+	if req.UserID == "" {
+		return nil, errors.New("userID is empty")
+	}
+	err := checkMemoryMode(req.MemoryMode)
+	if err != nil {
+		return nil, err
+	}
+	if req.Model != "" && !s.dal.Cm().Has(req.Model) {
+		return nil, fmt.Errorf("模型%s未配置", req.Model)
+	}
+	err = checkRetrieve(req.TopK, req.MinScore)
+	if err != nil {
+		return nil, err
+	}
 	now := time.Now()
 	data := &model.Ai_prompt{
 		ID:          utils.GetStringID(),
@@ -48,12 +69,17 @@ func (s *Service) Creat(ctx context.Context, req promptm.CreatReq) (*promptm.Cre
 		Name:        req.Name,
 		Description: req.Name,
 		Content:     req.Content,
+		MemoryMode:  req.MemoryMode,
+		Model:       req.Model,
+		TopK:        int32(req.TopK),
+		MinScore:    req.MinScore,
+		Collections: joinCollections(req.Collections),
 		IsShared:    false,
 		SharedAt:    time.Time{},
 		CreatedAt:   &now,
 		UpdatedAt:   &now,
 	}
-	err := s.dal.Db().Gdb().Urtyg_ai_agent().Ai_prompt().Gen().Add(data)
+	err = s.dal.Db().Gdb().Urtyg_ai_agent().Ai_prompt().Gen().Add(data)
 	if err != nil {
 		return nil, err
 	}
@@ -64,15 +90,38 @@ func (s *Service) Creat(ctx context.Context, req promptm.CreatReq) (*promptm.Cre
 		Name:        data.Name,
 		Description: data.Description,
 		Content:     data.Content,
+		MemoryMode:  data.MemoryMode,
+		Model:       data.Model,
+		TopK:        int(data.TopK),
+		MinScore:    data.MinScore,
+		Collections: splitCollections(data.Collections),
 		CreatedAt:   data.CreatedAt,
 		UpdatedAt:   data.UpdatedAt,
 	}, nil
 }
 
 func (s *Service) Delete(ctx context.Context, req promptm.DeleteReq) (*promptm.DeleteResp, error) {
-	result, err := s.dal.Db().Gdb().Urtyg_ai_agent().GenQ().
-		WithContext(ctx).Ai_prompt.
-		Where(s.dal.Db().Gdb().Urtyg_ai_agent().GenQ().Ai_prompt.ID.In(req.Ids...)).
+	if req.UserID == "" {
+		return nil, errors.New("userID is empty")
+	}
+	// 只能删除登录用户自己创建的模板,内置模板只能修改不能删除
+	q := s.dal.Db().Gdb().Urtyg_ai_agent().GenQ().Ai_prompt
+	list, err := q.WithContext(ctx).Where(q.ID.In(req.Ids...)).Find()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(list))
+	for _, v := range list {
+		if err = checkDeletable(ctx, v, req.UserID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, v.ID)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("提示词不存在")
+	}
+	result, err := q.WithContext(ctx).
+		Where(q.ID.In(ids...)).
 		Delete()
 	if err != nil {
 		return nil, err
@@ -83,11 +132,96 @@ func (s *Service) Delete(ctx context.Context, req promptm.DeleteReq) (*promptm.D
 	return nil, nil
 }
 
+// ModifySessionPrompt 切换会话使用的提示词模板与模型,下一轮对话起生效,并在聊天记录中追加切换标记
 func (s *Service) ModifySessionPrompt(ctx context.Context, req promptm.ModifySessionPromptReq) (*promptm.ModifySessionPromptResp, error) {
-	return nil, nil
+	if req.UserID == "" {
+		return nil, errors.New("userID is empty")
+	}
+	if req.SessionId == "" {
+		return nil, errors.New("sessionId is empty")
+	}
+	if req.PromptId == "" {
+		return nil, errors.New("promptId is empty")
+	}
+	if req.Model != "" && !s.dal.Cm().Has(req.Model) {
+		return nil, fmt.Errorf("模型%s未配置", req.Model)
+	}
+	session, err := s.dal.Db().Gdb().Urtyg_ai_agent().Ai_session_logs().Gen().First(&model.Ai_session_logs{ID: req.SessionId})
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, errors.New("会话不存在")
+	}
+	if session.UserID != req.UserID {
+		return nil, fmt.Errorf("%w: 会话不属于当前用户", authm.ErrForbidden)
+	}
+	p, err := s.dal.Db().Gdb().Urtyg_ai_agent().Ai_prompt().Gen().First(&model.Ai_prompt{ID: req.PromptId})
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, errors.New("提示词不存在")
+	}
+	if err = checkUsable(p, req.UserID); err != nil {
+		return nil, err
+	}
+	res := &promptm.ModifySessionPromptResp{
+		SessionId:  session.ID,
+		PromptId:   p.ID,
+		PromptName: p.Name,
+		Model:      req.Model,
+	}
+	if res.Model == "" {
+		res.Model = p.Model
+	}
+	if session.PromptID == p.ID && session.Model == req.Model {
+		return res, nil
+	}
+	// 切换标记只用于聊天记录的展示,不作为历史消息发送给模型
+	from := session.PromptID
+	old, err := s.dal.Db().Gdb().Urtyg_ai_agent().Ai_prompt().Gen().First(&model.Ai_prompt{ID: session.PromptID})
+	if err == nil && old != nil {
+		from = old.Name
+	}
+	var content string
+	switch {
+	case session.PromptID != p.ID && req.Model != "":
+		content = fmt.Sprintf("提示词模板由「%s」切换为「%s」,使用模型%s", from, p.Name, req.Model)
+	case session.PromptID != p.ID:
+		content = fmt.Sprintf("提示词模板由「%s」切换为「%s」", from, p.Name)
+	case req.Model != "":
+		content = fmt.Sprintf("会话模型切换为%s", req.Model)
+	default:
+		content = fmt.Sprintf("会话模型恢复为提示词模板「%s」指定的模型", p.Name)
+	}
+	marker := schema.SystemMessage(content)
+	marker.Extra = map[string]any{
+		"from_prompt_id": session.PromptID,
+		"prompt_id":      p.ID,
+		"model":          req.Model,
+	}
+	// 会话的修改与切换标记在同一事务中提交
+	err = s.dal.Db().Gdb().Urtyg_ai_agent().GenQ().Transaction(func(tx *query.Query) error {
+		q := tx.Ai_session_logs
+		_, err := q.WithContext(ctx).
+			Where(q.ID.Eq(session.ID)).
+			UpdateSimple(q.PromptID.Value(p.ID), q.Model.Value(req.Model))
+		if err != nil {
+			return err
+		}
+		return dbconversation.AppendMarker(ctx, tx, session.ID, req.UserID, marker)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
 func (s *Service) Query(ctx context.Context, req promptm.QueryReq) (*promptm.QueryResp, error) {
+	if req.UserID == nil || *req.UserID == "" {
+		return nil, errors.New("userID is empty")
+	}
 	list, _, err := s.dal.Db().Gdb().Urtyg_ai_agent().Ai_prompt().Gen().Find(&model.Ai_prompt_QueryReq{
 		Content:     req.Content,
 		CreatedAt:   req.CreatedAt,
@@ -119,6 +253,11 @@ func (s *Service) Query(ctx context.Context, req promptm.QueryReq) (*promptm.Que
 			Name:        v1.Name,
 			Description: v1.Description,
 			Content:     v1.Content,
+			MemoryMode:  v1.MemoryMode,
+			Model:       v1.Model,
+			TopK:        int(v1.TopK),
+			MinScore:    v1.MinScore,
+			Collections: splitCollections(v1.Collections),
 			CreatedAt:   v1.CreatedAt,
 			UpdatedAt:   v1.UpdatedAt,
 		})
@@ -127,6 +266,17 @@ func (s *Service) Query(ctx context.Context, req promptm.QueryReq) (*promptm.Que
 }
 
 func (s *Service) Update(ctx context.Context, req promptm.UpdateReq) (*promptm.UpdateResp, error) {
+	err := checkMemoryMode(req.MemoryMode)
+	if err != nil {
+		return nil, err
+	}
+	if req.Model != "" && !s.dal.Cm().Has(req.Model) {
+		return nil, fmt.Errorf("模型%s未配置", req.Model)
+	}
+	err = checkRetrieve(req.TopK, req.MinScore)
+	if err != nil {
+		return nil, err
+	}
 	first, err := s.dal.Db().Gdb().Urtyg_ai_agent().Ai_prompt().Gen().First(&model.Ai_prompt{ID: req.ID})
 	if err != nil {
 		return nil, err
@@ -134,6 +284,9 @@ func (s *Service) Update(ctx context.Context, req promptm.UpdateReq) (*promptm.U
 	if first == nil {
 		return nil, errors.New("提示词不存在")
 	}
+	if err = checkOwner(ctx, first, req.UserID); err != nil {
+		return nil, err
+	}
 	now := time.Now()
 	data := &model.Ai_prompt{
 		ID:          first.ID,
@@ -142,6 +295,11 @@ func (s *Service) Update(ctx context.Context, req promptm.UpdateReq) (*promptm.U
 		Name:        req.Name,
 		Description: req.Name,
 		Content:     req.Content,
+		MemoryMode:  req.MemoryMode,
+		Model:       req.Model,
+		TopK:        int32(req.TopK),
+		MinScore:    req.MinScore,
+		Collections: joinCollections(req.Collections),
 		IsShared:    false,
 		SharedAt:    time.Time{},
 		CreatedAt:   first.CreatedAt,
@@ -158,6 +316,11 @@ func (s *Service) Update(ctx context.Context, req promptm.UpdateReq) (*promptm.U
 		Name:        data.Name,
 		Description: data.Description,
 		Content:     data.Content,
+		MemoryMode:  data.MemoryMode,
+		Model:       data.Model,
+		TopK:        int(data.TopK),
+		MinScore:    data.MinScore,
+		Collections: splitCollections(data.Collections),
 		CreatedAt:   data.CreatedAt,
 		UpdatedAt:   data.UpdatedAt,
 	}, nil
@@ -173,6 +336,11 @@ func (s *Service) loadBaseDate() {
 			Name:        v.Name,
 			Description: v.Description,
 			Content:     v.Content,
+			MemoryMode:  v.MemoryMode,
+			Model:       v.Model,
+			TopK:        v.TopK,
+			MinScore:    v.MinScore,
+			Collections: v.Collections,
 			IsShared:    v.IsShared,
 			SharedAt:    v.SharedAt,
 			CreatedAt:   &v.SharedAt,
@@ -184,3 +352,79 @@ func (s *Service) loadBaseDate() {
 		}
 	}
 }
+
+// checkOwner 校验提示词模板由用户创建,内置模板由所有用户共享,需要prompt:admin权限才能修改
+func checkOwner(ctx context.Context, p *model.Ai_prompt, userID string) error {
+	if _, ok := iconsts.PromptDist[iconsts.PromptID(p.ID)]; ok {
+		if err := authm.Require(ctx, authm.PermPromptAdmin); err != nil {
+			return fmt.Errorf("%w: 内置提示词 %s", err, p.ID)
+		}
+		return nil
+	}
+	if p.UserID != userID {
+		return fmt.Errorf("%w: 提示词 %s", authm.ErrForbidden, p.ID)
+	}
+	return nil
+}
+
+// checkDeletable 校验用户可以删除提示词模板,内置模板不能删除
+func checkDeletable(ctx context.Context, p *model.Ai_prompt, userID string) error {
+	if _, ok := iconsts.PromptDist[iconsts.PromptID(p.ID)]; ok {
+		return fmt.Errorf("%w: 内置提示词 %s 不能删除", authm.ErrForbidden, p.ID)
+	}
+	return checkOwner(ctx, p, userID)
+}
+
+// checkUsable 校验用户可以在会话中使用提示词模板,内置模板与共享模板所有用户可用,其他模板只有创建者可用
+func checkUsable(p *model.Ai_prompt, userID string) error {
+	if _, ok := iconsts.PromptDist[iconsts.PromptID(p.ID)]; ok {
+		return nil
+	}
+	if p.IsShared || p.UserID == userID {
+		return nil
+	}
+	return fmt.Errorf("%w: 提示词 %s", authm.ErrForbidden, p.ID)
+}
+
+// checkMemoryMode 校验提示词模板的对话记忆策略
+func checkMemoryMode(m string) error {
+	switch mem.MemoryMode(m) {
+	case mem.MemoryModeFull, mem.MemoryModeSummary:
+		return nil
+	default:
+		return fmt.Errorf("不支持的对话记忆策略:%s", m)
+	}
+}
+
+// checkRetrieve 校验提示词模板的检索参数
+func checkRetrieve(topK int, minScore float64) error {
+	if topK < 0 {
+		return fmt.Errorf("检索返回的文档数量不能小于0:%d", topK)
+	}
+	if minScore < 0 {
+		return fmt.Errorf("检索文档的最低分数不能小于0:%v", minScore)
+	}
+	return nil
+}
+
+// joinCollections 可检索的知识库集合按逗号分隔存储
+func joinCollections(cs []string) string {
+	res := make([]string, 0, len(cs))
+	for _, v := range cs {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return strings.Join(res, ",")
+}
+
+// splitCollections 解析按逗号分隔存储的知识库集合
+func splitCollections(s string) []string {
+	res := make([]string, 0)
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
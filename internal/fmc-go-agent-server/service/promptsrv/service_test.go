@@ -0,0 +1,50 @@
+package promptsrv
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/iconsts"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/dalm/dbm/urtyg_ai_agent/model"
+)
+
+func TestPromptChecks(t *testing.T) {
+	builtin := &model.Ai_prompt{ID: string(iconsts.PromptID_IntelligentAssistant)}
+	own := &model.Ai_prompt{ID: "p1", UserID: "u1"}
+	shared := &model.Ai_prompt{ID: "p2", UserID: "u2", IsShared: true}
+	private := &model.Ai_prompt{ID: "p3", UserID: "u2"}
+	admin := authm.WithPrincipal(context.Background(), &authm.Principal{ID: "u1", Permissions: []string{authm.PermPromptAdmin}})
+	user := authm.WithPrincipal(context.Background(), &authm.Principal{ID: "u1"})
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		prompt    *model.Ai_prompt
+		owner     bool // checkOwner是否通过
+		deletable bool // checkDeletable是否通过
+		usable    bool // checkUsable是否通过
+	}{
+		{"builtin by admin", admin, builtin, true, false, true},
+		{"builtin by user", user, builtin, false, false, true},
+		{"own prompt", user, own, true, true, true},
+		{"shared prompt of others", user, shared, false, false, true},
+		{"private prompt of others", user, private, false, false, false},
+		{"private prompt of others by admin", admin, private, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := func(kind string, err error, want bool) {
+				if (err == nil) != want {
+					t.Errorf("%s = %v, want pass %v", kind, err, want)
+				}
+				if err != nil && !errors.Is(err, authm.ErrForbidden) {
+					t.Errorf("%s = %v, want ErrForbidden", kind, err)
+				}
+			}
+			check("checkOwner", checkOwner(tt.ctx, tt.prompt, "u1"), tt.owner)
+			check("checkDeletable", checkDeletable(tt.ctx, tt.prompt, "u1"), tt.deletable)
+			check("checkUsable", checkUsable(tt.prompt, "u1"), tt.usable)
+		})
+	}
+}
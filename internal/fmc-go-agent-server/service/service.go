@@ -4,7 +4,7 @@ import (
 	"context"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/dal"
-	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/dalm/extm/usercenterm"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/authsrv"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/einosrv"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/knowdbsrv"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/mcpsrv"
@@ -27,21 +27,24 @@ func New(ctx context.Context, opt *config.Config) If {
 	// 实例数据访问层
 	s.dal = dal.New(ctx, opt)
 
+	// 实例认证服务,按配置的认证方式认证请求
+	s.auth = authsrv.New(opt, s.dal)
+
 	// 实例mcp服务，提供内置独立（端口）的MCP Server支持
 	s.mcp = mcpsrv.New(ctx, opt, s.dal.Ext(), s.msg.Publish, s.msg.WaitingResMsg)
 
 	// 实例session服务
 	s.session = sessionsrv.New(ctx, opt, s.dal)
 
-	// 实例knowdb服务
-	s.knowdb = knowdbsrv.New()
-
 	// 实例prompt服务
 	s.prompt = promptsrv.New(opt, s.dal)
 
 	// 实例eino服务,并注入会话管理
 	s.eino = einosrv.New(ctx, opt, s.dal, s.session)
 
+	// 实例knowdb服务,上传的文件自动索引到向量数据库
+	s.knowdb = knowdbsrv.New(ctx, s.eino.VectorDb())
+
 	// 实例openai服务
 	s.openai = openaisrv.New(ctx, opt, s.dal, s.eino, s.msg)
 
@@ -61,6 +64,11 @@ type Service struct {
 	msg     msgsrv.If
 	knowdb  knowdbsrv.If
 	prompt  promptsrv.If
+	auth    authsrv.If
+}
+
+func (s *Service) Auth() authsrv.If {
+	return s.auth
 }
 
 func (s *Service) Prompt() promptsrv.If {
@@ -104,18 +112,6 @@ func (s *Service) RestStart() (done <-chan struct{}, err error) {
 	return s.Start(s.ctx)
 }
 
-func (s *Service) MidInvalidTokenIdByUserCenter(ctx context.Context, tokenId string) error {
-	userCenter, err := s.dal.Ext().UserCenter()
-	if err != nil {
-		return err
-	}
-	_, err = userCenter.InvalidToken(ctx, usercenterm.InvalidTokenReq{TokenId: tokenId})
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
 func (s *Service) Session() sessionsrv.If {
 	return s.session
 }
@@ -3,18 +3,25 @@ package dbconversation
 import (
 	"context"
 	"encoding/json"
+	model2 "github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/schema"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/dal/db/dbif"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/dalm/dbm/urtyg_ai_agent/model"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/dalm/dbm/urtyg_ai_agent/query"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/store/log"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaiagent/mem"
 	"github.com/freedqo/fmc-go-agent/pkg/ujwt"
 	"github.com/freedqo/fmc-go-agent/pkg/utils"
 	"go.uber.org/zap"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
-func New(sessionId string, uJwt ujwt.If, db dbif.If) If {
+// New 创建数据库会话,提示词模板的记忆策略为summary时,历史消息按token预算加载,超出时摘要较早的对话
+func New(ctx context.Context, sessionId string, uJwt ujwt.If, db dbif.If, cm model2.BaseChatModel, budget *mem.BudgetOption, session *config.SessionOption) If {
 	uClaims, err := uJwt.VerifySession(sessionId)
 	if err != nil {
 		panic(err)
@@ -42,65 +49,135 @@ func New(sessionId string, uJwt ujwt.If, db dbif.If) If {
 		ID: first.PromptID,
 	})
 	if err1 != nil {
-		panic(err)
+		panic(err1)
+	}
+	if first1 == nil {
+		panic("会话Id无效,提示词模板不存在,请切换提示词模板")
 	}
 	return &Service{
-		sessionId: sessionId,
-		userId:    uClaims.UserId,
-		promptID:  first.PromptID,
-		prompt:    first1.Content,
-		uJwt:      uJwt,
-		db:        db,
-		log:       log.SysLog(),
+		ctx:         ctx,
+		sessionId:   sessionId,
+		userId:      uClaims.UserId,
+		promptID:    first.PromptID,
+		title:       first.Title,
+		titleSource: first.TitleSource,
+		prompt:      first1.Content,
+		memoryMode:  mem.MemoryMode(first1.MemoryMode),
+		model:       sessionModel(first, first1),
+		topK:        int(first1.TopK),
+		minScore:    first1.MinScore,
+		collections: splitCollections(first1.Collections),
+		cm:          cm,
+		budget:      budget,
+		session:     session,
+		uJwt:        uJwt,
+		db:          db,
+		log:         log.SysLog(),
 	}
 }
 
+// RoleMemo 对话记录中摘要备忘的角色,不属于对话内容,会话记录列表中不展示
+const RoleMemo = "memo"
+
+// RoleMarker 对话记录中会话变更标记的角色,如切换提示词模板,会话记录列表中展示,不作为历史消息发送给模型
+const RoleMarker = "marker"
+
+// extraKeyMemoUntil 摘要备忘覆盖到的对话记录序号
+const extraKeyMemoUntil = "memo_until"
+
 type Service struct {
-	sessionId string
-	userId    string
-	promptID  string
-	prompt    string
-	db        dbif.If
-	uJwt      ujwt.If
-	log       *zap.SugaredLogger
+	ctx         context.Context
+	sessionId   string
+	userId      string
+	promptID    string
+	title       string // 会话标题
+	titleSource string // 会话标题来源
+	prompt      string
+	memoryMode  mem.MemoryMode        // 对话记忆策略
+	model       string                // 提示词模板指定的模型
+	topK        int                   // 提示词模板指定的检索文档数量
+	minScore    float64               // 提示词模板指定的检索最低分数
+	collections []string              // 提示词模板可检索的知识库集合
+	cm          model2.BaseChatModel  // 用于生成摘要
+	budget      *mem.BudgetOption     // 对话记忆的token预算
+	session     *config.SessionOption // 会话标题配置
+	db          dbif.If
+	uJwt        ujwt.If
+	log         *zap.SugaredLogger
 }
 
 func (s *Service) Append(msg *schema.Message) {
-	js, err := json.Marshal(msg)
+	// 更新会话的最后对话时间,只更新该列,不覆盖会话的标题、置顶等信息
+	q := s.db.Urtyg_ai_agent().GenQ().Ai_session_logs
+	_, err := q.WithContext(context.Background()).Where(q.ID.Eq(s.sessionId)).Update(q.LastAt, time.Now())
 	if err != nil {
-		s.log.Errorw("marshal message failed", "err", err)
+		s.log.Errorw("update session last time failed", "err", err)
 		return
 	}
-	// 更新会话记录表的时间
-	err = s.db.Urtyg_ai_agent().Ai_session_logs().Gen().Save(&model.Ai_session_logs{
-		ID:       s.sessionId,
-		UserID:   s.userId,
-		PromptID: s.promptID,
-		LastAt:   time.Now(),
-	})
+	err = s.add(string(msg.Role), msg)
 	if err != nil {
-		s.log.Errorw("get max order failed", "err", err)
+		s.log.Errorw("append message failed", "err", err)
 		return
 	}
-	order, err := s.db.Urtyg_ai_agent().Ai_chat_logs().Self().GetMaxOrder(context.Background(), s.sessionId)
+	switch {
+	case msg.Role == schema.User:
+		s.setAutoTitle(msg)
+	case msg.Role == schema.Assistant && msg.Content != "" && len(msg.ToolCalls) == 0:
+		s.genTitle(msg)
+	}
+}
+
+// add 追加一条对话记录
+func (s *Service) add(role string, msg *schema.Message) error {
+	return addLog(s.db, s.sessionId, s.userId, role, msg)
+}
+
+// AppendMarker 追加一条会话变更标记,标记会话从此处起的变更;tx为调用方的事务,与会话的变更一起提交
+func AppendMarker(ctx context.Context, tx *query.Query, sessionId, userId string, msg *schema.Message) error {
+	js, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	q := tx.Ai_chat_logs
+	var order *int32
+	err = q.WithContext(ctx).Where(q.SessionID.Eq(sessionId)).Select(q.Order.Max()).Scan(&order)
 	if err != nil {
-		s.log.Errorw("get max order failed", "err", err)
-		return
+		return err
+	}
+	if order == nil {
+		order = new(int32)
 	}
 	now := time.Now()
-	err = s.db.Urtyg_ai_agent().Ai_chat_logs().Gen().Add(&model.Ai_chat_logs{
+	return q.WithContext(ctx).Create(&model.Ai_chat_logs{
 		ID:        utils.GetStringID(),
-		SessionID: s.sessionId,
-		UserID:    s.userId,
-		Role:      string(msg.Role),
+		SessionID: sessionId,
+		UserID:    userId,
+		Role:      RoleMarker,
 		Content:   string(js),
-		Order:     int32(order + 1),
+		Order:     *order + 1,
 		CreatedAt: &now,
 	})
+}
+
+func addLog(db dbif.If, sessionId, userId, role string, msg *schema.Message) error {
+	js, err := json.Marshal(msg)
 	if err != nil {
-		s.log.Errorw("append message failed", "err", err)
-		return
+		return err
+	}
+	order, err := db.Urtyg_ai_agent().Ai_chat_logs().Self().GetMaxOrder(context.Background(), sessionId)
+	if err != nil {
+		return err
 	}
+	now := time.Now()
+	return db.Urtyg_ai_agent().Ai_chat_logs().Gen().Add(&model.Ai_chat_logs{
+		ID:        utils.GetStringID(),
+		SessionID: sessionId,
+		UserID:    userId,
+		Role:      role,
+		Content:   string(js),
+		Order:     int32(order + 1),
+		CreatedAt: &now,
+	})
 }
 
 func (s *Service) GetMessages() []*schema.Message {
@@ -122,8 +199,32 @@ func (s *Service) GetMessages() []*schema.Message {
 	sort.Slice(chatLogs, func(i, j int) bool {
 		return chatLogs[i].Order < chatLogs[j].Order
 	})
+	// 最近一次的摘要备忘
+	var memo string
+	var until int32
+	for _, v := range chatLogs {
+		if v.Role != RoleMemo {
+			continue
+		}
+		var msg schema.Message
+		err := json.Unmarshal([]byte(v.Content), &msg)
+		if err != nil {
+			s.log.Errorw("unmarshal memo failed", "err", err)
+			continue
+		}
+		memo = msg.Content
+		until = memoUntil(&msg)
+	}
 	res := make([]*schema.Message, 0, len(chatLogs))
+	orders := make([]int32, 0, len(chatLogs))
 	for _, v := range chatLogs {
+		if v.Role == RoleMemo || v.Role == RoleMarker {
+			continue
+		}
+		// 全部历史模式下不使用摘要
+		if s.memoryMode == mem.MemoryModeSummary && v.Order <= until {
+			continue
+		}
 		var msg schema.Message
 		err := json.Unmarshal([]byte(v.Content), &msg)
 		if err != nil {
@@ -131,8 +232,96 @@ func (s *Service) GetMessages() []*schema.Message {
 			continue
 		}
 		res = append(res, &msg)
+		orders = append(orders, v.Order)
+	}
+	if s.memoryMode != mem.MemoryModeSummary {
+		return res
+	}
+	return s.compact(memo, res, orders)
+}
+
+// compacting 正在后台摘要的会话,同一会话同时只有一个摘要任务
+var compacting sync.Map
+
+// compactTimeout 一次后台摘要的超时时间
+const compactTimeout = 2 * time.Minute
+
+// compact 按token预算压缩历史消息
+// 超出预算时本轮只使用最近的对话,较早的对话在后台与之前的摘要合并为新的摘要备忘,不阻塞本轮对话
+func (s *Service) compact(memo string, msgs []*schema.Message, orders []int32) []*schema.Message {
+	if n := mem.Split(s.budget, memo, msgs); n > 0 {
+		s.compactAsync(memo, msgs[:n], orders[n-1])
+		msgs = msgs[n:]
+	}
+	if memo == "" {
+		return msgs
+	}
+	return append([]*schema.Message{mem.NewMemo(memo)}, msgs...)
+}
+
+// compactAsync 后台生成摘要备忘,until为摘要覆盖到的对话记录序号
+func (s *Service) compactAsync(memo string, msgs []*schema.Message, until int32) {
+	if s.cm == nil {
+		return
+	}
+	if _, running := compacting.LoadOrStore(s.sessionId, struct{}{}); running {
+		return
+	}
+	go func() {
+		defer compacting.Delete(s.sessionId)
+		ctx, cancel := context.WithTimeout(s.ctx, compactTimeout)
+		defer cancel()
+		summary, err := mem.Summarize(ctx, s.cm, memo, msgs, int(float64(s.budget.Budget())*s.budget.SummaryRatio))
+		if err != nil {
+			// 摘要失败时仍使用原有的摘要,下一轮对话重试
+			s.log.Errorw("compact conversation failed", "sessionId", s.sessionId, "err", err)
+			return
+		}
+		// 其他实例可能已写入覆盖更多对话的摘要
+		latest, err := s.latestMemoUntil(ctx)
+		if err != nil {
+			s.log.Errorw("query memo failed", "sessionId", s.sessionId, "err", err)
+			return
+		}
+		if latest >= until {
+			return
+		}
+		msg := schema.SystemMessage(summary)
+		msg.Extra = map[string]any{extraKeyMemoUntil: until}
+		if err = s.add(RoleMemo, msg); err != nil {
+			s.log.Errorw("save memo failed", "sessionId", s.sessionId, "err", err)
+		}
+	}()
+}
+
+// latestMemoUntil 最近一次摘要备忘覆盖到的对话记录序号,没有摘要时为0
+func (s *Service) latestMemoUntil(ctx context.Context) (int32, error) {
+	q := s.db.Urtyg_ai_agent().GenQ().Ai_chat_logs
+	logs, err := q.WithContext(ctx).
+		Where(q.SessionID.Eq(s.sessionId), q.Role.Eq(RoleMemo)).
+		Order(q.Order.Desc()).
+		Limit(1).
+		Find()
+	if err != nil || len(logs) == 0 {
+		return 0, err
+	}
+	var msg schema.Message
+	if err = json.Unmarshal([]byte(logs[0].Content), &msg); err != nil {
+		return 0, err
+	}
+	return memoUntil(&msg), nil
+}
+
+// memoUntil 读取摘要备忘覆盖到的对话记录序号
+func memoUntil(msg *schema.Message) int32 {
+	switch v := msg.Extra[extraKeyMemoUntil].(type) {
+	case float64:
+		return int32(v)
+	case int32:
+		return v
+	default:
+		return 0
 	}
-	return res
 }
 
 func (s *Service) Load() error {
@@ -160,6 +349,41 @@ func (s *Service) GetPrompt() string {
 	}
 	return first1.Content
 }
+
+// GetModel 会话或提示词模板指定的模型,为空时使用默认模型
+func (s *Service) GetModel() string {
+	return s.model
+}
+
+// GetRetrieve 提示词模板指定的检索参数,为0时使用系统配置
+func (s *Service) GetRetrieve() (topK int, minScore float64) {
+	return s.topK, s.minScore
+}
+
+// GetKnowledge 提示词模板可检索的知识库集合与会话用户,用户只能检索公开集合与自己的私有集合
+func (s *Service) GetKnowledge() (collections []string, userId string) {
+	return s.collections, s.userId
+}
+
+// sessionModel 会话指定的模型优先,其次为提示词模板指定的模型
+func sessionModel(session *model.Ai_session_logs, prompt *model.Ai_prompt) string {
+	if session.Model != "" {
+		return session.Model
+	}
+	return prompt.Model
+}
+
+// splitCollections 解析按逗号分隔存储的知识库集合
+func splitCollections(s string) []string {
+	res := make([]string, 0)
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
 func (s *Service) GetSessionId() string {
 	return s.sessionId
 }
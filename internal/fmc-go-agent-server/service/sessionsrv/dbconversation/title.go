@@ -0,0 +1,128 @@
+package dbconversation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"gorm.io/gen/field"
+)
+
+// 会话标题来源
+const (
+	TitleAuto = "auto" // 用户的第一条消息
+	TitleLLM  = "llm"  // 模型生成
+	TitleUser = "user" // 用户修改
+)
+
+const titlePrompt = `你是对话标题生成器。根据用户的问题与助手的回答,生成一个简短的会话标题。
+要求:
+1. 使用与用户问题相同的语言;
+2. 不超过%d个字符,不使用标点与引号;
+3. 只输出标题本身。`
+
+// DeriveTitle 由消息内容截取会话标题,按字符截取,合并换行与连续空白
+func DeriveTitle(content string, n int) string {
+	title := []rune(strings.Join(strings.Fields(content), " "))
+	if n > 0 && len(title) > n {
+		title = title[:n]
+	}
+	return string(title)
+}
+
+// NoTitle 会话没有标题,升级前创建的会话标题为NULL
+func NoTitle(title field.String) field.Expr {
+	return field.Or(title.IsNull(), title.Eq(""))
+}
+
+// autoTitled 标题可由模型生成替换:没有标题来源(升级前为NULL)或由第一条消息截取
+func autoTitled(source field.String) field.Expr {
+	return field.Or(source.IsNull(), source.In("", TitleAuto))
+}
+
+// setAutoTitle 会话还没有标题时以用户的第一条消息作为标题
+func (s *Service) setAutoTitle(msg *schema.Message) {
+	if s.title != "" {
+		return
+	}
+	title := DeriveTitle(msg.Content, s.titleLength())
+	if title == "" {
+		return
+	}
+	q := s.db.Urtyg_ai_agent().GenQ().Ai_session_logs
+	_, err := q.WithContext(context.Background()).
+		Where(q.ID.Eq(s.sessionId), NoTitle(q.Title)).
+		UpdateSimple(q.Title.Value(title), q.TitleSource.Value(TitleAuto))
+	if err != nil {
+		s.log.Errorw("save session title failed", "sessionId", s.sessionId, "err", err)
+		return
+	}
+	s.title, s.titleSource = title, TitleAuto
+}
+
+// genTitle 第一轮对话后由模型生成会话标题,只替换由第一条消息截取的标题,用户修改过的标题不会被覆盖;
+// 生成失败时保留原标题,同样记为模型生成,不再重试
+func (s *Service) genTitle(answer *schema.Message) {
+	if s.session == nil || !s.session.AutoTitle || s.cm == nil {
+		return
+	}
+	if s.titleSource != "" && s.titleSource != TitleAuto {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(s.ctx, 30*time.Second)
+		defer cancel()
+		title, err := s.llmTitle(ctx, answer)
+		if err != nil {
+			s.log.Errorw("generate session title failed", "sessionId", s.sessionId, "err", err)
+		}
+		sq := s.db.Urtyg_ai_agent().GenQ().Ai_session_logs
+		updates := []field.AssignExpr{sq.TitleSource.Value(TitleLLM)}
+		if title != "" {
+			updates = append(updates, sq.Title.Value(title))
+		}
+		// 生成期间用户可能已修改标题;超时后仍需记录,使用新的上下文
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = sq.WithContext(ctx).
+			Where(sq.ID.Eq(s.sessionId), autoTitled(sq.TitleSource)).
+			UpdateSimple(updates...)
+		if err != nil {
+			s.log.Errorw("save session title failed", "sessionId", s.sessionId, "err", err)
+		}
+	}()
+}
+
+// llmTitle 由用户的第一条消息与助手的回答生成标题
+func (s *Service) llmTitle(ctx context.Context, answer *schema.Message) (string, error) {
+	q := s.db.Urtyg_ai_agent().GenQ().Ai_chat_logs
+	first, err := q.WithContext(ctx).
+		Where(q.SessionID.Eq(s.sessionId), q.Role.Eq(string(schema.User))).
+		Order(q.Order).
+		First()
+	if err != nil {
+		return "", err
+	}
+	question := &schema.Message{}
+	if err = json.Unmarshal([]byte(first.Content), question); err != nil {
+		return "", err
+	}
+	out, err := s.cm.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(fmt.Sprintf(titlePrompt, s.titleLength())),
+		schema.UserMessage("# 用户\n" + question.Content + "\n\n# 助手\n" + answer.Content),
+	})
+	if err != nil {
+		return "", err
+	}
+	return DeriveTitle(strings.Trim(strings.TrimSpace(out.Content), `"'“”《》`), s.titleLength()), nil
+}
+
+func (s *Service) titleLength() int {
+	if s.session == nil || s.session.TitleLength <= 0 {
+		return 20
+	}
+	return s.session.TitleLength
+}
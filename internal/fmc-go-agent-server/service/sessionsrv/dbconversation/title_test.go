@@ -0,0 +1,68 @@
+package dbconversation
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/dalm/dbm/urtyg_ai_agent/model"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/dalm/dbm/urtyg_ai_agent/query"
+	"gorm.io/driver/mysql"
+	"gorm.io/gen/field"
+	"gorm.io/gorm"
+)
+
+func TestDeriveTitle(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		n       int
+		want    string
+	}{
+		{"short", "你好", 20, "你好"},
+		{"truncate by rune", "如何申请年假以及需要哪些材料", 6, "如何申请年假"},
+		{"merge whitespace", "  how   to\n\tdeploy \r\n the agent  ", 20, "how to deploy the ag"},
+		{"no limit", "a b  c", 0, "a b c"},
+		{"blank", " \n\t ", 20, ""},
+		{"mixed", "Redis 集群怎么配置", 8, "Redis 集群"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DeriveTitle(tt.content, tt.n); got != tt.want {
+				t.Errorf("DeriveTitle(%q, %d) = %q, want %q", tt.content, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+// dryRun 以MySQL方言生成SQL,不连接数据库
+func dryRun(t *testing.T) *query.Query {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "u:p@tcp(127.0.0.1:1)/db", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return query.Use(db)
+}
+
+func TestTitleConds(t *testing.T) {
+	q := dryRun(t).Ai_session_logs
+	tests := []struct {
+		name string
+		cond field.Expr
+		want string
+	}{
+		{"null or empty title", NoTitle(q.Title), "(`ai_session_logs`.`title` IS NULL OR `ai_session_logs`.`title` = ?)"},
+		{"null, empty or auto title source", autoTitled(q.TitleSource),
+			"(`ai_session_logs`.`title_source` IS NULL OR `ai_session_logs`.`title_source` IN (?,?))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := q.WithContext(context.Background()).Where(tt.cond).UnderlyingDB().Find(&model.Ai_session_logs{}).Statement
+			if sql := stmt.SQL.String(); !strings.Contains(sql, tt.want) {
+				t.Errorf("sql = %s, want condition %s", sql, tt.want)
+			}
+		})
+	}
+}
@@ -11,13 +11,17 @@ type If interface {
 	QuerySessionChatLogsByUser(ctx context.Context, req sessionm.QuerySessionChatLogsByUserReq) (*sessionm.QuerySessionChatLogsByUserResp, error)
 	// CreatSession 创建一个会话（返回一个会话ID）
 	CreatSession(ctx context.Context, req sessionm.CreatSessionReq) (*sessionm.CreatSessionResp, error)
-	// UserSessionList 获取用户的会话列表（简化数据,所有或者分页）
+	// UserSessionList 获取用户的会话列表（按会话分页,置顶在前,其余按最后对话时间倒序）
 	UserSessionList(ctx context.Context, req sessionm.UserSessionListReq) (*sessionm.UserSessionListResp, error)
 	// SessionChatLogList 获取用户的会话列表（改对话的完整对话历史,所有）
 	SessionChatLogList(ctx context.Context, req sessionm.SessionChatLogListReq) (*sessionm.SessionChatLogListResp, error)
-	// DeleteSessions 删除多个对话
+	// UpdateSession 修改会话的标题、置顶、归档与元数据
+	UpdateSession(ctx context.Context, req sessionm.UpdateSessionReq) (*sessionm.UpdateSessionResp, error)
+	// DeleteSessions 删除多个对话（软删除,保留聊天记录）
 	DeleteSessions(ctx context.Context, req sessionm.DeleteSessionsReq) (*sessionm.DeleteSessionsResp, error)
 	// DeleteChatLogs 删除多条对话内容
 	DeleteChatLogs(ctx context.Context, req sessionm.DeleteChatLogsReq) (*sessionm.DeleteChatLogsResp, error)
+	// CheckSession 校验会话由用户创建,不属于用户时返回authm.ErrForbidden
+	CheckSession(sessionId, userId string) error
 	mem.MemoryIf
 }
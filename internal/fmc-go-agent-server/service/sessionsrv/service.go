@@ -9,15 +9,22 @@ import (
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/config"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/dal"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/iconsts"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/authm"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/dalm/dbm/urtyg_ai_agent/model"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/model/sessionm"
 	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/service/sessionsrv/dbconversation"
+	"github.com/freedqo/fmc-go-agent/internal/fmc-go-agent-server/store/log"
 	"github.com/freedqo/fmc-go-agent/pkg/uai/uaiagent/mem"
 	"github.com/freedqo/fmc-go-agent/pkg/ujwt"
 	"github.com/freedqo/fmc-go-agent/pkg/utils"
+	"gorm.io/gen"
+	"gorm.io/gen/field"
+	"gorm.io/gorm/clause"
 	"sort"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 // New 函数用于创建一个新的Service实例
@@ -30,6 +37,7 @@ func New(ctx context.Context, opt *config.Config, dal dal.If) If {
 		uJwt: ujwt.New("fmc-go-ai-agent", "your jwt key"),
 		mu:   sync.Mutex{},
 	}
+	go s.fillTitles(ctx)
 	return s
 }
 
@@ -44,109 +52,210 @@ type Service struct {
 func (s *Service) GetConversation(sessionId string, createIfNotExist bool) mem.ConversationIf {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	con := dbconversation.New(sessionId, s.uJwt, s.dal.Db().Gdb())
+	con := dbconversation.New(s.ctx, sessionId, s.uJwt, s.dal.Db().Gdb(), s.dal.Cm(), s.memBudget(), s.opt.Session)
 	return con
 }
 
+// memBudget 对话记忆的token预算,未单独配置上下文窗口时使用模型配置
+func (s *Service) memBudget() *mem.BudgetOption {
+	budget := mem.NewDefaultBudgetOption()
+	if s.opt.Mem != nil {
+		b := *s.opt.Mem
+		budget = &b
+	}
+	if budget.ContextWindow <= 0 && s.opt.UCM != nil {
+		budget.ContextWindow = s.opt.UCM.ContextWindow
+	}
+	return budget
+}
+
+// UserSessionList 按会话分页查询用户的会话,置顶的会话在前,其余按最后对话时间倒序
 func (s *Service) UserSessionList(ctx context.Context, req sessionm.UserSessionListReq) (*sessionm.UserSessionListResp, error) {
 	if req.UserId == "" {
 		return nil, fmt.Errorf("userId is empty")
 	}
-	logs, i, err := s.dal.Db().Gdb().Urtyg_ai_agent().Ai_chat_logs().Gen().Find(&model.Ai_chat_logs_QueryReq{
-		Content:   nil,
-		CreatedAt: nil,
-		ID:        nil,
-		Order:     nil,
-		Role:      nil,
-		SessionID: nil,
-		UserID:    &req.UserId,
-		OrderBy:   nil,
-		IsLike:    false,
-		Page:      req.Page,
-	})
+	q := s.dal.Db().Gdb().Urtyg_ai_agent().GenQ().Ai_session_logs
+	do := q.WithContext(ctx).Where(q.UserID.Eq(req.UserId), q.Archived.Is(req.Archived))
+	if req.Keyword != "" {
+		do = do.Where(gen.Cond(titleLike(req.Keyword))...)
+	}
+	do = do.Order(q.Pinned.Desc(), q.LastAt.Desc())
+	var sessions []*model.Ai_session_logs
+	var err error
+	if req.Page != nil && req.Page.Size > 0 {
+		if req.Page.Index < 1 {
+			req.Page.Index = 1
+		}
+		var total int64
+		sessions, total, err = do.FindByPage((req.Page.Index-1)*req.Page.Size, req.Page.Size)
+		req.Page.Total = int(total)
+	} else {
+		sessions, err = do.Find()
+	}
 	if err != nil {
 		return nil, err
 	}
-	m1 := make(map[string][]*model.Ai_chat_logs, 0)
-	// 按会话id的不同进行分类
-	for _, v := range logs {
-		_, ok := m1[v.SessionID]
-		if !ok {
-			m1[v.SessionID] = make([]*model.Ai_chat_logs, 0)
-		}
-		m1[v.SessionID] = append(m1[v.SessionID], v)
+	res := &sessionm.UserSessionListResp{
+		SessionList: make([]*sessionm.UserSessionListRespData, 0, len(sessions)),
+		Page:        req.Page,
+	}
+	for _, v := range sessions {
+		res.SessionList = append(res.SessionList, toSessionData(v))
+	}
+	return res, nil
+}
 
+// likeEscaper 转义LIKE的通配符,显式指定转义符,不依赖各数据库默认的转义符
+var likeEscaper = strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`)
+
+// escapeLike 转义关键字中的通配符,按字面匹配
+func escapeLike(keyword string) string {
+	return likeEscaper.Replace(keyword)
+}
+
+// titleLike 按关键字模糊匹配会话标题
+func titleLike(keyword string) clause.Expression {
+	return clause.Expr{
+		SQL:  "? LIKE ? ESCAPE '!'",
+		Vars: []any{clause.Column{Table: clause.CurrentTable, Name: "title"}, "%" + escapeLike(keyword) + "%"},
 	}
-	mapData := make(map[string]*sessionm.UserSessionListRespData, 0)
+}
 
-	// 对每个会话内的对话记录按order排序
-	for _, v := range m1 {
-		// 排序逻辑
-		sort.Slice(v, func(i, j int) bool {
-			// 2. 按 Order 升序
-			return v[i].Order < v[j].Order
-		})
-		// 拿第一条记录的内容来做标题
-		for _, v1 := range v {
-			_, ok := mapData[v1.SessionID]
-			if ok {
-				break
+// fillTitles 启动时为没有标题的会话(升级前创建的会话)以第一条用户消息补全标题,分批处理
+func (s *Service) fillTitles(ctx context.Context) {
+	const batch = 200
+	n := 20
+	if s.opt.Session != nil && s.opt.Session.TitleLength > 0 {
+		n = s.opt.Session.TitleLength
+	}
+	sq := s.dal.Db().Gdb().Urtyg_ai_agent().GenQ().Ai_session_logs
+	q := s.dal.Db().Gdb().Urtyg_ai_agent().GenQ().Ai_chat_logs
+	last := ""
+	for ctx.Err() == nil {
+		sessions, err := sq.WithContext(ctx).
+			Where(dbconversation.NoTitle(sq.Title), sq.ID.Gt(last)).
+			Order(sq.ID).
+			Limit(batch).
+			Find()
+		if err != nil {
+			log.SysLog().Errorw("query untitled sessions failed", "err", err)
+			return
+		}
+		if len(sessions) == 0 {
+			return
+		}
+		ids := make([]string, 0, len(sessions))
+		for _, v := range sessions {
+			ids = append(ids, v.ID)
+		}
+		last = ids[len(ids)-1]
+		logs, err := q.WithContext(ctx).
+			Where(q.SessionID.In(ids...), q.Role.Eq(string(schema.User))).
+			Order(q.SessionID, q.Order).
+			Find()
+		if err != nil {
+			log.SysLog().Errorw("query session first message failed", "err", err)
+			return
+		}
+		titles := make(map[string]string, len(ids))
+		for _, v := range logs {
+			if _, ok := titles[v.SessionID]; ok {
+				continue
 			}
 			msg := &schema.Message{}
-			err := json.Unmarshal([]byte(v1.Content), msg)
-			if err != nil {
-				return nil, err
+			if err = json.Unmarshal([]byte(v.Content), msg); err != nil {
+				continue
 			}
-			index := 20
-			if len(msg.Content) <= index {
-				index = len(msg.Content)
+			titles[v.SessionID] = dbconversation.DeriveTitle(msg.Content, n)
+		}
+		for id, title := range titles {
+			if title == "" {
+				continue
 			}
-			session := &sessionm.UserSessionListRespData{
-				SessionId: v1.SessionID,
-				Title:     msg.Content[:index],
-				CreatAt:   v1.CreatedAt,
+			_, err = sq.WithContext(ctx).
+				Where(sq.ID.Eq(id), dbconversation.NoTitle(sq.Title)).
+				UpdateSimple(sq.Title.Value(title), sq.TitleSource.Value(dbconversation.TitleAuto))
+			if err != nil {
+				log.SysLog().Errorw("save session title failed", "sessionId", id, "err", err)
 			}
-			mapData[v1.SessionID] = session
+		}
+		if len(sessions) < batch {
+			return
 		}
 	}
-	if req.Page != nil {
-		req.Page.Total = int(i)
+}
+
+// UpdateSession 修改会话的标题、置顶、归档与元数据,只修改请求中提供的字段
+func (s *Service) UpdateSession(ctx context.Context, req sessionm.UpdateSessionReq) (*sessionm.UpdateSessionResp, error) {
+	if req.SessionId == "" {
+		return nil, fmt.Errorf("sessionId is empty")
 	}
-	res := &sessionm.UserSessionListResp{
-		SessionList: make([]*sessionm.UserSessionListRespData, 0),
-		Page:        req.Page,
+	if err := s.CheckSession(req.SessionId, req.UserId); err != nil {
+		return nil, err
 	}
-	for _, v := range mapData {
-		v1 := *v
-		res.SessionList = append(res.SessionList, &v1)
-	}
-	sort.Slice(res.SessionList, func(i, j int) bool {
-		// 获取两个元素的时间指针
-		t1 := res.SessionList[i].CreatAt
-		t2 := res.SessionList[j].CreatAt
-		// 处理 nil 情况（nil 视为“最早”，排在最后）
-		switch {
-		case t1 == nil && t2 == nil:
-			// 两者都为 nil，顺序不变
-			return false
-		case t1 == nil:
-			// t1 为 nil，t2 非 nil → t1 排在 t2 后面
-			return false
-		case t2 == nil:
-			// t2 为 nil，t1 非 nil → t1 排在 t2 前面
-			return true
-		default:
-			// 两者都非 nil，比较时间：t1 比 t2 晚（更新），则 t1 排在前面
-			return t1.After(*t2)
+	q := s.dal.Db().Gdb().Urtyg_ai_agent().GenQ().Ai_session_logs
+	updates := make([]field.AssignExpr, 0)
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if title == "" {
+			return nil, fmt.Errorf("title is empty")
 		}
-	})
-	return res, nil
+		if utf8.RuneCountInString(title) > 200 {
+			return nil, fmt.Errorf("title is too long")
+		}
+		updates = append(updates, q.Title.Value(title), q.TitleSource.Value(dbconversation.TitleUser))
+	}
+	if req.Pinned != nil {
+		updates = append(updates, q.Pinned.Value(*req.Pinned))
+	}
+	if req.Archived != nil {
+		updates = append(updates, q.Archived.Value(*req.Archived))
+	}
+	if req.Metadata != nil {
+		js, err := json.Marshal(req.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, q.Metadata.Value(string(js)))
+	}
+	if len(updates) > 0 {
+		_, err := q.WithContext(ctx).Where(q.ID.Eq(req.SessionId)).UpdateSimple(updates...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	session, err := q.WithContext(ctx).Where(q.ID.Eq(req.SessionId)).First()
+	if err != nil {
+		return nil, err
+	}
+	return toSessionData(session), nil
+}
+
+func toSessionData(v *model.Ai_session_logs) *sessionm.UserSessionListRespData {
+	res := &sessionm.UserSessionListRespData{
+		SessionId: v.ID,
+		Title:     v.Title,
+		PromptId:  v.PromptID,
+		Model:     v.Model,
+		Pinned:    v.Pinned,
+		Archived:  v.Archived,
+		Metadata:  map[string]any{},
+		CreatAt:   v.CreatedAt,
+		LastAt:    v.LastAt,
+	}
+	if v.Metadata != "" {
+		_ = json.Unmarshal([]byte(v.Metadata), &res.Metadata)
+	}
+	return res
 }
 
 func (s *Service) SessionChatLogList(ctx context.Context, req sessionm.SessionChatLogListReq) (*sessionm.SessionChatLogListResp, error) {
 	if req.SessionId == "" {
 		return nil, fmt.Errorf("sessionId is empty")
 	}
+	if err := s.CheckSession(req.SessionId, req.UserId); err != nil {
+		return nil, err
+	}
 	logs, _, err := s.dal.Db().Gdb().Urtyg_ai_agent().Ai_chat_logs().Gen().Find(&model.Ai_chat_logs_QueryReq{
 		Content:   nil,
 		CreatedAt: nil,
@@ -167,21 +276,27 @@ func (s *Service) SessionChatLogList(ctx context.Context, req sessionm.SessionCh
 		// 按 Order 升序
 		return logs[i].Order < logs[j].Order
 	})
+	chatLogs := make([]*model.Ai_chat_logs, 0, len(logs))
 	for _, v := range logs {
+		if v.Role == dbconversation.RoleMemo {
+			continue
+		}
 		msg := &schema.Message{}
 		err := json.Unmarshal([]byte(v.Content), msg)
 		if err != nil {
 			return nil, err
 		}
 		v.Content = msg.Content
+		chatLogs = append(chatLogs, v)
 	}
 	res := &sessionm.SessionChatLogListResp{
 		SessionId: req.SessionId,
-		ChatLogs:  logs,
+		ChatLogs:  chatLogs,
 	}
 	return res, nil
 }
 
+// DeleteSessions 删除会话,会话标记为已删除,不再出现在会话列表中,也不能继续对话;聊天记录保留
 func (s *Service) DeleteSessions(ctx context.Context, req sessionm.DeleteSessionsReq) (*sessionm.DeleteSessionsResp, error) {
 	if len(req.SessionIds) == 0 {
 		return nil, fmt.Errorf("sessionIds is empty")
@@ -190,35 +305,74 @@ func (s *Service) DeleteSessions(ctx context.Context, req sessionm.DeleteSession
 		if v == "" {
 			return nil, fmt.Errorf("sessionId is empty")
 		}
+		if err := s.CheckSession(v, req.UserId); err != nil {
+			return nil, err
+		}
 	}
-	result, err := s.dal.Db().Gdb().Urtyg_ai_agent().GenQ().Ai_chat_logs.
-		WithContext(context.Background()).
-		Where(s.dal.Db().Gdb().Urtyg_ai_agent().GenQ().Ai_chat_logs.SessionID.In(req.SessionIds...)).
-		Delete()
-
+	q := s.dal.Db().Gdb().Urtyg_ai_agent().GenQ().Ai_session_logs
+	_, err := q.WithContext(ctx).Where(q.ID.In(req.SessionIds...), q.UserID.Eq(req.UserId)).Delete()
 	if err != nil {
 		return nil, err
 	}
-	if result.Error != nil {
-		return nil, result.Error
-	}
 	return nil, nil
 }
 
 func (s *Service) DeleteChatLogs(ctx context.Context, req sessionm.DeleteChatLogsReq) (*sessionm.DeleteChatLogsResp, error) {
+	if req.UserId == "" {
+		return nil, fmt.Errorf("userId is empty")
+	}
+	// 只能删除登录用户自己的聊天记录,拥有session:admin权限时可删除其他用户的聊天记录
+	q := s.dal.Db().Gdb().Urtyg_ai_agent().GenQ().Ai_chat_logs
+	logs, err := q.WithContext(ctx).Where(q.ID.In(req.IDs...)).Find()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range logs {
+		if v.UserID == req.UserId {
+			continue
+		}
+		if err = authm.Require(ctx, authm.PermSessionAdmin); err != nil {
+			return nil, fmt.Errorf("%w: 聊天记录 %s", err, v.ID)
+		}
+	}
 	dels := make([]*model.Ai_chat_logs, 0)
 	for _, v := range req.IDs {
 		dels = append(dels, &model.Ai_chat_logs{
 			ID: v,
 		})
 	}
-	err := s.dal.Db().Gdb().Urtyg_ai_agent().Ai_chat_logs().Gen().Del(dels...)
+	err = s.dal.Db().Gdb().Urtyg_ai_agent().Ai_chat_logs().Gen().Del(dels...)
 	if err != nil {
 		return nil, err
 	}
 	return nil, nil
 }
 
+// CheckSession 校验会话由用户创建,会话Id为签名的令牌,记录了创建用户
+func (s *Service) CheckSession(sessionId, userId string) error {
+	if userId == "" {
+		return fmt.Errorf("userId is empty")
+	}
+	claims, err := s.uJwt.VerifySession(sessionId)
+	if err != nil {
+		return err
+	}
+	if claims.UserId != userId {
+		return fmt.Errorf("%w: 会话不属于当前用户", authm.ErrForbidden)
+	}
+	// 已删除的会话视为不存在
+	first, err := s.dal.Db().Gdb().Urtyg_ai_agent().Ai_session_logs().Gen().First(&model.Ai_session_logs{
+		ID: sessionId,
+	})
+	if err != nil {
+		return err
+	}
+	if first == nil {
+		return fmt.Errorf("会话不存在")
+	}
+	return nil
+}
+
 func (s *Service) CreatSession(ctx context.Context, req sessionm.CreatSessionReq) (*sessionm.CreatSessionResp, error) {
 	if req.UserId == "" {
 		return nil, fmt.Errorf("userId is empty")
@@ -231,11 +385,13 @@ func (s *Service) CreatSession(ctx context.Context, req sessionm.CreatSessionReq
 	res := &sessionm.CreatSessionResp{
 		SessionId: *session,
 	}
+	now := time.Now()
 	err = s.dal.Db().Gdb().Urtyg_ai_agent().Ai_session_logs().Gen().Save(&model.Ai_session_logs{
-		ID:       res.SessionId,
-		UserID:   req.UserId,
-		PromptID: string(iconsts.PromptID_IntelligentAssistant),
-		LastAt:   time.Now(),
+		ID:        res.SessionId,
+		UserID:    req.UserId,
+		PromptID:  string(iconsts.PromptID_IntelligentAssistant),
+		CreatedAt: &now,
+		LastAt:    now,
 	})
 	if err != nil {
 		return nil, err
@@ -301,11 +457,13 @@ func (s *Service) QuerySessionChatLogsByUser(ctx context.Context, req sessionm.Q
 			return nil, err
 		}
 		res.SessionId = *session
+		now := time.Now()
 		err = s.dal.Db().Gdb().Urtyg_ai_agent().Ai_session_logs().Gen().Save(&model.Ai_session_logs{
-			ID:       res.SessionId,
-			UserID:   req.UserId,
-			PromptID: first.ID,
-			LastAt:   time.Now(),
+			ID:        res.SessionId,
+			UserID:    req.UserId,
+			PromptID:  first.ID,
+			CreatedAt: &now,
+			LastAt:    now,
 		})
 		if err != nil {
 			return nil, err
@@ -332,8 +490,11 @@ func (s *Service) QuerySessionChatLogsByUser(ctx context.Context, req sessionm.Q
 		}
 		res.SessionId = lastSession.ID
 		res.PromptType = first.Type
-		if chatLogs != nil {
-			res.ChatLogs = chatLogs
+		for _, v := range chatLogs {
+			if v.Role == dbconversation.RoleMemo {
+				continue
+			}
+			res.ChatLogs = append(res.ChatLogs, v)
 		}
 		return res, nil
 	}
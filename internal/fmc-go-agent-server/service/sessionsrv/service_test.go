@@ -0,0 +1,24 @@
+package sessionsrv
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		keyword string
+		want    string
+	}{
+		{"年假", "年假"},
+		{"100%", "100!%"},
+		{"user_id", "user!_id"},
+		{"hi!", "hi!!"},
+		{`C:\dir`, `C:\dir`},
+		{"%_!", "!%!_!!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.keyword, func(t *testing.T) {
+			if got := escapeLike(tt.keyword); got != tt.want {
+				t.Errorf("escapeLike(%q) = %q, want %q", tt.keyword, got, tt.want)
+			}
+		})
+	}
+}
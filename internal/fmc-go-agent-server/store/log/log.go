@@ -14,6 +14,7 @@ var (
 	logMaps = make(map[string]*uzlog.UzLog)
 	sysLog  *uzlog.UzLog // 系统日志
 	msgLog  *uzlog.UzLog // 消息日志
+	audLog  *uzlog.UzLog // 审计日志
 	kitLog  kit_log.Logger
 	once    sync.Once
 )
@@ -37,6 +38,8 @@ func NewLog(config *uzlog.Option) {
 		sysLog = uzlog.NewUzLog(c)
 		c.Name = "msgLog"
 		msgLog = uzlog.NewUzLog(c)
+		c.Name = "auditLog"
+		audLog = uzlog.NewUzLog(c)
 	})
 	if sysLog == nil {
 		panic("sysLog is nil")
@@ -44,8 +47,12 @@ func NewLog(config *uzlog.Option) {
 	if msgLog == nil {
 		panic("msgLog is nil")
 	}
+	if audLog == nil {
+		panic("auditLog is nil")
+	}
 	logMaps[sysLog.Name()] = sysLog
 	logMaps[msgLog.Name()] = msgLog
+	logMaps[audLog.Name()] = audLog
 	return
 }
 
@@ -61,6 +68,10 @@ func Sync() error {
 	if err != nil {
 		return err
 	}
+	err = audLog.Sync()
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -84,6 +95,16 @@ func MsgLog() *zap.SugaredLogger {
 	return msgLog.Sugar()
 }
 
+// AuditLog 获取审计日志实例,记录拒绝访问等安全事件
+// 入参: 无
+// 返回: *zap.SugaredLogger 日志实例
+func AuditLog() *zap.SugaredLogger {
+	if audLog == nil {
+		NewLog(nil)
+	}
+	return audLog.Sugar()
+}
+
 func GetLogLevel(name string) (string, zapcore.Level, error) {
 	v, ok := logMaps[name]
 	if !ok {
@@ -0,0 +1,141 @@
+package uaiagent
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino-ext/components/document/loader/file"
+	"github.com/cloudwego/eino/schema"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaivectordb"
+)
+
+// extraKeyCitations 引用列表在消息Extra中的键
+const extraKeyCitations = "_uaiagent_citations"
+
+// Citation 回答引用的知识库文档,序号与提示词中的文档序号一致
+type Citation struct {
+	Index      int     `json:"index"`                // 引用序号,回答中以 [序号] 标注
+	ChunkID    string  `json:"chunkId"`              // 分片Id
+	FileID     string  `json:"fileId,omitempty"`     // 文件Id,可通过 /knowdb/files/download?id= 下载
+	FileName   string  `json:"fileName,omitempty"`   // 文件名称
+	Collection string  `json:"collection,omitempty"` // 文件所属集合
+	Title      string  `json:"title,omitempty"`      // 标题
+	Chapter    string  `json:"chapter,omitempty"`    // 章
+	Section    string  `json:"section,omitempty"`    // 节
+	Subsection string  `json:"subsection,omitempty"` // 小节
+	Row        int     `json:"row,omitempty"`        // CSV文件按行索引时的行号
+	Score      float64 `json:"score"`                // 重排序分数或向量相似度,只由全文检索命中时为0
+}
+
+// GetCitations 获取消息携带的引用列表
+func GetCitations(msg *schema.Message) ([]*Citation, bool) {
+	if msg == nil || msg.Extra == nil {
+		return nil, false
+	}
+	cs, ok := msg.Extra[extraKeyCitations].([]*Citation)
+	return cs, ok
+}
+
+// withCitations 返回携带引用列表的消息副本,不修改写入历史的消息
+func withCitations(msg *schema.Message, cs []*Citation) *schema.Message {
+	if len(cs) == 0 {
+		return msg
+	}
+	res := *msg
+	res.Extra = make(map[string]any, len(msg.Extra)+1)
+	for k, v := range msg.Extra {
+		res.Extra[k] = v
+	}
+	res.Extra[extraKeyCitations] = cs
+	return &res
+}
+
+// newCitationsMessage 将引用列表包装为消息,在流式输出的回答之后发送
+func newCitationsMessage(cs []*Citation) *schema.Message {
+	return &schema.Message{
+		Role:  schema.Assistant,
+		Extra: map[string]any{extraKeyCitations: cs},
+	}
+}
+
+// citationsKey 上下文中引用收集器的键
+type citationsKey struct{}
+
+// citations 收集本次请求提示词中使用的文档
+type citations struct {
+	mu   sync.Mutex
+	list []*Citation
+}
+
+func (c *citations) set(list []*Citation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.list = list
+}
+
+func (c *citations) get() []*Citation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.list
+}
+
+// newCitation 由检索到的分片生成引用,文件名称与标题取自分片元数据
+func newCitation(index int, doc *schema.Document) *Citation {
+	meta := doc.MetaData
+	c := &Citation{
+		Index:   index,
+		ChunkID: doc.ID,
+		Score:   doc.Score(),
+	}
+	c.FileID, _ = meta[uaivectordb.MetaKeyFileID].(string)
+	c.Collection, _ = meta[uaivectordb.CollectionField].(string)
+	c.FileName, _ = meta[file.MetaKeyFileName].(string)
+	if c.FileName == "" {
+		if src, ok := meta[file.MetaKeySource].(string); ok && src != "" {
+			c.FileName = filepath.Base(src)
+		}
+	}
+	c.Title, _ = meta["title"].(string)
+	c.Chapter, _ = meta["chapter"].(string)
+	c.Section, _ = meta["section"].(string)
+	c.Subsection, _ = meta["subsection"].(string)
+	if row, ok := meta[uaivectordb.MetaKeyRow].(float64); ok {
+		c.Row = int(row)
+	}
+	return c
+}
+
+// source 引用的来源描述:文件名称与标题路径
+func (c *Citation) source() string {
+	parts := make([]string, 0, 5)
+	for _, v := range []string{c.FileName, c.Title, c.Chapter, c.Section, c.Subsection} {
+		if v = strings.TrimSpace(v); v != "" {
+			parts = append(parts, v)
+		}
+	}
+	if c.Row > 0 {
+		parts = append(parts, fmt.Sprintf("第%d行", c.Row))
+	}
+	return strings.Join(parts, " > ")
+}
+
+// formatDocuments 将检索到的文档编号并注明来源,写入提示词;引用列表记录到上下文的收集器中
+func formatDocuments(ctx context.Context, docs []*schema.Document) string {
+	list := make([]*Citation, 0, len(docs))
+	var sb strings.Builder
+	for i, doc := range docs {
+		c := newCitation(i+1, doc)
+		list = append(list, c)
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(fmt.Sprintf("[%d] 来源: %s\n%s", c.Index, c.source(), doc.Content))
+	}
+	if cs, ok := ctx.Value(citationsKey{}).(*citations); ok {
+		cs.set(list)
+	}
+	return sb.String()
+}
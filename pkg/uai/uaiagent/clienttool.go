@@ -0,0 +1,225 @@
+package uaiagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+	ub "github.com/cloudwego/eino/utils/callbacks"
+)
+
+// FinishReasonToolCalls 模型决定调用客户端工具时的结束原因
+const FinishReasonToolCalls = "tool_calls"
+
+// clientToolCallsKey 上下文中客户端工具调用收集器的键
+type clientToolCallsKey struct{}
+
+// clientToolCalls 收集本次请求中模型发起的客户端工具调用,以及同一次运行中服务端工具的调用与结果
+type clientToolCalls struct {
+	mu      sync.Mutex
+	client  map[string]struct{} // 客户端声明的工具名称
+	calls   []schema.ToolCall
+	server  []schema.ToolCall // 服务端工具调用
+	results []*schema.Message // 服务端工具的执行结果,与server一一对应
+}
+
+// newClientToolCalls 创建收集器,tools为客户端声明的工具
+func newClientToolCalls(tools []*schema.ToolInfo) *clientToolCalls {
+	c := &clientToolCalls{client: make(map[string]struct{}, len(tools))}
+	for _, v := range tools {
+		c.client[v.Name] = struct{}{}
+	}
+	return c
+}
+
+func (c *clientToolCalls) add(call schema.ToolCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, call)
+}
+
+// addServer 记录一次服务端工具的调用与结果
+func (c *clientToolCalls) addServer(call schema.ToolCall, result string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.server = append(c.server, call)
+	c.results = append(c.results, schema.ToolMessage(result, call.ID, schema.WithToolName(call.Function.Name)))
+}
+
+// serverStep 服务端工具的调用消息及其结果,没有调用时返回nil
+// 模型在同一步中同时调用服务端与客户端工具时,服务端工具已执行,其结果需要保存到会话,客户端返回结果后一并交给模型
+func (c *clientToolCalls) serverStep() []*schema.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.server) == 0 {
+		return nil
+	}
+	calls := make([]schema.ToolCall, len(c.server))
+	copy(calls, c.server)
+	for i := range calls {
+		index := i
+		calls[i].Index = &index
+	}
+	res := make([]*schema.Message, 0, len(c.results)+1)
+	res = append(res, schema.AssistantMessage("", calls))
+	res = append(res, c.results...)
+	return res
+}
+
+// message 将收集到的工具调用组装为assistant消息,没有调用时返回nil
+func (c *clientToolCalls) message() *schema.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.calls) == 0 {
+		return nil
+	}
+	calls := make([]schema.ToolCall, len(c.calls))
+	copy(calls, c.calls)
+	for i := range calls {
+		index := i
+		calls[i].Index = &index
+	}
+	msg := schema.AssistantMessage("", calls)
+	msg.ResponseMeta = &schema.ResponseMeta{FinishReason: FinishReasonToolCalls}
+	return msg
+}
+
+// clientTool 客户端声明的函数工具,服务端不执行,只记录调用并让ReAct代理直接返回,由客户端执行后带着结果再次请求
+type clientTool struct {
+	info *schema.ToolInfo
+}
+
+func (t *clientTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return t.info, nil
+}
+
+func (t *clientTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	c, ok := ctx.Value(clientToolCallsKey{}).(*clientToolCalls)
+	if !ok {
+		return "", errors.New("client tool can not be called outside of a chat request")
+	}
+	c.add(schema.ToolCall{
+		ID:   compose.GetToolCallID(ctx),
+		Type: "function",
+		Function: schema.FunctionCall{
+			Name:      t.info.Name,
+			Arguments: argumentsInJSON,
+		},
+	})
+	// 停止ReAct循环,把工具调用交还给客户端
+	err := react.SetReturnDirectly(ctx)
+	if err != nil {
+		return "", err
+	}
+	return "", nil
+}
+
+var _ tool.InvokableTool = &clientTool{}
+
+// newServerToolRecorder 记录服务端工具的调用与结果,客户端声明的工具由clientTool记录
+func newServerToolRecorder() callbacks.Handler {
+	type started struct {
+		name string
+		args string
+	}
+	type startedKey struct{}
+	handler := &ub.ToolCallbackHandler{
+		OnStart: func(ctx context.Context, info *callbacks.RunInfo, input *tool.CallbackInput) context.Context {
+			if input == nil {
+				return ctx
+			}
+			return context.WithValue(ctx, startedKey{}, &started{name: info.Name, args: input.ArgumentsInJSON})
+		},
+		OnEnd: func(ctx context.Context, info *callbacks.RunInfo, output *tool.CallbackOutput) context.Context {
+			c, ok := ctx.Value(clientToolCallsKey{}).(*clientToolCalls)
+			if !ok || output == nil {
+				return ctx
+			}
+			if _, ok = c.client[info.Name]; ok {
+				return ctx
+			}
+			st, _ := ctx.Value(startedKey{}).(*started)
+			if st == nil {
+				return ctx
+			}
+			c.addServer(schema.ToolCall{
+				ID:   compose.GetToolCallID(ctx),
+				Type: "function",
+				Function: schema.FunctionCall{
+					Name:      st.name,
+					Arguments: st.args,
+				},
+			}, output.Response)
+			return ctx
+		},
+	}
+	return ub.NewHandlerHelper().Tool(handler).Handler()
+}
+
+// pendingToolStep 会话历史末尾未得到回答的服务端工具调用及其结果,即交还客户端执行工具前保存的服务端工具步骤
+func pendingToolStep(history []*schema.Message) []*schema.Message {
+	i := len(history) - 1
+	for i >= 0 && history[i].Role == schema.Tool {
+		i--
+	}
+	if i < 0 || i == len(history)-1 || history[i].Role != schema.Assistant || len(history[i].ToolCalls) == 0 {
+		return nil
+	}
+	return history[i:]
+}
+
+// toolOptions 构建本次请求的工具相关参数:当前的服务端工具与客户端工具一并交给模型,并处理tool_choice
+func (u *UAiAgent) toolOptions(in *ChatInput) (tools []tool.BaseTool, opts []model.Option, err error) {
+	ts := u.toolSet.Load()
+	tools = make([]tool.BaseTool, 0, len(ts.tools)+len(in.Tools))
+	tools = append(tools, ts.tools...)
+	infos := make([]*schema.ToolInfo, 0, len(ts.infos)+len(in.Tools))
+	infos = append(infos, ts.infos...)
+	clientInfos := make([]*schema.ToolInfo, 0, len(in.Tools))
+	for _, v := range in.Tools {
+		for _, s := range ts.infos {
+			if s.Name == v.Name {
+				return nil, nil, fmt.Errorf("tool %s conflicts with a server tool", v.Name)
+			}
+		}
+		tools = append(tools, &clientTool{info: v})
+		infos = append(infos, v)
+		clientInfos = append(clientInfos, v)
+	}
+
+	if in.ToolChoice == nil {
+		return tools, []model.Option{model.WithTools(infos)}, nil
+	}
+	switch in.ToolChoice.Choice {
+	case schema.ToolChoiceAllowed, schema.ToolChoiceForbidden:
+		return tools, []model.Option{model.WithTools(infos), model.WithToolChoice(in.ToolChoice.Choice)}, nil
+	case schema.ToolChoiceForced:
+		// 强制调用只作用于客户端工具,否则服务端工具执行后模型会被再次强制调用,循环到最大步数
+		if len(clientInfos) == 0 {
+			return nil, nil, errors.New("tool_choice requires tools")
+		}
+		forced := clientInfos
+		if in.ToolChoice.Name != "" {
+			forced = nil
+			for _, v := range clientInfos {
+				if v.Name == in.ToolChoice.Name {
+					forced = []*schema.ToolInfo{v}
+					break
+				}
+			}
+			if forced == nil {
+				return nil, nil, fmt.Errorf("tool_choice function %s is not declared in tools", in.ToolChoice.Name)
+			}
+		}
+		return tools, []model.Option{model.WithTools(forced), model.WithToolChoice(schema.ToolChoiceForced)}, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported tool choice: %s", in.ToolChoice.Choice)
+	}
+}
@@ -0,0 +1,54 @@
+package uaiagent
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestPendingToolStep(t *testing.T) {
+	call := schema.AssistantMessage("", []schema.ToolCall{{ID: "1", Function: schema.FunctionCall{Name: "search"}}})
+	result := schema.ToolMessage("ok", "1")
+	tests := []struct {
+		name    string
+		history []*schema.Message
+		want    int
+	}{
+		{"empty", nil, 0},
+		{"answered", []*schema.Message{schema.UserMessage("q"), call, result, schema.AssistantMessage("a", nil)}, 0},
+		{"call without result", []*schema.Message{schema.UserMessage("q"), call}, 0},
+		{"pending", []*schema.Message{schema.UserMessage("q"), call, result}, 2},
+		{"tool without call", []*schema.Message{schema.UserMessage("q"), result}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pendingToolStep(tt.history); len(got) != tt.want {
+				t.Fatalf("pendingToolStep() = %d messages, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestClientToolCallsServerStep(t *testing.T) {
+	c := newClientToolCalls([]*schema.ToolInfo{{Name: "client"}})
+	if c.serverStep() != nil {
+		t.Fatal("serverStep() without server calls should be nil")
+	}
+	c.addServer(schema.ToolCall{ID: "a", Function: schema.FunctionCall{Name: "search", Arguments: "{}"}}, "r1")
+	c.addServer(schema.ToolCall{ID: "b", Function: schema.FunctionCall{Name: "open", Arguments: "{}"}}, "r2")
+	step := c.serverStep()
+	if len(step) != 3 {
+		t.Fatalf("serverStep() = %d messages, want 3", len(step))
+	}
+	if step[0].Role != schema.Assistant || len(step[0].ToolCalls) != 2 {
+		t.Fatalf("first message should carry both tool calls, got %+v", step[0])
+	}
+	for i, id := range []string{"a", "b"} {
+		if step[i+1].Role != schema.Tool || step[i+1].ToolCallID != id {
+			t.Fatalf("result %d = %+v, want tool result for %s", i, step[i+1], id)
+		}
+	}
+	if len(pendingToolStep(append([]*schema.Message{schema.UserMessage("q")}, step...))) != 3 {
+		t.Fatal("saved server step should be detected as pending")
+	}
+}
@@ -0,0 +1,127 @@
+package uaiagent
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	ub "github.com/cloudwego/eino/utils/callbacks"
+)
+
+// EventType 流式输出中的事件类型
+type EventType string
+
+const (
+	EventToolCall   EventType = "tool_call"   // 模型决定调用工具
+	EventToolResult EventType = "tool_result" // 工具执行完成
+	EventAnswer     EventType = "answer"      // 最终回答的增量
+	EventCitations  EventType = "citations"   // 回答引用的知识库文档,在最终回答之后输出
+)
+
+// extraKeyEvent 事件在消息Extra中的键
+const extraKeyEvent = "_uaiagent_event"
+
+// Event ReAct代理执行过程中的事件
+type Event struct {
+	Type       EventType `json:"type"`
+	ToolCallID string    `json:"toolCallId,omitempty"` // 工具调用ID
+	ToolName   string    `json:"toolName,omitempty"`   // 工具名称
+	Arguments  string    `json:"arguments,omitempty"`  // 工具调用参数,JSON格式
+	Result     string    `json:"result,omitempty"`     // 工具执行结果
+	Error      string    `json:"error,omitempty"`      // 工具执行错误
+}
+
+// GetEvent 获取消息携带的工具事件,最终回答的增量不携带事件
+func GetEvent(msg *schema.Message) (*Event, bool) {
+	if msg == nil || msg.Extra == nil {
+		return nil, false
+	}
+	e, ok := msg.Extra[extraKeyEvent].(*Event)
+	return e, ok
+}
+
+// newEventMessage 将事件包装为消息,与最终回答共用一个输出流
+func newEventMessage(e *Event) *schema.Message {
+	return &schema.Message{
+		Role:  schema.Assistant,
+		Extra: map[string]any{extraKeyEvent: e},
+	}
+}
+
+// newEventCallback 创建工具事件回调,将工具调用与结果写入输出流,客户端声明的工具不输出事件
+func newEventCallback(w *schema.StreamWriter[*schema.Message], clientTools []*schema.ToolInfo) callbacks.Handler {
+	skip := make(map[string]struct{}, len(clientTools))
+	for _, v := range clientTools {
+		skip[v.Name] = struct{}{}
+	}
+	handler := &ub.ToolCallbackHandler{
+		OnStart: func(ctx context.Context, info *callbacks.RunInfo, input *tool.CallbackInput) context.Context {
+			if _, ok := skip[info.Name]; ok || input == nil {
+				return ctx
+			}
+			w.Send(newEventMessage(&Event{
+				Type:       EventToolCall,
+				ToolCallID: compose.GetToolCallID(ctx),
+				ToolName:   info.Name,
+				Arguments:  input.ArgumentsInJSON,
+			}), nil)
+			return ctx
+		},
+		OnEnd: func(ctx context.Context, info *callbacks.RunInfo, output *tool.CallbackOutput) context.Context {
+			if _, ok := skip[info.Name]; ok || output == nil {
+				return ctx
+			}
+			w.Send(newEventMessage(&Event{
+				Type:       EventToolResult,
+				ToolCallID: compose.GetToolCallID(ctx),
+				ToolName:   info.Name,
+				Result:     output.Response,
+			}), nil)
+			return ctx
+		},
+		OnError: func(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+			if _, ok := skip[info.Name]; ok {
+				return ctx
+			}
+			w.Send(newEventMessage(&Event{
+				Type:       EventToolResult,
+				ToolCallID: compose.GetToolCallID(ctx),
+				ToolName:   info.Name,
+				Error:      err.Error(),
+			}), nil)
+			return ctx
+		},
+	}
+	return ub.NewHandlerHelper().Tool(handler).Handler()
+}
+
+// streamWithEvents 在后台运行代理,工具事件与最终回答写入同一个输出流,使长时间的工具调用过程能实时推送给客户端
+func (u *UAiAgent) streamWithEvents(in *ChatInput, run func(opts ...compose.Option) (*schema.StreamReader[*schema.Message], error)) *schema.StreamReader[*schema.Message] {
+	out, w := schema.Pipe[*schema.Message](16)
+	go func() {
+		defer w.Close()
+		sr, err := run(compose.WithCallbacks(newEventCallback(w, in.Tools)))
+		if err != nil {
+			w.Send(nil, err)
+			return
+		}
+		defer sr.Close()
+		for {
+			chunk, err := sr.Recv()
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					w.Send(nil, err)
+				}
+				return
+			}
+			if closed := w.Send(chunk, nil); closed {
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,114 @@
+package uaiagent
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaiagent/mem"
+)
+
+// newUserMessage 根据客户端输入与服务端会话历史，构建运行图的输入
+func (u *UAiAgent) newUserMessage(conversation mem.ConversationIf, in *ChatInput) (*UserMessage, error) {
+	if in == nil || in.SessionId == "" {
+		return nil, errors.New("sessionId is required")
+	}
+	if len(in.Messages) == 0 {
+		return nil, errors.New("messages is empty")
+	}
+	if !in.HistoryMode.Valid() {
+		return nil, fmt.Errorf("invalid history mode: %s", in.HistoryMode)
+	}
+	for _, v := range in.Messages {
+		if v == nil {
+			return nil, errors.New("message can not be null")
+		}
+	}
+
+	// 拆分本轮输入:用户消息,或assistant工具调用消息及其后的客户端工具执行结果
+	msgs := in.Messages
+	last := msgs[len(msgs)-1]
+	var input, rest []*schema.Message
+	switch last.Role {
+	case schema.User:
+		input, rest = msgs[len(msgs)-1:], msgs[:len(msgs)-1]
+	case schema.Tool:
+		i := len(msgs) - 1
+		for i >= 0 && msgs[i].Role == schema.Tool {
+			i--
+		}
+		if i < 0 || msgs[i].Role != schema.Assistant || len(msgs[i].ToolCalls) == 0 {
+			return nil, errors.New("tool messages must follow an assistant message with tool_calls")
+		}
+		input, rest = msgs[i:], msgs[:i]
+	default:
+		return nil, errors.New("the last message must be a user or tool message")
+	}
+
+	// 拆分客户端系统提示词与历史对话
+	systems := make([]*schema.Message, 0)
+	clientHistory := make([]*schema.Message, 0)
+	for _, v := range rest {
+		if v.Role == schema.System {
+			systems = append(systems, v)
+			continue
+		}
+		clientHistory = append(clientHistory, v)
+	}
+
+	mode := in.HistoryMode
+	if mode == HistoryModeAuto {
+		// 客户端自己维护了多轮对话（如OpenAI SDK），则以客户端为准
+		if len(clientHistory) > 0 {
+			mode = HistoryModeReplace
+		} else {
+			mode = HistoryModeMerge
+		}
+	}
+
+	isToolResult := input[0].Role != schema.User
+	var serverHistory, pending []*schema.Message
+	if mode != HistoryModeReplace || isToolResult {
+		serverHistory = conversation.GetMessages()
+	}
+	if isToolResult {
+		// 交还客户端前已执行的服务端工具步骤,放在客户端工具结果之前
+		pending = pendingToolStep(serverHistory)
+		serverHistory = serverHistory[:len(serverHistory)-len(pending)]
+	}
+
+	// 客户端系统提示词追加在模板系统提示词之后
+	history := make([]*schema.Message, 0)
+	switch mode {
+	case HistoryModeServer:
+		history = append(history, serverHistory...)
+	case HistoryModeMerge:
+		history = append(history, systems...)
+		history = append(history, serverHistory...)
+		history = append(history, clientHistory...)
+	case HistoryModeReplace:
+		history = append(history, systems...)
+		history = append(history, clientHistory...)
+	}
+	history = append(history, pending...)
+
+	return &UserMessage{
+		SessionId: in.SessionId,
+		Prompt:    conversation.GetPrompt(),
+		Query:     lastUserQuery(input, history),
+		History:   history,
+		Input:     input,
+	}, nil
+}
+
+// lastUserQuery 取最近一条用户消息作为知识库检索的问题
+func lastUserQuery(groups ...[]*schema.Message) string {
+	for _, msgs := range groups {
+		for i := len(msgs) - 1; i >= 0; i-- {
+			if msgs[i].Role == schema.User {
+				return msgs[i].Content
+			}
+		}
+	}
+	return ""
+}
@@ -1,14 +1,19 @@
 package uaiagent
 
 import (
+	"context"
+
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/schema"
 )
 
 type If interface {
-	AppendTools(name string, tool []tool.BaseTool) error
-	Invoke(msg string) (*schema.Message, error)
-	Stream(msg string) (*schema.StreamReader[*schema.Message], error)
-	Collect(inMsg *schema.StreamReader[string]) (*schema.Message, error)
-	Transform(inMsg *schema.StreamReader[string]) (*schema.StreamReader[*schema.Message], error)
+	// SetTools 替换外部工具集,下一次调用生效
+	SetTools(tools []tool.BaseTool) error
+	// Invoke 运行代理,ctx取消时停止运行
+	Invoke(ctx context.Context, in *ChatInput) (*schema.Message, error)
+	// Stream 流式运行代理,ctx取消时停止输出并保存部分回答
+	Stream(ctx context.Context, in *ChatInput) (*schema.StreamReader[*schema.Message], error)
+	Collect(ctx context.Context, sessionId string, inMsg *schema.StreamReader[string]) (*schema.Message, error)
+	Transform(ctx context.Context, sessionId string, inMsg *schema.StreamReader[string]) (*schema.StreamReader[*schema.Message], error)
 }
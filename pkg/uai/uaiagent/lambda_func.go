@@ -14,8 +14,10 @@ func newLambda(ctx context.Context, input *UserMessage, opts ...any) (output str
 func (u *UAiAgent) newLambda2(ctx context.Context, input *UserMessage, opts ...any) (output map[string]any, err error) {
 	return map[string]any{
 		"sessionId": input.SessionId,
+		"prompt":    input.Prompt,
 		"content":   input.Query,
 		"history":   input.History,
+		"input":     input.Input,
 		"date":      time.Now().Format("2006-01-02 15:04:05"),
 	}, nil
 }
@@ -0,0 +1,176 @@
+package mem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// MemoryMode 对话记忆策略,由提示词模板指定
+type MemoryMode string
+
+const (
+	MemoryModeFull    MemoryMode = ""        // 加载全部历史
+	MemoryModeSummary MemoryMode = "summary" // 按token预算加载,超出时将较早的对话摘要为系统备忘
+)
+
+// BudgetOption 按token预算管理对话记忆的配置
+type BudgetOption struct {
+	ContextWindow int     `comment:"模型上下文窗口大小,单位token,为0时使用模型配置"`
+	HistoryRatio  float64 `comment:"历史消息可占用上下文窗口的比例,其余留给系统提示词、知识库检索内容与模型输出"`
+	SummaryRatio  float64 `comment:"摘要可占用历史预算的比例"`
+}
+
+func NewDefaultBudgetOption() *BudgetOption {
+	return &BudgetOption{
+		ContextWindow: 0,
+		HistoryRatio:  0.5,
+		SummaryRatio:  0.25,
+	}
+}
+
+// Budget 历史消息可用的token数
+func (o *BudgetOption) Budget() int {
+	return int(float64(o.ContextWindow) * o.HistoryRatio)
+}
+
+// CountTokens 估算消息的token数
+// 不依赖具体模型的分词器:中日韩字符按1个token计,其余字符按4个字符1个token计,每条消息另加4个token的格式开销
+func CountTokens(msgs ...*schema.Message) int {
+	total := 0
+	for _, m := range msgs {
+		if m == nil {
+			continue
+		}
+		total += 4 + countText(m.Content) + countText(m.ReasoningContent)
+		for _, tc := range m.ToolCalls {
+			total += countText(tc.Function.Name) + countText(tc.Function.Arguments)
+		}
+	}
+	return total
+}
+
+func countText(s string) int {
+	if s == "" {
+		return 0
+	}
+	wide, other := 0, 0
+	for _, r := range s {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			wide++
+			continue
+		}
+		other++
+	}
+	return wide + (other+3)/4
+}
+
+// Split 按token预算计算需要摘要的历史消息数量
+// memo为之前的摘要,msgs为摘要之后的消息;未超出预算、预算未配置(上下文窗口为0)或只有一轮对话时返回0,
+// 否则msgs[:n]需要与memo合并为新的摘要,msgs[n:]原样保留。切分点只落在用户消息上,保证工具调用与工具结果不被拆开
+func Split(opt *BudgetOption, memo string, msgs []*schema.Message) int {
+	if opt == nil {
+		return 0
+	}
+	budget := opt.Budget()
+	if budget <= 0 {
+		return 0
+	}
+	if CountTokens(NewMemo(memo))+CountTokens(msgs...) <= budget {
+		return 0
+	}
+
+	// 保留尽量多的最近对话,同时为摘要留出空间
+	keep := budget - int(float64(budget)*opt.SummaryRatio)
+	n := 0
+	for i := 1; i < len(msgs); i++ {
+		if msgs[i].Role != schema.User {
+			continue
+		}
+		n = i
+		if CountTokens(msgs[i:]...) <= keep {
+			break
+		}
+	}
+	return n
+}
+
+// Compact 按token预算压缩对话历史,未超出预算时n为0,否则将msgs[:n]与memo合并为新的摘要,见Split
+func Compact(ctx context.Context, cm model.BaseChatModel, opt *BudgetOption, memo string, msgs []*schema.Message) (summary string, n int, err error) {
+	if cm == nil {
+		return "", 0, errors.New("chat model is required")
+	}
+	n = Split(opt, memo, msgs)
+	if n == 0 {
+		return "", 0, nil
+	}
+	summary, err = Summarize(ctx, cm, memo, msgs[:n], int(float64(opt.Budget())*opt.SummaryRatio))
+	if err != nil {
+		return "", 0, err
+	}
+	return summary, n, nil
+}
+
+// summaryPrompt 摘要的系统提示词
+const summaryPrompt = `你是对话记忆整理助手。请将"已有摘要"与"新增对话"合并为一份新的摘要,供后续对话作为背景使用。
+要求:
+- 保留用户的目标、偏好、约束条件,以及已确认的事实、数据、结论和工具执行结果
+- 保留尚未完成的事项
+- 去掉寒暄与重复内容,不要编造
+- 使用第三人称的陈述句,直接输出摘要正文`
+
+// Summarize 使用模型将已有摘要与新增对话合并为新的摘要
+func Summarize(ctx context.Context, cm model.BaseChatModel, memo string, msgs []*schema.Message, maxTokens int) (string, error) {
+	var b strings.Builder
+	if memo != "" {
+		b.WriteString("# 已有摘要\n")
+		b.WriteString(memo)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("# 新增对话\n")
+	for _, m := range msgs {
+		switch {
+		case m.Role == schema.Tool:
+			b.WriteString(fmt.Sprintf("[tool %s]: %s\n", m.Name, m.Content))
+		case len(m.ToolCalls) > 0:
+			for _, tc := range m.ToolCalls {
+				b.WriteString(fmt.Sprintf("[%s 调用工具 %s]: %s\n", m.Role, tc.Function.Name, tc.Function.Arguments))
+			}
+			if m.Content != "" {
+				b.WriteString(fmt.Sprintf("[%s]: %s\n", m.Role, m.Content))
+			}
+		default:
+			b.WriteString(fmt.Sprintf("[%s]: %s\n", m.Role, m.Content))
+		}
+	}
+
+	opts := make([]model.Option, 0)
+	if maxTokens > 0 {
+		opts = append(opts, model.WithMaxTokens(maxTokens))
+	}
+	out, err := cm.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(summaryPrompt),
+		schema.UserMessage(b.String()),
+	}, opts...)
+	if err != nil {
+		return "", fmt.Errorf("summarize conversation failed: %w", err)
+	}
+	summary := strings.TrimSpace(out.Content)
+	if summary == "" {
+		return "", errors.New("summarize conversation failed: empty summary")
+	}
+	return summary, nil
+}
+
+// NewMemo 将摘要包装为系统消息,放在历史消息的最前面
+func NewMemo(summary string) *schema.Message {
+	if summary == "" {
+		return nil
+	}
+	return schema.SystemMessage("以下是本次会话较早内容的摘要:\n" + summary)
+}
@@ -0,0 +1,65 @@
+package mem
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestCountTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *schema.Message
+		want int
+	}{
+		{"empty", schema.UserMessage(""), 4},
+		{"ascii", schema.UserMessage("abcdefgh"), 4 + 2},
+		{"han", schema.UserMessage("你好世界"), 4 + 4},
+		{"mixed", schema.UserMessage("你好abcd"), 4 + 2 + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CountTokens(tt.msg); got != tt.want {
+				t.Fatalf("CountTokens() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	turn := func(q, a string) []*schema.Message {
+		return []*schema.Message{schema.UserMessage(q), schema.AssistantMessage(a, nil)}
+	}
+	long := strings.Repeat("字", 100)
+	var history []*schema.Message
+	for i := 0; i < 4; i++ {
+		history = append(history, turn(long, long)...)
+	}
+	tests := []struct {
+		name string
+		opt  *BudgetOption
+		msgs []*schema.Message
+		want int
+	}{
+		{"nil option", nil, history, 0},
+		{"context window not configured", &BudgetOption{HistoryRatio: 0.5, SummaryRatio: 0.25}, history, 0},
+		{"within budget", &BudgetOption{ContextWindow: 10000, HistoryRatio: 0.5, SummaryRatio: 0.25}, history, 0},
+		{"single turn", &BudgetOption{ContextWindow: 100, HistoryRatio: 0.5, SummaryRatio: 0.25}, turn(long, long), 0},
+		// 预算300,保留225:最近一轮约208个token
+		{"keep last turn", &BudgetOption{ContextWindow: 600, HistoryRatio: 0.5, SummaryRatio: 0.25}, history, 6},
+		// 四轮约832个token,预算800,保留600:最近两轮约416个token
+		{"keep two turns", &BudgetOption{ContextWindow: 1600, HistoryRatio: 0.5, SummaryRatio: 0.25}, history, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Split(tt.opt, "", tt.msgs)
+			if got != tt.want {
+				t.Fatalf("Split() = %d, want %d", got, tt.want)
+			}
+			if got > 0 && tt.msgs[got].Role != schema.User {
+				t.Fatalf("split point %d is not a user message", got)
+			}
+		})
+	}
+}
@@ -22,6 +22,21 @@ func (c *Conversation) GetPrompt() string {
 	panic("implement me")
 }
 
+// GetModel 文件会话不指定模型,使用默认模型
+func (c *Conversation) GetModel() string {
+	return ""
+}
+
+// GetRetrieve 文件会话使用系统配置的检索参数
+func (c *Conversation) GetRetrieve() (topK int, minScore float64) {
+	return 0, 0
+}
+
+// GetKnowledge 文件会话只检索默认集合
+func (c *Conversation) GetKnowledge() (collections []string, userId string) {
+	return nil, ""
+}
+
 func (c *Conversation) GetSessionId() string {
 	//TODO implement me
 	panic("implement me")
@@ -10,4 +10,10 @@ type ConversationIf interface {
 	Append(msg *schema.Message)
 	GetMessages() []*schema.Message
 	GetPrompt() string
+	// GetModel 会话使用的模型名称,为空时使用默认模型
+	GetModel() string
+	// GetRetrieve 会话的检索参数:返回的文档数量与最低分数,为0时使用系统配置
+	GetRetrieve() (topK int, minScore float64)
+	// GetKnowledge 会话可检索的知识库集合与会话用户,集合为空时检索默认集合
+	GetKnowledge() (collections []string, userId string)
 }
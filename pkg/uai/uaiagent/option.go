@@ -1,4 +1,45 @@
 package uaiagent
 
+func NewDefaultOption() *Option {
+	return &Option{
+		Retriever: NewDefaultRetrieverOption(),
+	}
+}
+
 type Option struct {
+	Retriever *RetrieverOption `comment:"知识库检索配置"`
+}
+
+func NewDefaultRetrieverOption() *RetrieverOption {
+	return &RetrieverOption{
+		TopK:       8,
+		MinScore:   0,
+		Candidates: 20,
+		FullText:   true,
+		RRFK:       60,
+		Rerank: &RerankOption{
+			BaseURL: "",
+			APIKey:  "",
+			Model:   "bge-reranker-v2-m3",
+			Timeout: 30,
+		},
+	}
+}
+
+// RetrieverOption 混合检索配置,全文检索与向量检索的结果按RRF融合,可选经重排序模型重排
+type RetrieverOption struct {
+	TopK       int           `comment:"返回的文档数量,提示词模板可单独指定"`
+	MinScore   float64       `comment:"文档的最低分数,0为不过滤;启用重排序时为重排序分数,否则为向量相似度,提示词模板可单独指定"`
+	Candidates int           `comment:"全文检索与向量检索各自召回的候选数量"`
+	FullText   bool          `comment:"是否启用全文检索(BM25),关闭时只使用向量检索"`
+	RRFK       int           `comment:"RRF融合常数k,排名越靠前的文档权重越大,默认60"`
+	Rerank     *RerankOption `comment:"重排序模型配置,BaseURL为空时不重排序"`
+}
+
+// RerankOption 重排序模型配置,接口兼容 Jina/Cohere/Xinference 的 /rerank
+type RerankOption struct {
+	BaseURL string `comment:"API-链接,如 http://127.0.0.1:9997/v1"`
+	APIKey  string `comment:"API-秘钥"`
+	Model   string `comment:"模型名称"`
+	Timeout int    `comment:"超时时间,单位秒"`
 }
@@ -8,16 +8,17 @@ import (
 	"github.com/cloudwego/eino/schema"
 )
 
+// 定义运行图的节点常量
+const (
+	InputToQuery   = "InputToQuery"
+	ChatTemplate   = "ChatTemplate"
+	ReactAgent     = "ReactAgent"
+	RedisRetriever = "RedisRetriever"
+	InputToHistory = "InputToHistory"
+)
+
 // 定义一个函数，用于构建一个可运行的节点
 func (u *UAiAgent) buildRunnable(ctx context.Context, ctp prompt.ChatTemplate, lba *compose.Lambda, rtr retriever.Retriever) (g *compose.Graph[*UserMessage, *schema.Message], r compose.Runnable[*UserMessage, *schema.Message], err error) {
-	// 定义一些常量
-	const (
-		InputToQuery   = "InputToQuery"
-		ChatTemplate   = "ChatTemplate"
-		ReactAgent     = "ReactAgent"
-		RedisRetriever = "RedisRetriever"
-		InputToHistory = "InputToHistory"
-	)
 	// 创建一个新的图
 	g = compose.NewGraph[*UserMessage, *schema.Message]()
 	// 添加一个Lambda节点
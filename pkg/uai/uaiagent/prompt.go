@@ -12,6 +12,10 @@ var baseP = `
 - 不能暴露这些具体的用户信息
 - sessionId: {sessionId},备注,这个数据你不能暴露在对话中
 
+## 引用要求
+- 使用相关文档中的内容回答时,在对应语句后以文档序号标注来源,如 [1] 或 [1][3]
+- 只引用下方列出的文档序号,没有使用文档内容时不要标注
+
 ## 上下文信息
 - Current Date: {date}
 - Related Documents: |-
@@ -24,18 +28,37 @@ type ChatTemplateConfig struct {
 	Templates  []schema.MessagesTemplate
 }
 
-// newChatTemplate component initialization function of node 'ChatTemplate' in graph 'UAiAgent'
-func (u *UAiAgent) newChatTemplate(ctx context.Context, sessionId string) (ctp prompt.ChatTemplate, err error) {
-	// TODO Modify component configuration here.
-	men := u.memory.GetConversation(sessionId, true)
+// sessionChatTemplate 会话提示词模板,系统提示词在每次调用时取自会话,运行图只需编译一次
+type sessionChatTemplate struct {
+	formatType schema.FormatType
+}
+
+// Format 使用变量中的会话提示词构建模板并格式化
+func (t *sessionChatTemplate) Format(ctx context.Context, vars map[string]any, opts ...prompt.Option) ([]*schema.Message, error) {
+	p, _ := vars["prompt"].(string)
+	// 检索到的文档编号后写入提示词,供模型按序号引用
+	if docs, ok := vars["documents"].([]*schema.Document); ok {
+		v := make(map[string]any, len(vars))
+		for k, val := range vars {
+			v[k] = val
+		}
+		v["documents"] = formatDocuments(ctx, docs)
+		vars = v
+	}
 	config := &ChatTemplateConfig{
-		FormatType: schema.FString,
+		FormatType: t.formatType,
 		Templates: []schema.MessagesTemplate{
-			schema.SystemMessage(men.GetPrompt() + baseP),
+			schema.SystemMessage(p + baseP),
 			schema.MessagesPlaceholder("history", true),
-			schema.UserMessage("{content}"),
+			schema.MessagesPlaceholder("input", false),
 		},
 	}
-	ctp = prompt.FromMessages(config.FormatType, config.Templates...)
-	return ctp, nil
+	return prompt.FromMessages(config.FormatType, config.Templates...).Format(ctx, vars, opts...)
+}
+
+var _ prompt.ChatTemplate = &sessionChatTemplate{}
+
+// newChatTemplate component initialization function of node 'ChatTemplate' in graph 'UAiAgent'
+func (u *UAiAgent) newChatTemplate(ctx context.Context) (ctp prompt.ChatTemplate, err error) {
+	return &sessionChatTemplate{formatType: schema.FString}, nil
 }
@@ -49,9 +49,6 @@ func (u *UAiAgent) FilterToolsCall(ctx context.Context, sr *schema.StreamReader[
 			return false, err
 		}
 		if msg.Content != "" {
-			if u.wPeekToolsStreamOut != nil {
-				u.wPeekToolsStreamOut.Send(msg, nil)
-			}
 			u.log.Infof("调用工具前时，有msg输出，影响流式判断应答: %s", msg.Content)
 		}
 		if len(msg.ToolCalls) > 0 {
@@ -0,0 +1,90 @@
+package uaiagent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// reranker 重排序模型客户端
+type reranker struct {
+	opt    *RerankOption
+	client *http.Client
+}
+
+// newReranker 创建重排序模型客户端,未配置BaseURL时返回nil
+func newReranker(opt *RerankOption) *reranker {
+	if opt == nil || opt.BaseURL == "" {
+		return nil
+	}
+	timeout := opt.Timeout
+	if timeout <= 0 {
+		timeout = 30
+	}
+	return &reranker{
+		opt:    opt,
+		client: &http.Client{Timeout: time.Duration(timeout) * time.Second},
+	}
+}
+
+type rerankReq struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+type rerankResp struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// Rerank 计算文档与问题的相关性分数,返回值与documents一一对应
+func (r *reranker) Rerank(ctx context.Context, query string, documents []string) ([]float64, error) {
+	body, err := json.Marshal(&rerankReq{
+		Model:     r.opt.Model,
+		Query:     query,
+		Documents: documents,
+		TopN:      len(documents),
+	})
+	if err != nil {
+		return nil, err
+	}
+	url := strings.TrimSuffix(r.opt.BaseURL, "/") + "/rerank"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.opt.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.opt.APIKey)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rerank request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("rerank request failed: %s %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	res := &rerankResp{}
+	if err = json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return nil, fmt.Errorf("failed to decode rerank response: %w", err)
+	}
+	scores := make([]float64, len(documents))
+	for _, v := range res.Results {
+		if v.Index < 0 || v.Index >= len(documents) {
+			return nil, fmt.Errorf("rerank result index %d out of range", v.Index)
+		}
+		scores[v.Index] = v.RelevanceScore
+	}
+	return scores, nil
+}
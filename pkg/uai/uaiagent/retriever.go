@@ -2,55 +2,280 @@ package uaiagent
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/cloudwego/eino/components/embedding"
-	"strconv"
+	"sort"
 
-	redispkg "github.com/cloudwego/eino-examples/quickstart/eino_assistant/pkg/redis"
-	"github.com/cloudwego/eino/schema"
-	redisCli "github.com/redis/go-redis/v9"
-
-	"github.com/cloudwego/eino-ext/components/retriever/redis"
+	"github.com/cloudwego/eino-ext/components/document/loader/file"
 	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaivectordb"
+	"go.uber.org/zap"
 )
 
+// maxQueryTerms 全文检索使用的最大关键词数量
+const maxQueryTerms = 32
+
 // newRetriever component initialization function of node 'RedisRetriever' in graph 'UAiAgent'
-func (u *UAiAgent) newRetriever(ctx context.Context, redisClient *redisCli.Client, eb embedding.Embedder) (rtr retriever.Retriever, err error) {
-	// TODO Modify component configuration here.
-	config := &redis.RetrieverConfig{
-		Client:       redisClient,
-		Index:        fmt.Sprintf("%s%s", redispkg.RedisPrefix, redispkg.IndexName),
-		Dialect:      2,
-		ReturnFields: []string{redispkg.ContentField, redispkg.MetadataField, redispkg.DistanceField},
-		TopK:         8,
-		VectorField:  redispkg.VectorField,
-		DocumentConverter: func(ctx context.Context, doc redisCli.Document) (*schema.Document, error) {
-			resp := &schema.Document{
-				ID:       doc.ID,
-				Content:  "",
-				MetaData: map[string]any{},
+func (u *UAiAgent) newRetriever(ctx context.Context, vdb uaivectordb.If, opt *RetrieverOption) (rtr retriever.Retriever, err error) {
+	if vdb == nil || vdb.Store() == nil {
+		return nil, errors.New("vector store not provided")
+	}
+	if vdb.Embedder() == nil {
+		return nil, errors.New("embedding not provided")
+	}
+	if opt == nil {
+		opt = NewDefaultRetrieverOption()
+	}
+	return &hybridRetriever{
+		vdb: vdb,
+		opt: opt,
+		rr:  newReranker(opt.Rerank),
+		log: u.log,
+	}, nil
+}
+
+// hybridRetriever 混合检索器,向量检索(KNN)与全文检索(BM25)的结果按RRF融合,可选经重排序模型重排后按最低分数过滤
+// 检索由向量数据库配置的向量存储执行;调用时可通过 retriever.WithTopK 与 retriever.WithScoreThreshold 覆盖返回数量与最低分数
+type hybridRetriever struct {
+	vdb uaivectordb.If
+	opt *RetrieverOption
+	rr  *reranker // 为nil时不重排序
+	log *zap.SugaredLogger
+}
+
+// scopeOption 检索范围
+type scopeOption struct {
+	collections []string // 可检索的知识库集合,为空时为默认集合
+	userId      string   // 会话用户,可检索公开集合与该用户的私有集合
+}
+
+// WithScope 限定检索的知识库集合与用户,未指定时只检索默认集合中的公开分片
+func WithScope(collections []string, userId string) retriever.Option {
+	return retriever.WrapImplSpecificOptFn(func(o *scopeOption) {
+		o.collections = collections
+		o.userId = userId
+	})
+}
+
+// filter 检索范围的TAG过滤条件
+func (o *scopeOption) filter() uaivectordb.Filter {
+	collections := o.collections
+	if len(collections) == 0 {
+		collections = []string{uaivectordb.DefaultCollection}
+	}
+	owners := []string{uaivectordb.PublicOwner}
+	if o.userId != "" {
+		owners = append(owners, o.userId)
+	}
+	return uaivectordb.Filter{
+		uaivectordb.CollectionField: collections,
+		uaivectordb.OwnerField:      owners,
+	}
+}
+
+// hit 融合后的检索结果
+type hit struct {
+	doc    *schema.Document
+	rrf    float64 // RRF融合分数
+	score  float64 // 向量相似度或重排序分数
+	scored bool    // 只由全文检索命中且未重排序时没有分数,不参与最低分数过滤
+}
+
+func (h *hybridRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	topK, minScore := h.opt.TopK, h.opt.MinScore
+	co := retriever.GetCommonOptions(&retriever.Options{TopK: &topK, ScoreThreshold: &minScore}, opts...)
+	if co.TopK != nil {
+		topK = *co.TopK
+	}
+	if co.ScoreThreshold != nil {
+		minScore = *co.ScoreThreshold
+	}
+	if topK <= 0 {
+		topK = NewDefaultRetrieverOption().TopK
+	}
+	candidates := h.opt.Candidates
+	if candidates < topK {
+		candidates = topK
+	}
+	filter := retriever.GetImplSpecificOptions(&scopeOption{}, opts...).filter()
+
+	vdocs, err := h.knn(ctx, query, filter, candidates)
+	if err != nil {
+		return nil, err
+	}
+	var tdocs []*schema.Document
+	if h.opt.FullText {
+		tdocs, err = h.fullText(ctx, query, filter, candidates)
+		if err != nil {
+			return nil, err
+		}
+	}
+	hits := h.fuse(vdocs, tdocs)
+
+	if h.rr != nil && len(hits) > 0 {
+		contents := make([]string, 0, len(hits))
+		for _, v := range hits {
+			contents = append(contents, v.doc.Content)
+		}
+		scores, err := h.rr.Rerank(ctx, query, contents)
+		if err != nil {
+			// 重排序失败时按RRF顺序返回
+			h.log.Warnf("重排序失败,按融合顺序返回: %s", err.Error())
+		} else {
+			for i := range hits {
+				hits[i].score = scores[i]
+				hits[i].scored = true
 			}
-			for field, val := range doc.Fields {
-				if field == redispkg.ContentField {
-					resp.Content = val
-				} else if field == redispkg.MetadataField {
-					resp.MetaData[field] = val
-				} else if field == redispkg.DistanceField {
-					distance, err := strconv.ParseFloat(val, 64)
-					if err != nil {
-						continue
-					}
-					resp.WithScore(1 - distance)
-				}
+			sort.SliceStable(hits, func(i, j int) bool {
+				return hits[i].score > hits[j].score
+			})
+		}
+	}
+
+	docs := make([]*schema.Document, 0, topK)
+	for _, v := range hits {
+		if len(docs) >= topK {
+			break
+		}
+		if !v.scored {
+			docs = append(docs, v.doc)
+			continue
+		}
+		if minScore > 0 && v.score < minScore {
+			continue
+		}
+		docs = append(docs, v.doc.WithScore(v.score))
+	}
+	h.fileIDs(ctx, docs)
+	return docs, nil
+}
+
+// fileIDs 为文档补充来源文件Id,用于引用知识库文件;Id记录在分片元数据中,旧分片按来源文件查找文件清单
+func (h *hybridRetriever) fileIDs(ctx context.Context, docs []*schema.Document) {
+	bySource := make(map[string]string)
+	for _, doc := range docs {
+		if id, ok := doc.MetaData[uaivectordb.MetaKeyFileID].(string); ok && id != "" {
+			continue
+		}
+		src, _ := doc.MetaData[file.MetaKeySource].(string)
+		if src == "" {
+			continue
+		}
+		id, ok := bySource[src]
+		if !ok {
+			if m, err := h.vdb.ManifestOf(ctx, src); err == nil && m != nil {
+				id = m.MD5
 			}
+			bySource[src] = id
+		}
+		if id != "" {
+			doc.MetaData[uaivectordb.MetaKeyFileID] = id
+		}
+	}
+}
 
-			return resp, nil
-		},
+// knn 向量检索,在过滤范围内按余弦距离返回最相近的k个分片,分数为 1-距离
+func (h *hybridRetriever) knn(ctx context.Context, query string, filter uaivectordb.Filter, k int) ([]*schema.Document, error) {
+	vectors, err := h.vdb.Embedder().EmbedStrings(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("invalid return length of vector, got=%d, expected=1", len(vectors))
 	}
-	config.Embedding = eb
-	rtr, err = redis.NewRetriever(ctx, config)
+	hits, err := h.vdb.Store().KNN(ctx, vectors[0], filter, k)
 	if err != nil {
 		return nil, err
 	}
-	return rtr, nil
+	docs := make([]*schema.Document, 0, len(hits))
+	for _, v := range hits {
+		docs = append(docs, toDocument(v).WithScore(v.Score))
+	}
+	return docs, nil
+}
+
+// fullText 全文检索,在过滤范围内的分片内容中按BM25匹配问题中的任一关键词,返回得分最高的k个分片
+func (h *hybridRetriever) fullText(ctx context.Context, query string, filter uaivectordb.Filter, k int) ([]*schema.Document, error) {
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	hits, err := h.vdb.Store().FullText(ctx, terms, filter, k)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]*schema.Document, 0, len(hits))
+	for _, v := range hits {
+		docs = append(docs, toDocument(v))
+	}
+	return docs, nil
+}
+
+// fuse 按RRF融合向量检索与全文检索的结果,分数为各路结果中 1/(k+排名) 之和
+func (h *hybridRetriever) fuse(lists ...[]*schema.Document) []hit {
+	k := float64(h.opt.RRFK)
+	if k <= 0 {
+		k = float64(NewDefaultRetrieverOption().RRFK)
+	}
+	hits := make([]hit, 0)
+	pos := make(map[string]int)
+	for li, list := range lists {
+		for rank, doc := range list {
+			i, ok := pos[doc.ID]
+			if !ok {
+				i = len(hits)
+				pos[doc.ID] = i
+				hits = append(hits, hit{doc: doc})
+			}
+			hits[i].rrf += 1 / (k + float64(rank+1))
+			// 向量检索的结果带有相似度
+			if li == 0 {
+				hits[i].score = doc.Score()
+				hits[i].scored = true
+			}
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].rrf > hits[j].rrf
+	})
+	return hits
+}
+
+func (h *hybridRetriever) GetType() string {
+	return "HybridRetriever"
+}
+
+// toDocument 将检索结果转换为文档,元数据为分片的元数据与所属集合
+func toDocument(h *uaivectordb.Hit) *schema.Document {
+	meta := make(map[string]any, len(h.Chunk.MetaData)+1)
+	for k, v := range h.Chunk.MetaData {
+		meta[k] = v
+	}
+	if c, ok := h.Chunk.Tags[uaivectordb.CollectionField]; ok {
+		meta[uaivectordb.CollectionField] = c
+	}
+	return &schema.Document{
+		ID:       h.Chunk.ID,
+		Content:  h.Chunk.Content,
+		MetaData: meta,
+	}
+}
+
+// queryTerms 从问题中提取关键词,与分片内容使用相同的分词(连续的汉字按相邻两字切分),去重后最多取 maxQueryTerms 个
+func queryTerms(query string) []string {
+	words := uaivectordb.Tokenize(query)
+	seen := make(map[string]struct{}, len(words))
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		if _, ok := seen[w]; ok {
+			continue
+		}
+		seen[w] = struct{}{}
+		terms = append(terms, w)
+		if len(terms) >= maxQueryTerms {
+			break
+		}
+	}
+	return terms
 }
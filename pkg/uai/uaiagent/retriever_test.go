@@ -0,0 +1,39 @@
+package uaiagent
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestQueryTerms(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"empty", "", []string{}},
+		{"english", "How to configure Redis?", []string{"how", "to", "configure", "redis"}},
+		{"duplicate", "redis Redis REDIS", []string{"redis"}},
+		{"chinese bigrams", "向量数据库", []string{"向量", "量数", "数据", "据库"}},
+		{"chinese punctuation", "如何配置?向量", []string{"如何", "何配", "配置", "向量"}},
+		{"single han", "库", []string{"库"}},
+		{"mixed", "配置redis集群", []string{"配置", "redis", "集群"}},
+		{"numbers", "bge-m3 4096维", []string{"bge", "m3", "4096", "维"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryTerms(tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("queryTerms(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+	var b strings.Builder
+	for i := 0; i < maxQueryTerms*2; i++ {
+		fmt.Fprintf(&b, "w%d ", i)
+	}
+	if got := queryTerms(b.String()); len(got) != maxQueryTerms {
+		t.Errorf("len(queryTerms) = %d, want %d", len(got), maxQueryTerms)
+	}
+}
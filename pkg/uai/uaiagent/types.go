@@ -1,10 +1,61 @@
 package uaiagent
 
-import "github.com/cloudwego/eino/schema"
+import (
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
 
 type UserMessage struct {
 	SessionId string            `json:"sessionId"`
 	PromptId  string            `json:"promptId"`
+	Prompt    string            `json:"prompt"` // 会话提示词
 	Query     string            `json:"query"`
 	History   []*schema.Message `json:"history"`
+	Input     []*schema.Message `json:"input"` // 本轮输入:用户消息,或assistant工具调用消息及其客户端工具执行结果
+}
+
+// isToolResult 本轮输入是否为客户端工具的执行结果
+func (m *UserMessage) isToolResult() bool {
+	return len(m.Input) > 0 && m.Input[0].Role != schema.User
+}
+
+// HistoryMode 客户端历史消息与服务端会话历史的组合方式
+type HistoryMode string
+
+const (
+	// HistoryModeAuto 自动：客户端携带了多轮对话时以客户端为准，否则使用服务端会话历史
+	HistoryModeAuto HistoryMode = ""
+	// HistoryModeServer 仅使用服务端会话历史，客户端只取最后一条消息（兼容旧行为）
+	HistoryModeServer HistoryMode = "server"
+	// HistoryModeMerge 服务端会话历史在前，客户端历史消息在后，合并使用
+	HistoryModeMerge HistoryMode = "merge"
+	// HistoryModeReplace 仅使用客户端历史消息，忽略服务端会话历史
+	HistoryModeReplace HistoryMode = "replace"
+)
+
+// Valid 判断历史模式是否合法
+func (m HistoryMode) Valid() bool {
+	switch m {
+	case HistoryModeAuto, HistoryModeServer, HistoryModeMerge, HistoryModeReplace:
+		return true
+	default:
+		return false
+	}
+}
+
+// ChatInput 代理一次对话的输入
+type ChatInput struct {
+	SessionId   string             `json:"sessionId"`   // 会话ID
+	Messages    []*schema.Message  `json:"messages"`    // 客户端提交的完整消息列表，最后一条为本轮用户输入
+	HistoryMode HistoryMode        `json:"historyMode"` // 历史消息组合方式
+	Options     []model.Option     `json:"-"`           // 本次请求的模型参数,如temperature、top_p、max_tokens等
+	Tools       []*schema.ToolInfo `json:"tools"`       // 客户端声明的函数工具,由客户端执行
+	ToolChoice  *ToolChoice        `json:"toolChoice"`  // 工具选择策略,为空时由模型自行决定
+	Events      bool               `json:"events"`      // 流式输出时是否输出工具调用事件,见GetEvent
+}
+
+// ToolChoice 工具选择策略
+type ToolChoice struct {
+	Choice schema.ToolChoice `json:"choice"` // forbidden:不调用工具;allowed:模型自行决定;forced:必须调用客户端工具
+	Name   string            `json:"name"`   // 强制调用的客户端工具名称,为空时可调用任一客户端工具
 }
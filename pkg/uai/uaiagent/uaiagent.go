@@ -5,27 +5,28 @@ import (
 	"errors"
 	"fmt"
 	"github.com/cloudwego/eino/callbacks"
-	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/components/retriever"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent"
+	"github.com/cloudwego/eino/flow/agent/react"
 	"github.com/cloudwego/eino/schema"
 	"github.com/freedqo/fmc-go-agent/pkg/uai/uaiagent/mem"
-	"github.com/redis/go-redis/v9"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaicharmodel"
+	"github.com/freedqo/fmc-go-agent/pkg/uai/uaivectordb"
 	"go.uber.org/zap"
 	"io"
-	"sync"
+	"sync/atomic"
 )
 
 // New 函数用于创建一个新的UAiAgent实例
-func New(ctx context.Context, log *zap.SugaredLogger, cm model.ToolCallingChatModel, eb embedding.Embedder, redis *redis.Client, memdb mem.MemoryIf, sessionId string, tools []tool.BaseTool) If {
+// 运行图只编译一次,会话ID、提示词与历史消息在每次调用时注入,实例可被所有会话复用
+func New(ctx context.Context, opt *Option, log *zap.SugaredLogger, cm model.ToolCallingChatModel, vdb uaivectordb.If, memdb mem.MemoryIf, tools []tool.BaseTool) If {
 	// 创建一个新的UAiAgent实例
 	uag := &UAiAgent{
-		ctx:       ctx,
-		sessionId: sessionId,
-		mu:        sync.RWMutex{},
+		ctx: ctx,
 		// 初始化cbLog
 		cbLog: newCbLog(log),
 		// 初始化memory
@@ -35,10 +36,13 @@ func New(ctx context.Context, log *zap.SugaredLogger, cm model.ToolCallingChatMo
 	if memdb != nil {
 		uag.memory = memdb
 	}
+	if opt == nil {
+		opt = NewDefaultOption()
+	}
 	var err error
 
 	// 创建一个新的ChatTemplate实例
-	uag.ctp, err = uag.newChatTemplate(ctx, sessionId)
+	uag.ctp, err = uag.newChatTemplate(ctx)
 	if err != nil {
 		// 如果创建ChatTemplate实例失败，则抛出异常
 		panic(err)
@@ -49,13 +53,15 @@ func New(ctx context.Context, log *zap.SugaredLogger, cm model.ToolCallingChatMo
 		panic(err)
 	}
 
-	tols := make([]tool.BaseTool, 0)
-	tols = append(tols, einoLocalTools...)
+	uag.localTools = einoLocalTools
 	// 提供一个外部的工具集
-	tols = append(tols, tools...)
+	err = uag.setTools(tools)
+	if err != nil {
+		panic(err)
+	}
 
 	// 创建一个agent
-	agent, err := uag.newReactAgent(ctx, cm, tols)
+	agent, err := uag.newReactAgent(ctx, cm, uag.toolSet.Load().tools)
 	if err != nil {
 		return nil
 	}
@@ -68,7 +74,7 @@ func New(ctx context.Context, log *zap.SugaredLogger, cm model.ToolCallingChatMo
 	}
 
 	// 创建一个新的Retriever实例
-	uag.rtr, err = uag.newRetriever(ctx, redis, eb)
+	uag.rtr, err = uag.newRetriever(ctx, vdb, opt.Retriever)
 	if err != nil {
 		// 如果创建Retriever实例失败，则抛出异常
 		panic(err)
@@ -86,123 +92,292 @@ func New(ctx context.Context, log *zap.SugaredLogger, cm model.ToolCallingChatMo
 }
 
 type UAiAgent struct {
-	ctx                 context.Context                                 // 上下文
-	sessionId           string                                          // 会话ID
-	mu                  sync.RWMutex                                    // 互斥锁
-	cbLog               callbacks.Handler                               // 日志回调
-	g                   *compose.Graph[*UserMessage, *schema.Message]   // 代理运行图
-	r                   compose.Runnable[*UserMessage, *schema.Message] // 代理运行图
-	memory              mem.MemoryIf                                    // 对话缓存
-	ctp                 prompt.ChatTemplate                             // 系统提示词模板
-	lba                 *compose.Lambda                                 // Lambda代理
-	rtr                 retriever.Retriever                             // 检索器
-	log                 *zap.SugaredLogger                              // 日志
-	wPeekToolsStreamOut *schema.StreamWriter[*schema.Message]           // 用来接收peek的流输出
+	ctx        context.Context                                 // 上下文
+	cbLog      callbacks.Handler                               // 日志回调
+	g          *compose.Graph[*UserMessage, *schema.Message]   // 代理运行图
+	r          compose.Runnable[*UserMessage, *schema.Message] // 代理运行图
+	memory     mem.MemoryIf                                    // 对话缓存
+	ctp        prompt.ChatTemplate                             // 系统提示词模板
+	lba        *compose.Lambda                                 // Lambda代理
+	rtr        retriever.Retriever                             // 检索器
+	localTools []tool.BaseTool                                 // eino提供的本地工具
+	toolSet    atomic.Pointer[toolSet]                         // 服务端工具,本地工具与外部工具,刷新时整体替换
+	log        *zap.SugaredLogger                              // 日志
 
 }
 
-func (u *UAiAgent) AppendTools(name string, tool []tool.BaseTool) error {
-	u.mu.Lock()
-	defer u.mu.Unlock()
-	// 创建工具节点
-	toolsNode, _ := compose.NewToolNode(u.ctx, &compose.ToolsNodeConfig{
-		Tools:                tool,
-		UnknownToolsHandler:  nil,
-		ExecuteSequentially:  false,
-		ToolArgumentsHandler: nil,
-	})
-	err := u.g.AddToolsNode("MCPTools", toolsNode)
-	if err != nil {
-		return err
+// toolSet 服务端工具及其信息,创建后不再修改
+type toolSet struct {
+	tools []tool.BaseTool
+	infos []*schema.ToolInfo
+}
+
+// SetTools 替换外部工具集(如MCP工具),下一次调用生效,无需重新编译运行图
+// 新的工具集在锁外构建后原子替换,进行中的对话继续使用原工具集,不阻塞新的对话
+func (u *UAiAgent) SetTools(tools []tool.BaseTool) error {
+	return u.setTools(tools)
+}
+
+// setTools 合并本地工具与外部工具,并记录工具信息交给模型
+func (u *UAiAgent) setTools(tools []tool.BaseTool) error {
+	tols := make([]tool.BaseTool, 0, len(u.localTools)+len(tools))
+	tols = append(tols, u.localTools...)
+	tols = append(tols, tools...)
+	infos := make([]*schema.ToolInfo, 0, len(tols))
+	for _, t := range tols {
+		info, err := t.Info(u.ctx)
+		if err != nil {
+			return err
+		}
+		infos = append(infos, info)
 	}
+	u.toolSet.Store(&toolSet{tools: tols, infos: infos})
 	return nil
 }
 
-// Invoke 函数用于运行一个代理，接受一个上下文、一个ID和一个消息作为参数，返回一个Message和一个错误
-func (u *UAiAgent) Invoke(msg string) (*schema.Message, error) {
-	u.mu.RLock()
-	defer u.mu.RUnlock()
+// runOptions 构建运行图的调用参数,将本次请求的模型参数与客户端工具传递给ReAct代理
+// 会话指定了模型时作为默认选择,请求中选择的模型优先;提示词模板指定了检索参数时覆盖系统配置,检索范围限定为模板的知识库集合
+func (u *UAiAgent) runOptions(conversation mem.ConversationIf, in *ChatInput) ([]compose.Option, error) {
+	opts := []compose.Option{compose.WithCallbacks(u.cbLog, newServerToolRecorder())}
+	// 每次调用都传入当前的工具集,工具刷新后无需重新编译运行图
+	tools, toolOpts, err := u.toolOptions(in)
+	if err != nil {
+		return nil, err
+	}
+	modelOpts := make([]model.Option, 0, len(in.Options)+len(toolOpts)+1)
+	if name := conversation.GetModel(); name != "" {
+		modelOpts = append(modelOpts, uaicharmodel.WithModel(name))
+	}
+	modelOpts = append(modelOpts, in.Options...)
+	modelOpts = append(modelOpts, toolOpts...)
+	agentOpts := make([]agent.AgentOption, 0, 2)
+	if len(modelOpts) > 0 {
+		agentOpts = append(agentOpts, react.WithChatModelOptions(modelOpts...))
+	}
+	if len(tools) > 0 {
+		agentOpts = append(agentOpts, react.WithToolList(tools...))
+	}
+	if len(agentOpts) > 0 {
+		lambdaOpts := make([]any, 0, len(agentOpts))
+		for _, v := range agentOpts {
+			lambdaOpts = append(lambdaOpts, v)
+		}
+		opts = append(opts, compose.WithLambdaOption(lambdaOpts...).DesignateNode(ReactAgent))
+	}
+	// 提示词模板指定的检索参数与可检索的知识库集合
+	topK, minScore := conversation.GetRetrieve()
+	collections, userId := conversation.GetKnowledge()
+	rtrOpts := []retriever.Option{WithScope(collections, userId)}
+	if topK > 0 {
+		rtrOpts = append(rtrOpts, retriever.WithTopK(topK))
+	}
+	if minScore > 0 {
+		rtrOpts = append(rtrOpts, retriever.WithScoreThreshold(minScore))
+	}
+	opts = append(opts, compose.WithRetrieverOption(rtrOpts...).DesignateNode(RedisRetriever))
+	return opts, nil
+}
+
+// Invoke 函数用于运行一个代理，接受客户端提交的消息列表作为参数，返回一个Message和一个错误
+// 代理运行、工具调用与MCP请求均绑定ctx,ctx取消时停止运行,与流式输出相同,已输出的部分回答以cancelled结束原因保存
+func (u *UAiAgent) Invoke(ctx context.Context, in *ChatInput) (*schema.Message, error) {
+	if in == nil || in.SessionId == "" {
+		return nil, errors.New("sessionId is required")
+	}
 	// 获取对话
-	conversation := u.memory.GetConversation(u.sessionId, true)
+	conversation := u.memory.GetConversation(in.SessionId, true)
 	// 创建用户消息
-	userMessage := &UserMessage{
-		SessionId: u.sessionId,
-		Query:     msg,
-		History:   conversation.GetMessages(),
+	userMessage, err := u.newUserMessage(conversation, in)
+	if err != nil {
+		return nil, err
 	}
-	// 运行代理
-	sr, err := u.r.Invoke(u.ctx, userMessage, compose.WithCallbacks(u.cbLog))
+	opts, err := u.runOptions(conversation, in)
+	if err != nil {
+		return nil, err
+	}
+	calls := newClientToolCalls(in.Tools)
+	cites := &citations{}
+	ctx = context.WithValue(ctx, clientToolCallsKey{}, calls)
+	ctx = context.WithValue(ctx, citationsKey{}, cites)
+	// 运行代理,按流式输出的方式保存本轮对话并读取完整的回答
+	sr, err := u.stream(ctx, conversation, userMessage, calls, cites, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to invoke: %w", err)
 	}
-	// add user input to history
-	conversation.Append(schema.UserMessage(msg))
-	// add agent response to history
-	conversation.Append(sr)
-
-	return sr, nil
+	defer sr.Close()
+	msgs := make([]*schema.Message, 0)
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to invoke: %w", err)
+		}
+		msgs = append(msgs, chunk)
+	}
+	// 模型调用了客户端工具,交还给客户端执行
+	if msg := calls.message(); msg != nil {
+		return msg, nil
+	}
+	if n := len(msgs); n > 0 {
+		last := msgs[n-1]
+		if last.ResponseMeta != nil && last.ResponseMeta.FinishReason == FinishReasonCancelled && last.Content == "" {
+			// 请求已取消,部分回答已保存
+			return nil, fmt.Errorf("failed to invoke: %w", ctx.Err())
+		}
+		if _, ok := last.Extra[extraKeyCitations]; ok && last.Content == "" {
+			// 去掉流式输出末尾的引用列表消息,引用列表附加在回答上
+			msgs = msgs[:n-1]
+		}
+	}
+	msg, err := schema.ConcatMessages(msgs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to invoke: %w", err)
+	}
+	// 回答携带提示词中使用的文档,引用列表不写入历史
+	return withCitations(msg, cites.get()), nil
 }
 
-// Stream 函数用于运行一个代理，接受一个上下文、一个ID和一个消息作为参数，返回一个StreamReader和一个错误
-func (u *UAiAgent) Stream(msg string) (*schema.StreamReader[*schema.Message], error) {
-	u.mu.RLock()
-	defer u.mu.RUnlock()
+// Stream 函数用于运行一个代理，接受客户端提交的消息列表作为参数，返回一个StreamReader和一个错误
+// ctx取消时停止模型输出与未完成的工具调用,已输出的部分回答以cancelled结束原因保存,输出流以一个cancelled分片结束
+func (u *UAiAgent) Stream(ctx context.Context, in *ChatInput) (*schema.StreamReader[*schema.Message], error) {
+	if in == nil || in.SessionId == "" {
+		return nil, errors.New("sessionId is required")
+	}
 	// 获取对话
-	conversation := u.memory.GetConversation(u.sessionId, true)
+	conversation := u.memory.GetConversation(in.SessionId, true)
 	// 创建用户消息
-	userMessage := &UserMessage{
-		SessionId: u.sessionId,
-		Query:     msg,
-		History:   conversation.GetMessages(),
+	userMessage, err := u.newUserMessage(conversation, in)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := u.runOptions(conversation, in)
+	if err != nil {
+		return nil, err
+	}
+	calls := newClientToolCalls(in.Tools)
+	cites := &citations{}
+	ctx = context.WithValue(ctx, clientToolCallsKey{}, calls)
+	ctx = context.WithValue(ctx, citationsKey{}, cites)
+	run := func(extra ...compose.Option) (*schema.StreamReader[*schema.Message], error) {
+		runOpts := make([]compose.Option, 0, len(opts)+len(extra))
+		runOpts = append(runOpts, opts...)
+		runOpts = append(runOpts, extra...)
+		return u.stream(ctx, conversation, userMessage, calls, cites, runOpts)
+	}
+	// 需要输出工具事件时,后台运行代理,工具调用过程实时写入输出流
+	if in.Events {
+		return u.streamWithEvents(in, run), nil
 	}
+	return run()
+}
+
+// stream 运行代理并保存本轮对话,模型调用了客户端工具时,ReAct代理直接返回工具消息,替换为assistant工具调用消息
+func (u *UAiAgent) stream(ctx context.Context, conversation mem.ConversationIf, userMessage *UserMessage, calls *clientToolCalls, cites *citations, opts []compose.Option) (*schema.StreamReader[*schema.Message], error) {
 	// 运行代理
-	sr, err := u.r.Stream(u.ctx, userMessage, compose.WithCallbacks(u.cbLog))
+	sr, err := u.r.Stream(ctx, userMessage, opts...)
 	if err != nil {
 		return nil, err
 	}
-	// 启动一个goroutine来保存到内存中
-	srs := sr.Copy(2)
-	go func() {
-		// for save to memory
-		fullMsgs := make([]*schema.Message, 0)
-		defer func() {
-			// close stream if you used it
-			srs[1].Close()
+	sent := false
+	sr = schema.StreamReaderWithConvert(sr, func(msg *schema.Message) (*schema.Message, error) {
+		if msg.Role != schema.Tool {
+			return msg, nil
+		}
+		toolCallMsg := calls.message()
+		if sent || toolCallMsg == nil {
+			return nil, schema.ErrNoValue
+		}
+		sent = true
+		return toolCallMsg, nil
+	})
+	return u.saveStream(ctx, conversation, userMessage, calls, cites, sr), nil
+}
 
-			// add user input to history
-			conversation.Append(schema.UserMessage(msg))
+// FinishReasonCancelled 请求被取消(客户端断开或主动取消)时的结束原因
+const FinishReasonCancelled = "cancelled"
 
-			fullMsg, err := schema.ConcatMessages(fullMsgs)
-			if err != nil {
-				fmt.Println("error concatenating messages: ", err.Error())
-			}
-			// add agent response to history
-			conversation.Append(fullMsg)
-		}()
+// saveStream 转发输出流,在流结束或请求取消后将本轮对话保存到内存中
+// 读取方关闭输出流后仍会读完代理的输出,保证部分回答被保存;回答正常结束时以一个携带引用列表的消息结束输出流
+func (u *UAiAgent) saveStream(ctx context.Context, conversation mem.ConversationIf, userMessage *UserMessage, calls *clientToolCalls, cites *citations, sr *schema.StreamReader[*schema.Message]) *schema.StreamReader[*schema.Message] {
+	out, w := schema.Pipe[*schema.Message](16)
+	go func() {
+		defer w.Close()
+		defer sr.Close()
+		fullMsgs := make([]*schema.Message, 0)
+		closed := false
 		for {
-			select {
-			case <-u.ctx.Done():
-				fmt.Println("context done", u.ctx.Err())
-				return
-			default:
-				chunk, err := srs[1].Recv()
-				if err != nil {
-					if errors.Is(err, io.EOF) {
-						return
+			chunk, err := sr.Recv()
+			if err != nil {
+				switch {
+				case errors.Is(err, io.EOF):
+					u.saveTurn(conversation, userMessage, calls, fullMsgs, "")
+					if cs := cites.get(); len(cs) > 0 && calls.message() == nil && !closed {
+						w.Send(newCitationsMessage(cs), nil)
+					}
+				case ctx.Err() != nil:
+					u.saveTurn(conversation, userMessage, calls, fullMsgs, FinishReasonCancelled)
+					if !closed {
+						w.Send(&schema.Message{
+							Role:         schema.Assistant,
+							ResponseMeta: &schema.ResponseMeta{FinishReason: FinishReasonCancelled},
+						}, nil)
+					}
+				default:
+					if !closed {
+						w.Send(nil, err)
 					}
 				}
-
-				fullMsgs = append(fullMsgs, chunk)
+				return
+			}
+			fullMsgs = append(fullMsgs, chunk)
+			if !closed {
+				closed = w.Send(chunk, nil)
 			}
 		}
 	}()
-	return srs[0], nil
+	return out
+}
+
+// saveTurn 保存本轮对话,finishReason不为空时覆盖回答的结束原因;取消时没有输出则不保存
+func (u *UAiAgent) saveTurn(conversation mem.ConversationIf, userMessage *UserMessage, calls *clientToolCalls, msgs []*schema.Message, finishReason string) {
+	if finishReason == FinishReasonCancelled && len(msgs) == 0 {
+		return
+	}
+	if !userMessage.isToolResult() {
+		// add user input to history
+		conversation.Append(schema.UserMessage(userMessage.Query))
+	}
+	// 模型调用了客户端工具,交还给客户端执行,只保存已执行的服务端工具结果
+	if calls.message() != nil {
+		u.saveToolStep(conversation, calls)
+		return
+	}
+	fullMsg, err := schema.ConcatMessages(msgs)
+	if err != nil {
+		u.log.Errorf("error concatenating messages: %s", err.Error())
+		return
+	}
+	if finishReason != "" {
+		if fullMsg.ResponseMeta == nil {
+			fullMsg.ResponseMeta = &schema.ResponseMeta{}
+		}
+		fullMsg.ResponseMeta.FinishReason = finishReason
+	}
+	// add agent response to history
+	conversation.Append(fullMsg)
+}
+
+// saveToolStep 保存交还客户端前已执行的服务端工具调用及其结果,客户端返回工具结果时一并交给模型
+func (u *UAiAgent) saveToolStep(conversation mem.ConversationIf, calls *clientToolCalls) {
+	for _, v := range calls.serverStep() {
+		conversation.Append(v)
+	}
 }
 
 // Collect 函数用于运行一个代理，接受一个上下文、一个ID和一个消息作为参数，返回一个Message和一个错误
-func (u *UAiAgent) Collect(inMsg *schema.StreamReader[string]) (*schema.Message, error) {
-	u.mu.RLock()
-	defer u.mu.RUnlock()
+func (u *UAiAgent) Collect(ctx context.Context, sessionId string, inMsg *schema.StreamReader[string]) (*schema.Message, error) {
 	in, sw := schema.Pipe[*UserMessage](10)
 	defer func() {
 		in.Close()
@@ -210,11 +385,15 @@ func (u *UAiAgent) Collect(inMsg *schema.StreamReader[string]) (*schema.Message,
 	}()
 	msg := ""
 	// 获取对话
-	conversation := u.memory.GetConversation(u.sessionId, true)
+	conversation := u.memory.GetConversation(sessionId, true)
+	opts, err := u.runOptions(conversation, &ChatInput{SessionId: sessionId})
+	if err != nil {
+		return nil, err
+	}
 	go func() {
 		for {
 			select {
-			case <-u.ctx.Done():
+			case <-ctx.Done():
 				{
 					return
 				}
@@ -228,9 +407,11 @@ func (u *UAiAgent) Collect(inMsg *schema.StreamReader[string]) (*schema.Message,
 						return
 					}
 					userMsg := &UserMessage{
-						SessionId: u.sessionId,
+						SessionId: sessionId,
+						Prompt:    conversation.GetPrompt(),
 						Query:     msg,
 						History:   conversation.GetMessages(),
+						Input:     []*schema.Message{schema.UserMessage(msg)},
 					}
 					msg += msg
 					isClose := sw.Send(userMsg, nil)
@@ -242,7 +423,7 @@ func (u *UAiAgent) Collect(inMsg *schema.StreamReader[string]) (*schema.Message,
 		}
 	}()
 	// 运行代理
-	sr, err := u.r.Collect(u.ctx, in, compose.WithCallbacks(u.cbLog))
+	sr, err := u.r.Collect(ctx, in, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to invoke: %w", err)
 	}
@@ -256,9 +437,7 @@ func (u *UAiAgent) Collect(inMsg *schema.StreamReader[string]) (*schema.Message,
 }
 
 // Transform 函数用于运行一个代理，接受一个上下文、一个ID和一个消息作为参数，返回一个Message和一个错误
-func (u *UAiAgent) Transform(inMsg *schema.StreamReader[string]) (*schema.StreamReader[*schema.Message], error) {
-	u.mu.RLock()
-	defer u.mu.RUnlock()
+func (u *UAiAgent) Transform(ctx context.Context, sessionId string, inMsg *schema.StreamReader[string]) (*schema.StreamReader[*schema.Message], error) {
 	in, sw := schema.Pipe[*UserMessage](10)
 	defer func() {
 		in.Close()
@@ -266,11 +445,15 @@ func (u *UAiAgent) Transform(inMsg *schema.StreamReader[string]) (*schema.Stream
 	}()
 	msgs := ""
 	// 获取对话
-	conversation := u.memory.GetConversation(u.sessionId, true)
+	conversation := u.memory.GetConversation(sessionId, true)
+	opts, err := u.runOptions(conversation, &ChatInput{SessionId: sessionId})
+	if err != nil {
+		return nil, err
+	}
 	go func() {
 		for {
 			select {
-			case <-u.ctx.Done():
+			case <-ctx.Done():
 				{
 					return
 				}
@@ -284,9 +467,11 @@ func (u *UAiAgent) Transform(inMsg *schema.StreamReader[string]) (*schema.Stream
 						return
 					}
 					userMsg := &UserMessage{
-						SessionId: u.sessionId,
+						SessionId: sessionId,
+						Prompt:    conversation.GetPrompt(),
 						Query:     msg,
 						History:   conversation.GetMessages(),
+						Input:     []*schema.Message{schema.UserMessage(msg)},
 					}
 					msgs += msg
 					isClose := sw.Send(userMsg, nil)
@@ -298,7 +483,7 @@ func (u *UAiAgent) Transform(inMsg *schema.StreamReader[string]) (*schema.Stream
 		}
 	}()
 	// 运行代理
-	sr, err := u.r.Transform(u.ctx, in, compose.WithCallbacks(u.cbLog))
+	sr, err := u.r.Transform(ctx, in, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to invoke: %w", err)
 	}
@@ -317,27 +502,18 @@ func (u *UAiAgent) Transform(inMsg *schema.StreamReader[string]) (*schema.Stream
 
 			fullMsg, err := schema.ConcatMessages(fullMsgs)
 			if err != nil {
-				fmt.Println("error concatenating messages: ", err.Error())
+				u.log.Errorf("error concatenating messages: %s", err.Error())
+				return
 			}
 			// add agent response to history
 			conversation.Append(fullMsg)
 		}()
 		for {
-			select {
-			case <-u.ctx.Done():
-				fmt.Println("context done", u.ctx.Err())
+			chunk, err := srs[1].Recv()
+			if err != nil {
 				return
-			default:
-				chunk, err := srs[1].Recv()
-				if err != nil {
-					if errors.Is(err, io.EOF) {
-						return
-					}
-				}
-
-				fullMsgs = append(fullMsgs, chunk)
-
 			}
+			fullMsgs = append(fullMsgs, chunk)
 		}
 	}()
 	return srs[0], nil
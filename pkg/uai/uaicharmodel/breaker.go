@@ -0,0 +1,85 @@
+package uaicharmodel
+
+import (
+	"sync"
+	"time"
+)
+
+// breaker 熔断器,连续失败达到阈值后将模型移出轮换,冷却时间过后放行一次请求试探是否恢复
+type breaker struct {
+	mu        sync.Mutex
+	threshold int           // 连续失败阈值,小于等于0时不熔断
+	cooldown  time.Duration // 熔断冷却时间
+	failures  int           // 连续失败次数
+	openUntil time.Time     // 熔断截止时间
+	probing   bool          // 冷却结束后是否已有试探请求
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// allow 是否放行请求
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false
+	}
+	// 半开状态,只放行一次试探
+	b.probing = true
+	return true
+}
+
+// success 请求成功,关闭熔断
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+}
+
+// failure 请求失败,达到阈值时熔断
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// release 归还试探名额,请求未得出结果(如调用方取消)时既不计成功也不计失败
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// trip 直接熔断,用于启动时连接测试失败的模型
+func (b *breaker) trip() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.threshold <= 0 {
+		return
+	}
+	if b.failures < b.threshold {
+		b.failures = b.threshold
+	}
+	b.probing = false
+	b.openUntil = time.Now().Add(b.cooldown)
+}
+
+// isOpen 是否处于熔断状态
+func (b *breaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.threshold > 0 && b.failures >= b.threshold && time.Now().Before(b.openUntil)
+}
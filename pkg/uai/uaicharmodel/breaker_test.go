@@ -0,0 +1,84 @@
+package uaicharmodel
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	const (
+		allow   = "allow"
+		success = "success"
+		failure = "failure"
+		release = "release"
+		trip    = "trip"
+		expire  = "expire" // 冷却时间到期
+	)
+	type step struct {
+		op   string
+		want bool // allow时的期望结果,其他操作为isOpen的期望结果
+	}
+	tests := []struct {
+		name      string
+		threshold int
+		steps     []step
+	}{
+		{"disabled", 0, []step{
+			{failure, false}, {failure, false}, {allow, true}, {trip, false}, {allow, true},
+		}},
+		{"below threshold", 2, []step{
+			{failure, false}, {allow, true}, {success, false}, {failure, false}, {allow, true},
+		}},
+		{"open at threshold", 2, []step{
+			{failure, false}, {failure, true}, {allow, false},
+		}},
+		{"single probe when half open", 2, []step{
+			{failure, false}, {failure, true}, {expire, false}, {allow, true}, {allow, false},
+		}},
+		{"probe success closes", 2, []step{
+			{failure, false}, {failure, true}, {expire, false}, {allow, true}, {success, false}, {allow, true}, {allow, true},
+		}},
+		{"probe failure reopens", 2, []step{
+			{failure, false}, {failure, true}, {expire, false}, {allow, true}, {failure, true}, {allow, false},
+		}},
+		{"released probe can be retried", 2, []step{
+			{failure, false}, {failure, true}, {expire, false}, {allow, true}, {release, false}, {allow, true}, {allow, false},
+		}},
+		{"release keeps failures", 2, []step{
+			{failure, false}, {release, false}, {failure, true}, {allow, false},
+		}},
+		{"trip opens immediately", 3, []step{
+			{trip, true}, {allow, false}, {expire, false}, {allow, true}, {allow, false}, {success, false}, {allow, true},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(tt.threshold, time.Hour)
+			for i, s := range tt.steps {
+				var got bool
+				switch s.op {
+				case allow:
+					got = b.allow()
+				case success:
+					b.success()
+				case failure:
+					b.failure()
+				case release:
+					b.release()
+				case trip:
+					b.trip()
+				case expire:
+					b.mu.Lock()
+					b.openUntil = time.Now().Add(-time.Second)
+					b.mu.Unlock()
+				}
+				if s.op != allow {
+					got = b.isOpen()
+				}
+				if got != s.want {
+					t.Fatalf("step %d %s: got %v, want %v", i, s.op, got, s.want)
+				}
+			}
+		})
+	}
+}
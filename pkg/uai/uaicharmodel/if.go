@@ -1,6 +1,7 @@
 package uaicharmodel
 
 import (
+	"context"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/openai/openai-go"
 )
@@ -14,6 +15,8 @@ type If interface {
 	// SetProvider 设置模型提供方
 	SetProvider(provider ModelProvider) error
 	V1() *openai.Client
+	// Ping 测试连接是否正常
+	Ping(ctx context.Context) error
 }
 
 type UChatModelIf interface {
@@ -0,0 +1,233 @@
+package uaicharmodel
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/openai/openai-go"
+	"go.uber.org/zap"
+)
+
+// ModelInfo 模型目录中的模型
+type ModelInfo struct {
+	Name     string        // 模型名称,请求按名称选择
+	Provider ModelProvider // 模型提供商
+	Model    string        // 模型提供商的模型名称
+	Default  bool          // 是否为默认模型
+}
+
+// ModelsIf 多个命名模型,按请求选择,未指定时使用默认模型
+type ModelsIf interface {
+	If
+	// Models 模型目录,默认模型在前,其余按名称排序
+	Models() []ModelInfo
+	// Has 判断模型是否在目录中
+	Has(name string) bool
+}
+
+// RouteOption 单次请求选择的模型
+type RouteOption struct {
+	Model string // 模型名称,为空时使用默认模型
+}
+
+// WithModel 按名称选择本次请求使用的模型
+func WithModel(name string) model.Option {
+	return model.WrapImplSpecificOptFn(func(o *RouteOption) {
+		o.Model = name
+	})
+}
+
+// NewModels 创建模型目录,def为默认模型,以其模型名称登记;named为其他命名模型;ro为重试、故障转移与熔断配置
+// 启动时连接测试失败不会中断启动,服务以降级模式运行,调用时由重试与故障转移处理
+func NewModels(ctx context.Context, def *Option, named map[string]*Option, ro *ResilientOption, log *zap.SugaredLogger) ModelsIf {
+	if ro == nil {
+		ro = NewDefaultResilientOption()
+	}
+	ms := &UChatModels{
+		ctx:      ctx,
+		models:   make(map[string]If),
+		infos:    make(map[string]ModelInfo),
+		breakers: make(map[string]*breaker),
+		def:      def.Model,
+		ro:       ro,
+		log:      log,
+	}
+	ms.add(def.Model, def, true)
+	for name, opt := range named {
+		if opt == nil {
+			continue
+		}
+		ms.add(name, opt, false)
+	}
+	for _, v := range ro.Fallback {
+		if _, ok := ms.models[v]; !ok {
+			panic(fmt.Sprintf("fallback model %s is not configured", v))
+		}
+	}
+	// 测试连接,失败时直接熔断,冷却结束后由试探请求判断是否恢复
+	for name, cm := range ms.models {
+		pctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := cm.Ping(pctx)
+		cancel()
+		if err != nil {
+			ms.breakers[name].trip()
+			if log != nil {
+				log.Warnf("模型%s连接失败,以降级模式启动: %s", name, err.Error())
+			}
+		}
+	}
+	return ms
+}
+
+type UChatModels struct {
+	mu       sync.RWMutex         // 读写锁
+	ctx      context.Context      // 上下文
+	models   map[string]If        // 命名模型
+	infos    map[string]ModelInfo // 模型目录
+	breakers map[string]*breaker  // 各模型的熔断器
+	def      string               // 默认模型名称
+	ro       *ResilientOption     // 重试、故障转移与熔断配置
+	tools    []*schema.ToolInfo   // 已绑定的工具,调用时绑定到所选的模型
+	log      *zap.SugaredLogger   // 日志
+}
+
+func (ms *UChatModels) add(name string, opt *Option, isDefault bool) {
+	if name == "" {
+		panic("model name is empty")
+	}
+	if _, ok := ms.models[name]; ok {
+		panic(fmt.Sprintf("duplicate model name: %s", name))
+	}
+	cm := New(ms.ctx, opt)
+	ms.models[name] = cm
+	ms.breakers[name] = newBreaker(ms.ro.BreakerThreshold, time.Duration(ms.ro.BreakerCooldown)*time.Second)
+	p, _ := GetProvider(ModelProvider(opt.Provider))
+	ms.infos[name] = ModelInfo{
+		Name:     name,
+		Provider: p.Name,
+		Model:    opt.Model,
+		Default:  isDefault,
+	}
+}
+
+// bind 取出命名模型并绑定工具
+func (ms *UChatModels) bind(name string) (model.ToolCallingChatModel, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	cm := ms.models[name]
+	if len(ms.tools) == 0 {
+		return cm, nil
+	}
+	return cm.WithTools(ms.tools)
+}
+
+func (ms *UChatModels) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	var out *schema.Message
+	err := ms.call(ctx, opts, func(cm model.ToolCallingChatModel) error {
+		var err error
+		out, err = cm.Generate(ctx, input, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (ms *UChatModels) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	var out *schema.StreamReader[*schema.Message]
+	err := ms.call(ctx, opts, func(cm model.ToolCallingChatModel) error {
+		sr, err := cm.Stream(ctx, input, opts...)
+		if err != nil {
+			return err
+		}
+		out, err = peekStream(sr)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (ms *UChatModels) BindTools(tools []*schema.ToolInfo) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.tools = tools
+	return nil
+}
+
+// WithTools 返回绑定了工具的新实例,工具在调用时绑定到所选的模型
+func (ms *UChatModels) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return &UChatModels{
+		ctx:      ms.ctx,
+		models:   ms.models,
+		infos:    ms.infos,
+		breakers: ms.breakers,
+		def:      ms.def,
+		ro:       ms.ro,
+		tools:    tools,
+		log:      ms.log,
+	}, nil
+}
+
+// SetProvider 切换默认模型的提供者
+func (ms *UChatModels) SetProvider(provider ModelProvider) error {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.models[ms.def].SetProvider(provider)
+}
+
+// V1 默认模型的OpenAI V1客户端
+func (ms *UChatModels) V1() *openai.Client {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	return ms.models[ms.def].V1()
+}
+
+func (ms *UChatModels) Models() []ModelInfo {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	res := make([]ModelInfo, 0, len(ms.infos))
+	for _, v := range ms.infos {
+		res = append(res, v)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Default != res[j].Default {
+			return res[i].Default
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// Ping 测试默认模型的连接
+func (ms *UChatModels) Ping(ctx context.Context) error {
+	return ms.models[ms.def].Ping(ctx)
+}
+
+func (ms *UChatModels) Has(name string) bool {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	_, ok := ms.models[name]
+	return ok
+}
+
+// GetType 组件类型,用于回调日志
+func (ms *UChatModels) GetType() string {
+	return "UChatModels"
+}
+
+// IsCallbacksEnabled 回调由所选模型内部的eino cm触发
+func (ms *UChatModels) IsCallbacksEnabled() bool {
+	return true
+}
+
+var _ ModelsIf = &UChatModels{}
@@ -1,22 +1,77 @@
 package uaicharmodel
 
+import "github.com/cloudwego/eino/components/model"
+
 const (
-	OpenAI   ModelProvider = "OpenAi"
-	Ollama   ModelProvider = "Ollama"
-	DeepSeek ModelProvider = "DeepSeek"
+	OpenAI           ModelProvider = "OpenAi"
+	Azure            ModelProvider = "Azure"
+	Ollama           ModelProvider = "Ollama"
+	DeepSeek         ModelProvider = "DeepSeek"
+	Qwen             ModelProvider = "Qwen"
+	Zhipu            ModelProvider = "Zhipu"
+	Moonshot         ModelProvider = "Moonshot"
+	OpenAICompatible ModelProvider = "OpenAICompatible"
 )
 
-var SupportModelProvider = map[ModelProvider]string{
-	OpenAI:   "OpenAI",
-	Ollama:   "Ollama",
-	DeepSeek: "DeepSeek",
+type Option struct {
+	APIKey           string            `comment:"API-秘钥"`                                                                             // API秘钥
+	BaseURL          string            `comment:"API-链接"`                                                                             // API基础链接
+	Organization     string            `comment:"API-使用组织"`                                                                           // API使用组织
+	Provider         string            `comment:"API-模型提供商:OpenAi、Azure、Ollama、DeepSeek、Qwen、Zhipu、Moonshot、OpenAICompatible,不区分大小写"` // API提供商,按名称在注册表中选择
+	Model            string            `comment:"API-应用模型"`                                                                           // API-应用模型
+	Timeout          int64             `comment:"API-超时时间"`                                                                           // API超时时间,单位秒
+	ContextWindow    int               `comment:"模型上下文窗口大小,单位token,用于对话记忆的token预算"`                                                   // 模型上下文窗口大小,单位token
+	APIVersion       string            `comment:"API-版本,Azure OpenAI使用"`                                                              // API版本
+	AzureDeployments map[string]string `comment:"Azure部署名映射,模型名:部署名,未配置的模型使用模型名作为部署名"`                                                // Azure部署名映射
+	Path             string            `comment:"API-对话接口路径,OpenAI兼容接口使用,为空时使用/chat/completions"`                                     // 对话接口路径
+	Headers          map[string]string `comment:"API-额外请求头,OpenAI兼容接口使用"`                                                             // 额外请求头
 }
 
-type Option struct {
-	APIKey       string `comment:"API-秘钥"`                                           // API秘钥
-	BaseURL      string `comment:"API-链接"`                                           // API基础链接
-	Organization string `comment:"API-使用组织"`                                         // API使用组织
-	Provider     string `comment:"API-模型提供商:OpenAI、Ollama、DeepSeek等支持OpenAi Api的厂商"` // API提供商:OpenAI、Ollama、DeepSeek等支持OpenAi Api的厂商
-	Model        string `comment:"API-应用模型"`                                         // API-应用模型
-	Timeout      int64  `comment:"API-超时时间"`                                         // API超时时间,单位秒
+// ResponseFormat 模型输出格式
+type ResponseFormat string
+
+const (
+	ResponseFormatText ResponseFormat = "text"        // 普通文本
+	ResponseFormatJSON ResponseFormat = "json_object" // JSON模式,输出合法的JSON对象
+)
+
+// CallOption 单次请求的扩展参数,eino公共参数(temperature、top_p、max_tokens、stop)之外,各模型提供商需要在配置中指定的参数
+type CallOption struct {
+	Seed             *int           // 随机种子
+	ResponseFormat   ResponseFormat // 输出格式
+	FrequencyPenalty *float32       // 频率惩罚
+	PresencePenalty  *float32       // 存在惩罚
+}
+
+// isEmpty 判断是否没有扩展参数
+func (o *CallOption) isEmpty() bool {
+	return o.Seed == nil && o.ResponseFormat == "" && o.FrequencyPenalty == nil && o.PresencePenalty == nil
+}
+
+// WithSeed 设置随机种子
+func WithSeed(seed int) model.Option {
+	return model.WrapImplSpecificOptFn(func(o *CallOption) {
+		o.Seed = &seed
+	})
+}
+
+// WithResponseFormat 设置输出格式
+func WithResponseFormat(format ResponseFormat) model.Option {
+	return model.WrapImplSpecificOptFn(func(o *CallOption) {
+		o.ResponseFormat = format
+	})
+}
+
+// WithFrequencyPenalty 设置频率惩罚
+func WithFrequencyPenalty(penalty float32) model.Option {
+	return model.WrapImplSpecificOptFn(func(o *CallOption) {
+		o.FrequencyPenalty = &penalty
+	})
+}
+
+// WithPresencePenalty 设置存在惩罚
+func WithPresencePenalty(penalty float32) model.Option {
+	return model.WrapImplSpecificOptFn(func(o *CallOption) {
+		o.PresencePenalty = &penalty
+	})
 }
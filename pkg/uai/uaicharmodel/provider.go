@@ -0,0 +1,117 @@
+package uaicharmodel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/openai/openai-go/option"
+)
+
+// ProviderFactory 模型提供商构造器,co/common为单次请求参数,不为nil
+type ProviderFactory func(ctx context.Context, opt *Option, co *CallOption, common *model.Options) (UChatModelIf, error)
+
+// ProviderField 模型提供商使用的配置项
+type ProviderField struct {
+	Name     string // Option中的字段名
+	Required bool   // 是否必填
+	Comment  string // 说明
+}
+
+// Provider 模型提供商
+type Provider struct {
+	Name           ModelProvider   // 名称,配置中按名称选择,不区分大小写
+	Desc           string          // 描述
+	Fields         []ProviderField // 配置项说明,必填项为空时拒绝创建
+	DefaultBaseURL string          // 未配置API链接时使用的默认链接
+	New            ProviderFactory // 构造器
+	// ClientOptions OpenAI V1客户端(模型列表等接口)的参数,为nil时按 BaseURL+"/v1" 连接
+	ClientOptions func(opt *Option) []option.RequestOption
+	// ListModels 是否支持模型列表接口,支持时启动时用于测试连接
+	ListModels bool
+	// DeriveOnMaxTokens 不支持按请求设置max_tokens,需要按参数派生cm
+	DeriveOnMaxTokens bool
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[string]*Provider)
+)
+
+// RegisterProvider 注册模型提供商,同名时覆盖
+func RegisterProvider(p *Provider) {
+	if p == nil || p.Name == "" || p.New == nil {
+		panic("invalid model provider")
+	}
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[strings.ToLower(string(p.Name))] = p
+}
+
+// GetProvider 按名称获取模型提供商,不区分大小写
+func GetProvider(name ModelProvider) (*Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[strings.ToLower(string(name))]
+	return p, ok
+}
+
+// Providers 已注册的模型提供商,按名称排序
+func Providers() []*Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	res := make([]*Provider, 0, len(providers))
+	for _, v := range providers {
+		res = append(res, v)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// resolve 补全默认配置并校验必填项,返回新的配置,不修改原配置
+func (p *Provider) resolve(opt *Option) (*Option, error) {
+	if opt == nil {
+		return nil, errors.New("model option is nil")
+	}
+	o := *opt
+	if o.BaseURL == "" {
+		o.BaseURL = p.DefaultBaseURL
+	}
+	v := reflect.ValueOf(o)
+	for _, f := range p.Fields {
+		if !f.Required {
+			continue
+		}
+		fv := v.FieldByName(f.Name)
+		if !fv.IsValid() {
+			return nil, fmt.Errorf("model provider %s: unknown option %s", p.Name, f.Name)
+		}
+		if fv.IsZero() {
+			return nil, fmt.Errorf("model provider %s: option %s is required", p.Name, f.Name)
+		}
+	}
+	return &o, nil
+}
+
+// clientOptions OpenAI V1客户端参数
+func (p *Provider) clientOptions(opt *Option) []option.RequestOption {
+	if p.ClientOptions != nil {
+		return p.ClientOptions(opt)
+	}
+	opts := []option.RequestOption{
+		option.WithBaseURL(opt.BaseURL + "/v1"),   // 设置 OpenAI API 的基础 URL
+		option.WithAPIKey(opt.APIKey),             // 设置 OpenAI API 的密钥
+		option.WithOrganization(opt.Organization), // 设置 OpenAI API 的调用组织 ID
+	}
+	for k, v := range opt.Headers {
+		opts = append(opts, option.WithHeader(k, v))
+	}
+	return opts
+}
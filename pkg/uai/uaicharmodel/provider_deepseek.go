@@ -0,0 +1,55 @@
+package uaicharmodel
+
+import (
+	"context"
+	"time"
+
+	edeepseek "github.com/cloudwego/eino-ext/components/model/deepseek"
+	"github.com/cloudwego/eino/components/model"
+)
+
+func init() {
+	RegisterProvider(&Provider{
+		Name: DeepSeek,
+		Desc: "DeepSeek开放平台",
+		Fields: []ProviderField{
+			{Name: "APIKey", Required: true, Comment: "API秘钥"},
+			{Name: "Model", Required: true, Comment: "模型名称"},
+			{Name: "Path", Comment: "对话接口路径,为空时使用chat/completions"},
+		},
+		DefaultBaseURL: "https://api.deepseek.com",
+		New:            newDeepSeekChatModel,
+		ListModels:     true,
+	})
+}
+
+// newDeepSeekChatModel 创建DeepSeek的cm,DeepSeek不支持seed
+func newDeepSeekChatModel(ctx context.Context, opt *Option, co *CallOption, _ *model.Options) (UChatModelIf, error) {
+	conf := &edeepseek.ChatModelConfig{
+		APIKey:             opt.APIKey,
+		Timeout:            time.Duration(opt.Timeout) * time.Second,
+		HTTPClient:         newHTTPClient(&Option{Timeout: opt.Timeout, Headers: opt.Headers}),
+		BaseURL:            opt.BaseURL,
+		Path:               opt.Path,
+		Model:              opt.Model,
+		MaxTokens:          0,
+		Temperature:        0,
+		TopP:               0,
+		Stop:               nil,
+		PresencePenalty:    0,
+		ResponseFormatType: "",
+		FrequencyPenalty:   0,
+		LogProbs:           false,
+		TopLogProbs:        0,
+	}
+	if co.ResponseFormat != "" {
+		conf.ResponseFormatType = edeepseek.ResponseFormatType(co.ResponseFormat)
+	}
+	if co.PresencePenalty != nil {
+		conf.PresencePenalty = *co.PresencePenalty
+	}
+	if co.FrequencyPenalty != nil {
+		conf.FrequencyPenalty = *co.FrequencyPenalty
+	}
+	return edeepseek.NewChatModel(ctx, conf)
+}
@@ -0,0 +1,60 @@
+package uaicharmodel
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	eollama "github.com/cloudwego/eino-ext/components/model/ollama"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/ollama/ollama/api"
+)
+
+func init() {
+	RegisterProvider(&Provider{
+		Name: Ollama,
+		Desc: "Ollama本地模型,API链接为服务地址,如 http://127.0.0.1:11434",
+		Fields: []ProviderField{
+			{Name: "BaseURL", Required: true, Comment: "服务地址"},
+			{Name: "Model", Required: true, Comment: "模型名称"},
+		},
+		DefaultBaseURL:    "http://127.0.0.1:11434",
+		New:               newOllamaChatModel,
+		ListModels:        true,
+		DeriveOnMaxTokens: true,
+	})
+}
+
+// newOllamaChatModel 创建Ollama的cm,Ollama的采样参数在配置中指定
+func newOllamaChatModel(ctx context.Context, opt *Option, co *CallOption, common *model.Options) (UChatModelIf, error) {
+	conf := &eollama.ChatModelConfig{
+		BaseURL:    opt.BaseURL,
+		Timeout:    time.Duration(opt.Timeout) * time.Second,
+		HTTPClient: nil,
+		Model:      opt.Model,
+		Format:     nil,
+		KeepAlive:  nil,
+		Options:    nil,
+	}
+	if co.ResponseFormat == ResponseFormatJSON {
+		conf.Format = json.RawMessage(`"json"`)
+	}
+	if !co.isEmpty() || common.MaxTokens != nil {
+		// 以Ollama默认参数为基础,避免未指定的温度等参数被置零
+		opts := api.DefaultOptions()
+		if co.Seed != nil {
+			opts.Seed = *co.Seed
+		}
+		if co.FrequencyPenalty != nil {
+			opts.FrequencyPenalty = *co.FrequencyPenalty
+		}
+		if co.PresencePenalty != nil {
+			opts.PresencePenalty = *co.PresencePenalty
+		}
+		if common.MaxTokens != nil {
+			opts.NumPredict = *common.MaxTokens
+		}
+		conf.Options = &opts
+	}
+	return eollama.NewChatModel(ctx, conf)
+}
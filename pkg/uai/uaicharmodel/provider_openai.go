@@ -0,0 +1,198 @@
+package uaicharmodel
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	eopenai "github.com/cloudwego/eino-ext/components/model/openai"
+	aclopenai "github.com/cloudwego/eino-ext/libs/acl/openai"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/freedqo/fmc-go-agent/pkg/utils"
+	"github.com/openai/openai-go/option"
+)
+
+func init() {
+	RegisterProvider(&Provider{
+		Name: OpenAI,
+		Desc: "OpenAI,API链接为空时为 https://api.openai.com/v1,未包含版本路径时模型列表接口按 API链接+/v1 连接",
+		Fields: []ProviderField{
+			{Name: "APIKey", Required: true, Comment: "API秘钥"},
+			{Name: "Model", Required: true, Comment: "模型名称"},
+			{Name: "Organization", Comment: "使用组织"},
+		},
+		DefaultBaseURL: "https://api.openai.com/v1",
+		New:            newOpenAIChatModel,
+		ClientOptions:  openAIClientOptions,
+		ListModels:     true,
+	})
+	RegisterProvider(&Provider{
+		Name: Azure,
+		Desc: "Azure OpenAI,API链接为资源终结点,如 https://{resource}.openai.azure.com",
+		Fields: []ProviderField{
+			{Name: "BaseURL", Required: true, Comment: "资源终结点"},
+			{Name: "APIKey", Required: true, Comment: "API秘钥"},
+			{Name: "APIVersion", Required: true, Comment: "API版本"},
+			{Name: "Model", Required: true, Comment: "模型名称"},
+			{Name: "AzureDeployments", Comment: "模型名与部署名的映射"},
+		},
+		New:           newOpenAIChatModel,
+		ClientOptions: azureClientOptions,
+		ListModels:    true,
+	})
+	RegisterProvider(&Provider{
+		Name:           Qwen,
+		Desc:           "通义千问(阿里云百炼DashScope),OpenAI兼容模式",
+		Fields:         compatibleFields(false),
+		DefaultBaseURL: "https://dashscope.aliyuncs.com/compatible-mode/v1",
+		New:            newOpenAIChatModel,
+		ClientOptions:  compatibleClientOptions,
+		ListModels:     true,
+	})
+	RegisterProvider(&Provider{
+		Name:           Zhipu,
+		Desc:           "智谱AI,OpenAI兼容接口",
+		Fields:         compatibleFields(false),
+		DefaultBaseURL: "https://open.bigmodel.cn/api/paas/v4",
+		New:            newOpenAIChatModel,
+		ClientOptions:  compatibleClientOptions,
+	})
+	RegisterProvider(&Provider{
+		Name:           Moonshot,
+		Desc:           "月之暗面Kimi,OpenAI兼容接口",
+		Fields:         compatibleFields(false),
+		DefaultBaseURL: "https://api.moonshot.cn/v1",
+		New:            newOpenAIChatModel,
+		ClientOptions:  compatibleClientOptions,
+		ListModels:     true,
+	})
+	RegisterProvider(&Provider{
+		Name:          OpenAICompatible,
+		Desc:          "通用的OpenAI兼容接口,可配置对话接口路径与额外请求头,适配其他模型厂商或私有化部署",
+		Fields:        compatibleFields(true),
+		New:           newOpenAIChatModel,
+		ClientOptions: compatibleClientOptions,
+	})
+}
+
+// compatibleFields OpenAI兼容接口的配置项
+func compatibleFields(baseURLRequired bool) []ProviderField {
+	return []ProviderField{
+		{Name: "BaseURL", Required: baseURLRequired, Comment: "API链接,需包含版本路径"},
+		{Name: "APIKey", Comment: "API秘钥"},
+		{Name: "Model", Required: true, Comment: "模型名称"},
+		{Name: "Path", Comment: "对话接口路径,为空时使用/chat/completions"},
+		{Name: "Headers", Comment: "额外请求头"},
+	}
+}
+
+// newOpenAIChatModel 基于OpenAI协议创建eino的cm,Azure按部署名调用
+func newOpenAIChatModel(ctx context.Context, opt *Option, co *CallOption, _ *model.Options) (UChatModelIf, error) {
+	conf := &eopenai.ChatModelConfig{
+		APIKey:               opt.APIKey,
+		Timeout:              time.Duration(opt.Timeout) * time.Second,
+		HTTPClient:           newHTTPClient(opt),
+		ByAzure:              false,
+		AzureModelMapperFunc: nil,
+		BaseURL:              opt.BaseURL,
+		APIVersion:           "",
+		Model:                opt.Model,
+		MaxTokens:            nil,
+		Temperature:          nil,
+		TopP:                 nil,
+		Stop:                 nil,
+		PresencePenalty:      co.PresencePenalty,
+		ResponseFormat:       nil,
+		Seed:                 co.Seed,
+		FrequencyPenalty:     co.FrequencyPenalty,
+		LogitBias:            nil,
+		User:                 nil,
+		ExtraFields:          nil,
+	}
+	if strings.EqualFold(opt.Provider, string(Azure)) {
+		conf.ByAzure = true
+		conf.APIVersion = opt.APIVersion
+		deployments := opt.AzureDeployments
+		if len(deployments) > 0 {
+			conf.AzureModelMapperFunc = func(model string) string {
+				if d, ok := deployments[model]; ok {
+					return d
+				}
+				return model
+			}
+		}
+	}
+	if co.ResponseFormat != "" {
+		conf.ResponseFormat = &aclopenai.ChatCompletionResponseFormat{
+			Type: aclopenai.ChatCompletionResponseFormatType(co.ResponseFormat),
+		}
+	}
+	return eopenai.NewChatModel(ctx, conf)
+}
+
+// compatibleClientOptions OpenAI兼容接口的V1客户端参数,API链接已包含版本路径
+func compatibleClientOptions(opt *Option) []option.RequestOption {
+	opts := []option.RequestOption{
+		option.WithBaseURL(opt.BaseURL),
+		option.WithAPIKey(opt.APIKey),
+	}
+	if opt.Organization != "" {
+		opts = append(opts, option.WithOrganization(opt.Organization))
+	}
+	for k, v := range opt.Headers {
+		opts = append(opts, option.WithHeader(k, v))
+	}
+	return opts
+}
+
+// openAIClientOptions OpenAI的V1客户端参数,兼容未包含版本路径的旧配置,按 API链接+/v1 连接
+func openAIClientOptions(opt *Option) []option.RequestOption {
+	o := *opt
+	o.BaseURL = utils.WithVersionPath(o.BaseURL)
+	return compatibleClientOptions(&o)
+}
+
+// azureClientOptions Azure OpenAI的V1客户端参数,使用api-key请求头鉴权
+func azureClientOptions(opt *Option) []option.RequestOption {
+	return []option.RequestOption{
+		option.WithBaseURL(strings.TrimSuffix(opt.BaseURL, "/") + "/openai/"),
+		option.WithHeader("api-key", opt.APIKey),
+		option.WithQuery("api-version", opt.APIVersion),
+	}
+}
+
+// newHTTPClient 配置了对话接口路径或额外请求头时,创建改写请求的http客户端,否则返回nil使用默认客户端
+func newHTTPClient(opt *Option) *http.Client {
+	if opt.Path == "" && len(opt.Headers) == 0 {
+		return nil
+	}
+	return &http.Client{
+		Timeout: time.Duration(opt.Timeout) * time.Second,
+		Transport: &rewriteTransport{
+			base:    http.DefaultTransport,
+			path:    opt.Path,
+			headers: opt.Headers,
+		},
+	}
+}
+
+// rewriteTransport 替换对话接口路径并添加额外请求头
+type rewriteTransport struct {
+	base    http.RoundTripper
+	path    string
+	headers map[string]string
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	const chatPath = "/chat/completions"
+	if t.path != "" && strings.HasSuffix(req.URL.Path, chatPath) {
+		req.URL.Path = strings.TrimSuffix(req.URL.Path, chatPath) + "/" + strings.TrimPrefix(t.path, "/")
+		req.URL.RawPath = ""
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,193 @@
+package uaicharmodel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/cohesion-org/deepseek-go"
+	goopenai "github.com/meguminnnnnnnnn/go-openai"
+	"github.com/ollama/ollama/api"
+	"github.com/openai/openai-go"
+)
+
+// ErrModelUnavailable 所有候选模型均调用失败或处于熔断状态
+var ErrModelUnavailable = errors.New("model unavailable")
+
+// ResilientOption 模型调用的重试、故障转移与熔断配置
+type ResilientOption struct {
+	Retries          int      `comment:"单个模型遇到临时错误(超时、限流、5xx等)时的重试次数"`
+	Backoff          int      `comment:"重试的初始间隔,单位毫秒,每次重试翻倍"`
+	Fallback         []string `comment:"故障转移链,所选模型失败后依次尝试的模型名称,如 deepseek-chat → qwen2.5:7b"`
+	BreakerThreshold int      `comment:"连续失败多少次后熔断,将模型移出轮换,0为不熔断"`
+	BreakerCooldown  int      `comment:"熔断冷却时间,单位秒,冷却结束后放行一次请求试探是否恢复"`
+}
+
+func NewDefaultResilientOption() *ResilientOption {
+	return &ResilientOption{
+		Retries:          2,
+		Backoff:          500,
+		Fallback:         nil,
+		BreakerThreshold: 3,
+		BreakerCooldown:  30,
+	}
+}
+
+// isTransient 判断错误是否可以重试:网络错误、超时、限流(429)与服务端错误(5xx)
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	code := statusCode(err)
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// statusCode 上游接口返回的HTTP状态码,不是接口错误时返回0
+func statusCode(err error) int {
+	var oe *goopenai.APIError
+	if errors.As(err, &oe) {
+		return oe.HTTPStatusCode
+	}
+	var re *goopenai.RequestError
+	if errors.As(err, &re) {
+		return re.HTTPStatusCode
+	}
+	var ve *openai.Error
+	if errors.As(err, &ve) {
+		return ve.StatusCode
+	}
+	var se api.StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode
+	}
+	var de deepseek.APIError
+	if errors.As(err, &de) {
+		return de.StatusCode
+	}
+	return 0
+}
+
+// candidates 本次请求的候选模型:所选模型在前,其后为故障转移链中的其他模型
+func (ms *UChatModels) candidates(opts ...model.Option) ([]string, error) {
+	ro := model.GetImplSpecificOptions(&RouteOption{}, opts...)
+	name := ro.Model
+	if name == "" {
+		name = ms.def
+	}
+	if _, ok := ms.models[name]; !ok {
+		return nil, fmt.Errorf("model %s is not configured", name)
+	}
+	names := []string{name}
+	for _, v := range ms.ro.Fallback {
+		if v != name {
+			names = append(names, v)
+		}
+	}
+	return names, nil
+}
+
+// call 按候选顺序调用模型,临时错误按退避间隔重试,失败后转移到下一个模型,熔断中的模型跳过
+func (ms *UChatModels) call(ctx context.Context, opts []model.Option, fn func(cm model.ToolCallingChatModel) error) error {
+	names, err := ms.candidates(opts...)
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	for _, name := range names {
+		b := ms.breakers[name]
+		if !b.allow() {
+			continue
+		}
+		next, err := ms.attempt(ctx, name, b, fn)
+		if !next {
+			return err
+		}
+		lastErr = err
+		if ms.log != nil {
+			ms.log.Warnf("模型%s调用失败,尝试下一个模型: %s", name, err.Error())
+		}
+	}
+	if lastErr == nil {
+		return fmt.Errorf("%w: all models are out of rotation", ErrModelUnavailable)
+	}
+	return fmt.Errorf("%w: %w", ErrModelUnavailable, lastErr)
+}
+
+// attempt 调用单个模型,临时错误按退避间隔重试;next为true时表示模型调用失败,应转移到下一个模型
+// 调用方取消时既不计成功也不计失败,任何情况下退出时都归还熔断器的试探名额
+func (ms *UChatModels) attempt(ctx context.Context, name string, b *breaker, fn func(cm model.ToolCallingChatModel) error) (next bool, err error) {
+	defer b.release()
+	cm, err := ms.bind(name)
+	if err != nil {
+		return false, err
+	}
+	for i := 0; i <= ms.ro.Retries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return false, ctx.Err()
+			case <-time.After(time.Duration(ms.ro.Backoff) * time.Millisecond << (i - 1)):
+			}
+		}
+		err = fn(cm)
+		if err == nil {
+			b.success()
+			return false, nil
+		}
+		if ctx.Err() != nil {
+			return false, err
+		}
+		if !isTransient(err) {
+			break
+		}
+	}
+	b.failure()
+	return true, err
+}
+
+// peekStream 读取流的第一个分片,上游在输出前失败时返回错误以便重试或故障转移,成功时返回包含该分片的完整流
+func peekStream(sr *schema.StreamReader[*schema.Message]) (*schema.StreamReader[*schema.Message], error) {
+	first, err := sr.Recv()
+	if err != nil {
+		sr.Close()
+		if errors.Is(err, io.EOF) {
+			return schema.StreamReaderFromArray([]*schema.Message{}), nil
+		}
+		return nil, err
+	}
+	out, w := schema.Pipe[*schema.Message](1)
+	go func() {
+		defer w.Close()
+		defer sr.Close()
+		if closed := w.Send(first, nil); closed {
+			return
+		}
+		for {
+			chunk, err := sr.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if closed := w.Send(chunk, err); closed || err != nil {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
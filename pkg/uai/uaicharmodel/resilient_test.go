@@ -0,0 +1,99 @@
+package uaicharmodel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	goopenai "github.com/meguminnnnnnnnn/go-openai"
+	"github.com/ollama/ollama/api"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"canceled", fmt.Errorf("failed: %w", context.Canceled), false},
+		{"deadline", fmt.Errorf("failed: %w", context.DeadlineExceeded), true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"connection refused", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), true},
+		{"openai 429", fmt.Errorf("failed to create chat completion: %w", &goopenai.APIError{HTTPStatusCode: 429}), true},
+		{"openai 503", &goopenai.RequestError{HTTPStatusCode: 503}, true},
+		{"openai 400", &goopenai.APIError{HTTPStatusCode: 400, Message: "max_tokens 500 is too large"}, false},
+		{"openai 401", &goopenai.APIError{HTTPStatusCode: 401}, false},
+		{"ollama 502", fmt.Errorf("error during Chat request: %w", api.StatusError{StatusCode: 502}), true},
+		{"ollama 404", api.StatusError{StatusCode: 404, ErrorMessage: "model not found"}, false},
+		{"plain error mentions 500", errors.New("context length 5000 exceeded"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.err); got != tt.want {
+				t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCall(t *testing.T) {
+	errBusy := &goopenai.APIError{HTTPStatusCode: 503}
+	errBad := &goopenai.APIError{HTTPStatusCode: 400}
+	tests := []struct {
+		name     string
+		results  []error // 依次调用返回的结果,所选模型a重试1次,失败后转移到b
+		cancel   bool    // 调用时取消上下文
+		wantErr  bool
+		wantFail map[string]int
+	}{
+		{"success", []error{nil}, false, false, map[string]int{}},
+		{"retry then success", []error{errBusy, nil}, false, false, map[string]int{}},
+		{"fallback after retries", []error{errBusy, errBusy, nil}, false, false, map[string]int{"a": 1}},
+		{"no retry on client error", []error{errBad, nil}, false, false, map[string]int{"a": 1}},
+		{"all failed", []error{errBad, errBad}, false, true, map[string]int{"a": 1, "b": 1}},
+		{"canceled is neutral", []error{context.Canceled}, true, true, map[string]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := &UChatModels{
+				models:   map[string]If{"a": nil, "b": nil},
+				breakers: map[string]*breaker{"a": newBreaker(3, time.Hour), "b": newBreaker(3, time.Hour)},
+				def:      "a",
+				ro:       &ResilientOption{Retries: 1, Fallback: []string{"b"}},
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			n := 0
+			err := ms.call(ctx, nil, func(cm model.ToolCallingChatModel) error {
+				if n >= len(tt.results) {
+					t.Fatalf("unexpected call %d", n+1)
+				}
+				n++
+				if tt.cancel {
+					cancel()
+				}
+				return tt.results[n-1]
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("call() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if n != len(tt.results) {
+				t.Errorf("calls = %d, want %d", n, len(tt.results))
+			}
+			for name, b := range ms.breakers {
+				if b.failures != tt.wantFail[name] {
+					t.Errorf("breaker %s failures = %d, want %d", name, b.failures, tt.wantFail[name])
+				}
+				if b.probing {
+					t.Errorf("breaker %s is still probing", name)
+				}
+			}
+		})
+	}
+}
@@ -3,38 +3,22 @@ package uaicharmodel
 import (
 	"context"
 	"errors"
-	edeepseek "github.com/cloudwego/eino-ext/components/model/deepseek"
-	eollama "github.com/cloudwego/eino-ext/components/model/ollama"
-	eopenai "github.com/cloudwego/eino-ext/components/model/openai"
+	"fmt"
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/schema"
 	"github.com/openai/openai-go"
-	"github.com/openai/openai-go/option"
 	"sync"
-	"time"
 )
 
 func New(ctx context.Context, opt *Option) If {
-	openaiClient := openai.NewClient(
-		option.WithBaseURL(opt.BaseURL+"/v1"),     // 设置 OpenAI API 的基础 URL
-		option.WithAPIKey(opt.APIKey),             // 设置 OpenAI API 的密钥
-		option.WithOrganization(opt.Organization), // 设置 OpenAI API 的调用组织 ID
-	)
-	// 测试连接是否正常
-	_, err := openaiClient.Models.List(ctx)
-	if err != nil {
-		panic(err)
-	}
-
 	// 创建一个 UChatModel 实例
 	uCM := &UChatModel{
-		mu:     sync.RWMutex{},
-		ctx:    ctx,
-		opt:    opt,
-		openai: &openaiClient,
+		mu:  sync.RWMutex{},
+		ctx: ctx,
+		opt: opt,
 	}
-	// 设置默认Eino的cm
-	err = uCM.SetProvider(ModelProvider(opt.Provider))
+	// 按配置的名称选择模型提供商,设置默认Eino的cm
+	err := uCM.SetProvider(ModelProvider(opt.Provider))
 	if err != nil {
 		panic(err)
 	}
@@ -42,136 +26,142 @@ func New(ctx context.Context, opt *Option) If {
 }
 
 type UChatModel struct {
-	mu     sync.RWMutex    // 读写锁
-	ctx    context.Context // 上下文
-	opt    *Option         // 配置参数
-	openai *openai.Client  // openai 客户端
-	einoCm UChatModelIf    // eino 客户端
+	mu       sync.RWMutex       // 读写锁
+	ctx      context.Context    // 上下文
+	opt      *Option            // 配置参数
+	openai   *openai.Client     // openai 客户端
+	provider ModelProvider      // 当前模型提供者
+	prov     *Provider          // 当前模型提供者的注册信息
+	provOpt  *Option            // 补全默认值后的当前模型提供者配置
+	einoCm   UChatModelIf       // eino 客户端
+	tools    []*schema.ToolInfo // 已绑定的工具,按请求派生cm时需要重新绑定
 }
 
 func (m *UChatModel) BindTools(tools []*schema.ToolInfo) error {
-	return m.einoCm.BindTools(tools)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	err := m.einoCm.BindTools(tools)
+	if err != nil {
+		return err
+	}
+	m.tools = tools
+	return nil
 }
 
 func (m *UChatModel) V1() *openai.Client {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.openai
 }
 
 func (m *UChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	if opts != nil {
-		return m.einoCm.Generate(ctx, input, opts...)
-	} else {
-		return m.einoCm.Generate(ctx, input)
+	cm, err := m.callModel(opts...)
+	if err != nil {
+		return nil, err
 	}
+	return cm.Generate(ctx, input, opts...)
 }
 
 func (m *UChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.einoCm.Stream(ctx, input, opts...)
+	cm, err := m.callModel(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return cm.Stream(ctx, input, opts...)
 }
 
+// WithTools 返回绑定了工具的新实例,仍由UChatModel包装,保证单次请求的扩展参数生效
 func (m *UChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.einoCm.WithTools(tools)
+	cm, err := m.einoCm.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	einoCm, ok := cm.(UChatModelIf)
+	if !ok {
+		return nil, errors.New("model does not implement UChatModelIf")
+	}
+	return &UChatModel{
+		mu:       sync.RWMutex{},
+		ctx:      m.ctx,
+		opt:      m.opt,
+		openai:   m.openai,
+		provider: m.provider,
+		prov:     m.prov,
+		provOpt:  m.provOpt,
+		einoCm:   einoCm,
+		tools:    tools,
+	}, nil
+}
+
+// Ping 测试连接是否正常,模型提供商不支持模型列表接口时不测试
+func (m *UChatModel) Ping(ctx context.Context) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.prov.ListModels {
+		return nil
+	}
+	_, err := m.openai.Models.List(ctx)
+	return err
+}
+
+// GetType 组件类型,用于回调日志
+func (m *UChatModel) GetType() string {
+	return string(m.provider)
+}
+
+// IsCallbacksEnabled 回调由内部的eino cm触发,避免重复触发
+func (m *UChatModel) IsCallbacksEnabled() bool {
+	return true
 }
 
-// SetProvider 设置模型提供者,主要用于切换eino的cm
+// callModel 选择本次请求使用的cm,请求携带了扩展参数时,按参数派生一个临时的cm
+func (m *UChatModel) callModel(opts ...model.Option) (model.BaseChatModel, error) {
+	co := model.GetImplSpecificOptions(&CallOption{}, opts...)
+	common := model.GetCommonOptions(nil, opts...)
+	// 部分模型提供商(如Ollama)不支持按请求设置 max_tokens,需要派生
+	if co.isEmpty() && !(m.prov.DeriveOnMaxTokens && common.MaxTokens != nil) {
+		return m.einoCm, nil
+	}
+	cm, err := m.prov.New(m.ctx, m.provOpt, co, common)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.tools) == 0 {
+		return cm, nil
+	}
+	return cm.WithTools(m.tools)
+}
+
+// SetProvider 设置模型提供者,从注册表中按名称选择,切换eino的cm与V1客户端
 func (m *UChatModel) SetProvider(provider ModelProvider) error {
-	// 检查提供的模型提供者是否在支持列表中
-	_, ok := SupportModelProvider[provider]
+	p, ok := GetProvider(provider)
 	if !ok {
-		// 如果不在支持列表中，返回错误
-		return errors.New("unsupported model provider")
+		return fmt.Errorf("unsupported model provider: %s", provider)
 	}
+	opt, err := p.resolve(m.opt)
+	if err != nil {
+		return err
+	}
+	opt.Provider = string(p.Name)
+	c, err := p.New(m.ctx, opt, &CallOption{}, &model.Options{})
+	if err != nil {
+		return err
+	}
+	client := openai.NewClient(p.clientOptions(opt)...)
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	// 根据提供的模型提供者，执行相应的操作
-	switch provider {
-	case OpenAI:
-		{
-			// 如果是OpenAI，执行相应的操作
-			c, err := eopenai.NewChatModel(m.ctx, &eopenai.ChatModelConfig{
-				APIKey:               m.opt.APIKey,
-				Timeout:              time.Duration(m.opt.Timeout) * time.Second,
-				HTTPClient:           nil,
-				ByAzure:              false,
-				AzureModelMapperFunc: nil,
-				BaseURL:              m.opt.BaseURL,
-				APIVersion:           "",
-				Model:                m.opt.Model,
-				MaxTokens:            nil,
-				Temperature:          nil,
-				TopP:                 nil,
-				Stop:                 nil,
-				PresencePenalty:      nil,
-				ResponseFormat:       nil,
-				Seed:                 nil,
-				FrequencyPenalty:     nil,
-				LogitBias:            nil,
-				User:                 nil,
-				ExtraFields:          nil,
-			})
-			if err != nil {
-				return err
-			}
-			m.einoCm = c
-			break
-		}
-	case Ollama:
-		{
-			// 如果是Ollama，执行相应的操作
-			c, err := eollama.NewChatModel(m.ctx, &eollama.ChatModelConfig{
-				BaseURL:    m.opt.BaseURL,
-				Timeout:    time.Duration(m.opt.Timeout) * time.Second,
-				HTTPClient: nil,
-				Model:      m.opt.Model,
-				Format:     nil,
-				KeepAlive:  nil,
-				Options:    nil,
-			})
-			if err != nil {
-				return err
-			}
-			m.einoCm = c
-			break
-		}
-	case DeepSeek:
-		{
-			// 如果是DeepSeek，执行相应的操作
-			// 如果是Ollama，执行相应的操作
-			c, err := edeepseek.NewChatModel(m.ctx, &edeepseek.ChatModelConfig{
-				APIKey:             m.opt.APIKey,
-				Timeout:            time.Duration(m.opt.Timeout) * time.Second,
-				HTTPClient:         nil,
-				BaseURL:            m.opt.BaseURL,
-				Path:               "",
-				Model:              m.opt.Model,
-				MaxTokens:          0,
-				Temperature:        0,
-				TopP:               0,
-				Stop:               nil,
-				PresencePenalty:    0,
-				ResponseFormatType: "",
-				FrequencyPenalty:   0,
-				LogProbs:           false,
-				TopLogProbs:        0,
-			})
-			if err != nil {
-				return err
-			}
-			m.einoCm = c
-			break
-		}
-	default:
-		{
-			// 如果提供的模型提供者不在支持列表中，返回错误
-			return errors.New("unsupported model provider")
-		}
-	}
+	m.einoCm = c
+	m.openai = &client
+	m.provider = p.Name
+	m.prov = p
+	m.provOpt = opt
+	m.tools = nil
 	// 返回nil，表示设置成功
 	return nil
 }
@@ -9,5 +9,7 @@ import (
 type If interface {
 	commif.MonitorIf
 	Initialize(ctx context.Context) error
-	DToEinoTools(ctx context.Context) []tool.BaseTool
+	DToEinoTools(ctx context.Context) ([]tool.BaseTool, error)
+	// OnToolsChanged 注册服务端工具列表变更(tools/list_changed)的回调
+	OnToolsChanged(f func())
 }
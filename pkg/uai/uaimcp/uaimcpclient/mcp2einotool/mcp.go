@@ -122,16 +122,16 @@ func (m *toolHelper) StreamableRun(ctx context.Context, argumentsInJSON string,
 	// 创建一个goroutine，用于调用工具
 	go func() {
 		defer w.Close()
+		defer c()
 		select {
 		case <-lctx.Done():
 			{
-				c()
 				return
 			}
 		default:
 			{
-				// 调用工具
-				result, err := m.cli.CallTool(ctx, mcp.CallToolRequest{
+				// 调用工具,请求取消或超时时中止
+				result, err := m.cli.CallTool(lctx, mcp.CallToolRequest{
 					Request: mcp.Request{
 						Method: "tools/call",
 					},
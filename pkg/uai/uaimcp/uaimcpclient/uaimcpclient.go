@@ -2,6 +2,7 @@ package uaimcpclient
 
 import (
 	"context"
+	"fmt"
 	"github.com/freedqo/fmc-go-agent/pkg/uai/uaimcp"
 	"github.com/freedqo/fmc-go-agent/pkg/uai/uaimcp/uaimcpclient/mcp2einotool"
 
@@ -161,12 +162,22 @@ func (u *UAiMcpClient) Initialize(ctx context.Context) error {
 	return nil
 }
 
-func (u *UAiMcpClient) DToEinoTools(ctx context.Context) []tool.BaseTool {
+func (u *UAiMcpClient) DToEinoTools(ctx context.Context) ([]tool.BaseTool, error) {
 	baseTools, err := mcp2einotool.GetTools(ctx, &mcp2einotool.Config{Cli: u.client})
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("获取MCP服务%s的工具列表失败: %w", u.name, err)
 	}
-	return baseTools
+	return baseTools, nil
+}
+
+// OnToolsChanged 工具列表变更时回调
+// 通知在MCP连接的读取循环中处理,回调中请求MCP服务(如ListTools)会等待同一个读取循环而死锁,因此回调在新的goroutine中执行
+func (u *UAiMcpClient) OnToolsChanged(f func()) {
+	u.client.OnNotification(func(notification mcpgo.JSONRPCNotification) {
+		if notification.Method == mcpgo.MethodNotificationToolsListChanged {
+			go f()
+		}
+	})
 }
 
 var _ If = &UAiMcpClient{}
@@ -0,0 +1,249 @@
+package uaivectordb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino-ext/components/document/loader/file"
+)
+
+const (
+	// CollectionKey 知识库集合(hash,集合名称:集合JSON)
+	CollectionKey = "eino:collection"
+	// DefaultCollection 默认集合,无需创建,所有用户可检索
+	DefaultCollection = "default"
+	// PublicOwner 公开集合分片的所有者标签
+	PublicOwner = "public"
+	// CollectionsDir 知识库目录下存放命名集合的子目录,文件路径为 CollectionsDir/集合名称/...,其他文件属于默认集合
+	CollectionsDir = "collections"
+)
+
+var (
+	// ErrCollectionNotFound 集合不存在
+	ErrCollectionNotFound = errors.New("collection not found")
+	// ErrCollectionExists 集合已存在
+	ErrCollectionExists = errors.New("collection already exists")
+)
+
+// collectionName 集合名称只允许字母、数字、下划线与中划线
+var collectionName = regexp.MustCompile(`^[\p{L}\p{N}_-]{1,64}$`)
+
+// Collection 知识库集合,分片以集合与所有者为TAG建立索引,检索时按集合与用户过滤
+type Collection struct {
+	Name        string `json:"name"`        // 集合名称
+	Owner       string `json:"owner"`       // 所有者用户Id,为空时为公开集合
+	Description string `json:"description"` // 集合描述
+	Created     int64  `json:"created"`     // 创建时间,unix秒
+}
+
+// CollectionOf 按文件相对知识库目录的路径确定所属集合
+func CollectionOf(rel string) string {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if len(parts) > 2 && parts[0] == CollectionsDir {
+		return parts[1]
+	}
+	return DefaultCollection
+}
+
+// CollectionPath 集合在知识库目录下的相对路径,默认集合为知识库目录本身
+func CollectionPath(name string) string {
+	if name == "" || name == DefaultCollection {
+		return ""
+	}
+	return filepath.Join(CollectionsDir, name)
+}
+
+// CreateCollection 创建集合,名称已存在时返回ErrCollectionExists
+func (i *IRVector) CreateCollection(ctx context.Context, c *Collection) error {
+	if !collectionName.MatchString(c.Name) {
+		return fmt.Errorf("invalid collection name: %q", c.Name)
+	}
+	if c.Name == DefaultCollection {
+		return fmt.Errorf("%w: %s", ErrCollectionExists, c.Name)
+	}
+	c.Created = time.Now().Unix()
+	b, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal collection %s: %w", c.Name, err)
+	}
+	ok, err := i.store.HSetNX(ctx, CollectionKey, c.Name, string(b))
+	if err != nil {
+		return fmt.Errorf("failed to create collection %s: %w", c.Name, err)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrCollectionExists, c.Name)
+	}
+	return nil
+}
+
+// Collection 按名称获取集合,不存在时返回ErrCollectionNotFound
+func (i *IRVector) Collection(ctx context.Context, name string) (*Collection, error) {
+	if name == "" || name == DefaultCollection {
+		return &Collection{Name: DefaultCollection, Description: "默认集合"}, nil
+	}
+	raw, err := i.store.HGet(ctx, CollectionKey, name)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrCollectionNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection %s: %w", name, err)
+	}
+	c := &Collection{}
+	if err = json.Unmarshal([]byte(raw), c); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal collection %s: %w", name, err)
+	}
+	return c, nil
+}
+
+// Collections 所有集合,默认集合在前,其余按名称排序
+func (i *IRVector) Collections(ctx context.Context) ([]*Collection, error) {
+	all, err := i.store.HGetAll(ctx, CollectionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collections: %w", err)
+	}
+	res := make([]*Collection, 0, len(all)+1)
+	for name, raw := range all {
+		c := &Collection{}
+		if err = json.Unmarshal([]byte(raw), c); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal collection %s: %w", name, err)
+		}
+		res = append(res, c)
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].Name < res[b].Name
+	})
+	def, _ := i.Collection(ctx, DefaultCollection)
+	return append([]*Collection{def}, res...), nil
+}
+
+// DeleteCollection 删除集合及其所有文件的分片,返回删除的分片数量;默认集合不能删除
+func (i *IRVector) DeleteCollection(ctx context.Context, name string) (chunks int, err error) {
+	if name == "" || name == DefaultCollection {
+		return 0, errors.New("default collection cannot be deleted")
+	}
+	if _, err = i.Collection(ctx, name); err != nil {
+		return 0, err
+	}
+	sources, err := i.Sources(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range sources {
+		if m.Collection != name {
+			continue
+		}
+		n, err := i.RemoveFile(ctx, m.MD5)
+		if err != nil {
+			return chunks, err
+		}
+		chunks += n
+	}
+	err = i.store.Update(ctx, func(tx Tx) {
+		tx.HDel(CollectionKey, name)
+	})
+	if err != nil {
+		return chunks, fmt.Errorf("failed to delete collection %s: %w", name, err)
+	}
+	return chunks, nil
+}
+
+// MetaKeyFileID 分片元数据中来源文件的Id(文件内容MD5),与知识库文件列表中的文件Id一致
+const MetaKeyFileID = "_file_id"
+
+// chunkScope 索引时分片所属的集合、所有者与来源文件,经ctx传递给分片的哈希转换
+type chunkScope struct {
+	collection string
+	owner      string
+	fileId     string
+}
+
+type chunkScopeKey struct{}
+
+// withChunkScope 设置本次索引的分片所属集合与来源文件Id
+func withChunkScope(ctx context.Context, c *Collection, fileId string) context.Context {
+	owner := c.Owner
+	if owner == "" {
+		owner = PublicOwner
+	}
+	return context.WithValue(ctx, chunkScopeKey{}, &chunkScope{collection: c.Name, owner: owner, fileId: fileId})
+}
+
+// chunkFileID 本次索引的来源文件Id,未设置时为空
+func chunkFileID(ctx context.Context) string {
+	if scope, ok := ctx.Value(chunkScopeKey{}).(*chunkScope); ok {
+		return scope.fileId
+	}
+	return ""
+}
+
+// chunkTags 分片的TAG字段:集合、所有者、文件类型与markdown标题路径
+func chunkTags(ctx context.Context, meta map[string]any) map[string]string {
+	scope, ok := ctx.Value(chunkScopeKey{}).(*chunkScope)
+	if !ok {
+		scope = &chunkScope{collection: DefaultCollection, owner: PublicOwner}
+	}
+	ext, _ := meta[file.MetaKeyExtension].(string)
+	headers := make([]string, 0, len(headerKeys))
+	for _, k := range headerKeys {
+		if v, ok := meta[k].(string); ok && v != "" {
+			// 标题路径整体作为一个TAG值,标题中的TAG分隔符 | 替换为空格
+			headers = append(headers, strings.ReplaceAll(strings.TrimSpace(v), "|", " "))
+		}
+	}
+	return map[string]string{
+		CollectionField: scope.collection,
+		OwnerField:      scope.owner,
+		FileTypeField:   strings.ToLower(strings.TrimPrefix(ext, ".")),
+		HeaderField:     strings.Join(headers, " > "),
+	}
+}
+
+// SchemaKey 分片结构的版本,用于判断是否已为旧分片补充TAG字段
+const SchemaKey = "eino:schema"
+
+// schemaVersion 当前分片结构的版本,1为带有TAG字段
+const schemaVersion = 1
+
+// migrateTags 为TAG字段出现之前索引的分片补充TAG字段,旧分片属于默认集合
+func (i *IRVector) migrateTags(ctx context.Context) error {
+	raw, err := i.store.Get(ctx, SchemaKey)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+	if v, _ := strconv.Atoi(raw); v >= schemaVersion {
+		return nil
+	}
+	tags := make(map[string]map[string]string)
+	err = i.store.Scan(ctx, func(c *Chunk) error {
+		if _, ok := c.Tags[CollectionField]; !ok {
+			tags[c.ID] = chunkTags(ctx, c.MetaData)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for id, t := range tags {
+		if err = i.store.SetTags(ctx, id, t); err != nil {
+			return fmt.Errorf("failed to migrate chunk %s: %w", id, err)
+		}
+	}
+	err = i.store.Update(ctx, func(tx Tx) {
+		tx.Set(SchemaKey, strconv.Itoa(schemaVersion))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+	if len(tags) > 0 {
+		i.log.Infow("Migrated chunks to default collection", "number of parts", len(tags))
+	}
+	return nil
+}
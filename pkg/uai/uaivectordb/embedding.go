@@ -1,23 +1,221 @@
 package uaivectordb
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/cloudwego/eino-ext/components/embedding/openai"
 	"github.com/cloudwego/eino/components/embedding"
+	"github.com/freedqo/fmc-go-agent/pkg/utils"
+	"io"
+	"net/http"
+	"strings"
 	"time"
 )
 
+const (
+	// EmbeddingOllama Ollama原生接口 /api/embed,API链接为Ollama服务地址,如 http://127.0.0.1:11434
+	EmbeddingOllama = "ollama"
+	// EmbeddingOpenAI OpenAI,API链接为空时为 https://api.openai.com/v1,未包含版本路径时追加/v1
+	EmbeddingOpenAI = "openai"
+	// EmbeddingCompatible 其他OpenAI兼容接口,如vLLM、Xinference、通义千问等,API链接需包含版本路径
+	EmbeddingCompatible = "compatible"
+)
+
+// embeddingAliases 旧配置中的模型提供商,旧版本统一按 API链接+/v1 调用OpenAI兼容接口
+var embeddingAliases = map[string]string{
+	"deepseek": EmbeddingOpenAI,
+}
+
+// EmbeddingProvider 规范化配置中的模型提供商:不区分大小写,旧配置的提供商映射为新的名称
+func EmbeddingProvider(provider string) string {
+	p := strings.ToLower(strings.TrimSpace(provider))
+	if v, ok := embeddingAliases[p]; ok {
+		return v
+	}
+	return p
+}
+
+// EmbeddingKey 建立向量索引的嵌入模型(EmbeddingInfo的JSON)
+const EmbeddingKey = "eino:embedding"
+
+// ErrEmbeddingMismatch 嵌入模型或向量维度与已有索引不一致
+var ErrEmbeddingMismatch = errors.New("embedding model mismatch")
+
+// ErrEmbeddingUnavailable 嵌入模型不可用,尚未探测到向量维度
+var ErrEmbeddingUnavailable = errors.New("embedding model unavailable")
+
+// EmbeddingInfo 嵌入模型与向量维度
+type EmbeddingInfo struct {
+	Provider  string `json:"provider"`  // 模型提供商
+	Model     string `json:"model"`     // 模型名称
+	Dimension int    `json:"dimension"` // 向量维度
+}
+
+// newEmbedding 按模型提供商创建嵌入模型
 func newEmbedding(ctx context.Context, opt *Option) (eb embedding.Embedder, err error) {
-	// TODO Modify component configuration here.
-	config := &openai.EmbeddingConfig{
-		BaseURL: opt.IRVModel.BaseURL + "/v1",
-		APIKey:  opt.IRVModel.APIKey,
-		Model:   opt.IRVModel.Model,
-		Timeout: time.Duration(opt.IRVModel.Timeout) * time.Second,
-	}
-	eb, err = openai.NewEmbedder(ctx, config)
+	m := opt.IRVModel
+	timeout := time.Duration(m.Timeout) * time.Second
+	provider := EmbeddingProvider(m.Provider)
+	switch provider {
+	case "", EmbeddingOllama:
+		if m.BaseURL == "" {
+			return nil, errors.New("ollama embedding requires BaseURL")
+		}
+		return &ollamaEmbedder{
+			baseURL: strings.TrimSuffix(m.BaseURL, "/"),
+			model:   m.Model,
+			client:  &http.Client{Timeout: timeout},
+		}, nil
+	case EmbeddingOpenAI, EmbeddingCompatible:
+		baseURL := m.BaseURL
+		switch {
+		case baseURL == "" && provider != EmbeddingOpenAI:
+			return nil, errors.New("openai compatible embedding requires BaseURL")
+		case baseURL == "":
+			baseURL = "https://api.openai.com/v1"
+		case provider == EmbeddingOpenAI:
+			// 兼容旧配置只填写服务地址的情况
+			baseURL = utils.WithVersionPath(baseURL)
+		}
+		return openai.NewEmbedder(ctx, &openai.EmbeddingConfig{
+			BaseURL: baseURL,
+			APIKey:  m.APIKey,
+			Model:   m.Model,
+			Timeout: timeout,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported embedding provider: %s", m.Provider)
+	}
+}
+
+// probeDimension 调用嵌入模型探测向量维度
+func probeDimension(ctx context.Context, eb embedding.Embedder) (int, error) {
+	vectors, err := eb.EmbedStrings(ctx, []string{"dimension probe"})
+	if err != nil {
+		return 0, fmt.Errorf("failed to probe embedding dimension: %w", err)
+	}
+	if len(vectors) != 1 || len(vectors[0]) == 0 {
+		return 0, errors.New("failed to probe embedding dimension: empty vector")
+	}
+	return len(vectors[0]), nil
+}
+
+// ollamaEmbedder Ollama原生嵌入接口
+type ollamaEmbedder struct {
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+type ollamaEmbedReq struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type ollamaEmbedResp struct {
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+func (o *ollamaEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	model := o.model
+	if co := embedding.GetCommonOptions(&embedding.Options{}, opts...); co.Model != nil && *co.Model != "" {
+		model = *co.Model
+	}
+	body, err := json.Marshal(&ollamaEmbedReq{Model: model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/api/embed", bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
-	return eb, nil
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama embed request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("ollama embed request failed: %s %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	res := &ollamaEmbedResp{}
+	if err = json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return nil, fmt.Errorf("failed to decode ollama embed response: %w", err)
+	}
+	if len(res.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("invalid return length of vector, got=%d, expected=%d", len(res.Embeddings), len(texts))
+	}
+	return res.Embeddings, nil
+}
+
+func (o *ollamaEmbedder) GetType() string {
+	return "OllamaEmbedder"
+}
+
+// checkEmbedding 校验已有索引的嵌入模型与向量维度,不一致时按配置重建索引,否则返回ErrEmbeddingMismatch
+func (i *IRVector) checkEmbedding(ctx context.Context) error {
+	cur := i.emb.Load()
+	var old *EmbeddingInfo
+	raw, err := i.store.Get(ctx, EmbeddingKey)
+	switch {
+	case err == nil:
+		old = &EmbeddingInfo{}
+		if err = json.Unmarshal([]byte(raw), old); err != nil {
+			return fmt.Errorf("failed to unmarshal embedding info: %w", err)
+		}
+	case errors.Is(err, ErrNotFound):
+		// 记录嵌入模型之前建立的索引,按已有分片的向量维度判断
+		d, err := i.store.Dimension(ctx)
+		if err != nil {
+			return err
+		}
+		if d == 0 {
+			// 没有分片,按探测的维度重建向量索引
+			if err = i.store.Reset(ctx, cur.Dimension); err != nil {
+				return err
+			}
+		} else if d != cur.Dimension {
+			old = &EmbeddingInfo{Dimension: d}
+		}
+	default:
+		return fmt.Errorf("failed to get embedding info: %w", err)
+	}
+	if old != nil && (old.Dimension != cur.Dimension || (old.Model != "" && old.Model != cur.Model)) {
+		if !i.opt.IRVModel.Reindex {
+			return fmt.Errorf("%w: index built with model %q (dimension %d), current model %q (dimension %d), enable IRVModel.Reindex to rebuild the index",
+				ErrEmbeddingMismatch, old.Model, old.Dimension, cur.Model, cur.Dimension)
+		}
+		if err = i.reindex(ctx); err != nil {
+			return err
+		}
+		i.log.Warnw("Rebuilt vector index for new embedding model", "from", old.Model, "to", cur.Model, "dimension", cur.Dimension)
+	}
+	b, err := json.Marshal(cur)
+	if err != nil {
+		return err
+	}
+	return i.store.Update(ctx, func(tx Tx) {
+		tx.Set(EmbeddingKey, string(b))
+	})
+}
+
+// reindex 删除所有分片与文件清单并按当前维度重建向量索引,集合保留;知识库文件在下次同步时重新索引
+func (i *IRVector) reindex(ctx context.Context) error {
+	sources, err := i.Sources(ctx)
+	if err != nil {
+		return err
+	}
+	if err = i.store.Reset(ctx, i.emb.Load().Dimension); err != nil {
+		return err
+	}
+	return i.store.Update(ctx, func(tx Tx) {
+		for _, m := range sources {
+			tx.Del(ManifestPrefix + m.MD5)
+		}
+		tx.Del(SourceKey, PendingKey)
+	})
 }
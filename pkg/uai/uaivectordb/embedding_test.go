@@ -0,0 +1,26 @@
+package uaivectordb
+
+import "testing"
+
+func TestEmbeddingProvider(t *testing.T) {
+	tests := []struct {
+		provider string
+		want     string
+	}{
+		{"", ""},
+		{"ollama", EmbeddingOllama},
+		{"Ollama", EmbeddingOllama},
+		{"OpenAi", EmbeddingOpenAI},
+		{" OpenAI ", EmbeddingOpenAI},
+		{"DeepSeek", EmbeddingOpenAI},
+		{"Compatible", EmbeddingCompatible},
+		{"unknown", "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			if got := EmbeddingProvider(tt.provider); got != tt.want {
+				t.Errorf("EmbeddingProvider(%q) = %q, want %q", tt.provider, got, tt.want)
+			}
+		})
+	}
+}
@@ -5,13 +5,43 @@ import (
 	"github.com/cloudwego/eino/components/document"
 	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/compose"
-	"github.com/redis/go-redis/v9"
 )
 
 type If interface {
-	Redis() *redis.Client
+	// Store 向量存储
+	Store() Store
 	Embedder() embedding.Embedder
+	// Embedding 嵌入模型与启动时探测到的向量维度
+	Embedding() *EmbeddingInfo
 	Runnable() compose.Runnable[document.Source, []string]
 	BuildDir(ctx context.Context, dir string) (err error)
 	BuildFile(ctx context.Context, filepath string) (err error)
+	// IndexFile 索引文件到集合,先删除该文件已有的分片,返回新的分片数量;不支持的文件类型返回ErrUnsupportedFile,集合不存在返回ErrCollectionNotFound
+	IndexFile(ctx context.Context, filepath string, collection string) (chunks int, err error)
+	// DeleteFile 按路径删除文件在向量数据库中的所有分片,返回删除的分片数量
+	DeleteFile(ctx context.Context, filepath string) (chunks int, err error)
+	// RemoveFile 按文件MD5删除文件在向量数据库中的所有分片,返回删除的分片数量
+	RemoveFile(ctx context.Context, md5 string) (chunks int, err error)
+	// Manifest 按文件MD5获取文件清单,未索引时返回nil
+	Manifest(ctx context.Context, md5 string) (*Manifest, error)
+	// ManifestOf 按路径获取文件清单,未索引时返回nil
+	ManifestOf(ctx context.Context, filepath string) (*Manifest, error)
+	// Sources 已索引的文件清单,键为文件的绝对路径
+	Sources(ctx context.Context) (map[string]*Manifest, error)
+	// Sync 增量同步目录:只索引新增或变化的文件,删除已移除文件的分片;dryRun时只报告变化
+	Sync(ctx context.Context, dir string, dryRun bool) (*SyncReport, error)
+	// CreateCollection 创建集合,名称已存在时返回ErrCollectionExists
+	CreateCollection(ctx context.Context, c *Collection) error
+	// Collection 按名称获取集合,不存在时返回ErrCollectionNotFound
+	Collection(ctx context.Context, name string) (*Collection, error)
+	// Collections 所有集合,默认集合在前
+	Collections(ctx context.Context) ([]*Collection, error)
+	// DeleteCollection 删除集合及其所有文件的分片,返回删除的分片数量
+	DeleteCollection(ctx context.Context, name string) (chunks int, err error)
+	// Search 向量检索,在过滤范围内返回与检索文本最相近的topK个分片
+	Search(ctx context.Context, query string, filter Filter, topK int) ([]*Hit, error)
+	// FileChunks 按文件MD5获取文件的分片,未索引时返回nil
+	FileChunks(ctx context.Context, md5 string) ([]*Chunk, error)
+	// Stats 知识库统计信息:文件与分片数量、嵌入模型与索引大小
+	Stats(ctx context.Context) (*Stats, error)
 }
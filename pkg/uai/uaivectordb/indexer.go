@@ -2,51 +2,96 @@ package uaivectordb
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/cloudwego/eino-ext/components/indexer/redis"
+	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/schema"
 	"github.com/google/uuid"
-	redisCli "github.com/redis/go-redis/v9"
 )
 
-// newIndexer component initialization function of node 'RedisIndexer' in graph 'VectorDb'
-func newIndexer(ctx context.Context, opt *Option, redisClient *redisCli.Client) (idr indexer.Indexer, err error) {
-	
-	config := &redis.IndexerConfig{
-		Client:    redisClient,
-		KeyPrefix: RedisPrefix,
-		BatchSize: 1,
-		DocumentToHashes: func(ctx context.Context, doc *schema.Document) (*redis.Hashes, error) {
-			if doc.ID == "" {
-				doc.ID = uuid.New().String()
-			}
-			key := doc.ID
+// newIndexer component initialization function of node 'StoreIndexer' in graph 'VectorDb'
+func newIndexer(ctx context.Context, opt *Option, store Store, eb embedding.Embedder) (idr indexer.Indexer, err error) {
+	if store == nil {
+		return nil, errors.New("vector store not provided")
+	}
+	// 分片按批调用嵌入模型
+	batchSize := opt.IRVModel.BatchSize
+	if batchSize <= 0 {
+		batchSize = 10
+	}
+	return &storeIndexer{
+		store:     store,
+		eb:        eb,
+		batchSize: batchSize,
+	}, nil
+}
 
-			metadataBytes, err := json.Marshal(doc.MetaData)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal metadata: %w", err)
-			}
+// storeIndexer 将分片向量化后写入向量存储
+type storeIndexer struct {
+	store     Store
+	eb        embedding.Embedder
+	batchSize int
+}
 
-			return &redis.Hashes{
-				Key: key,
-				Field2Value: map[string]redis.FieldValue{
-					ContentField:  {Value: doc.Content, EmbedKey: VectorField},
-					MetadataField: {Value: metadataBytes},
-				},
-			}, nil
-		},
+func (s *storeIndexer) Store(ctx context.Context, docs []*schema.Document, opts ...indexer.Option) (ids []string, err error) {
+	co := indexer.GetCommonOptions(&indexer.Options{Embedding: s.eb}, opts...)
+	if co.Embedding == nil {
+		return nil, errors.New("embedding not provided")
 	}
-
-	embeddingIns11, err := newEmbedding(ctx, opt)
-	if err != nil {
-		return nil, err
+	chunks := make([]*Chunk, 0, len(docs))
+	for start := 0; start < len(docs); start += s.batchSize {
+		end := start + s.batchSize
+		if end > len(docs) {
+			end = len(docs)
+		}
+		texts := make([]string, 0, end-start)
+		for _, doc := range docs[start:end] {
+			texts = append(texts, doc.Content)
+		}
+		vectors, err := co.Embedding.EmbedStrings(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed chunks: %w", err)
+		}
+		if len(vectors) != len(texts) {
+			return nil, fmt.Errorf("invalid return length of vector, got=%d, expected=%d", len(vectors), len(texts))
+		}
+		for i, doc := range docs[start:end] {
+			chunks = append(chunks, s.toChunk(ctx, doc, vectors[i]))
+		}
 	}
-	config.Embedding = embeddingIns11
-	idr, err = redis.NewIndexer(ctx, config)
-	if err != nil {
+	if err = s.store.Put(ctx, chunks); err != nil {
 		return nil, err
 	}
-	return idr, nil
+	ids = make([]string, 0, len(chunks))
+	for _, c := range chunks {
+		ids = append(ids, c.ID)
+	}
+	return ids, nil
+}
+
+// toChunk 将文档转换为分片,补充来源文件Id与集合、所有者等TAG字段
+func (s *storeIndexer) toChunk(ctx context.Context, doc *schema.Document, vector []float64) *Chunk {
+	if doc.ID == "" {
+		doc.ID = uuid.New().String()
+	}
+	meta := make(map[string]any, len(doc.MetaData)+1)
+	for k, v := range doc.MetaData {
+		meta[k] = v
+	}
+	// 记录来源文件Id,检索结果据此引用知识库文件
+	if id := chunkFileID(ctx); id != "" {
+		meta[MetaKeyFileID] = id
+	}
+	return &Chunk{
+		ID:       doc.ID,
+		Content:  doc.Content,
+		MetaData: meta,
+		Tags:     chunkTags(ctx, meta),
+		Vector:   vector,
+	}
+}
+
+func (s *storeIndexer) GetType() string {
+	return "StoreIndexer"
 }
@@ -9,8 +9,12 @@ import (
 
 // newLoader component initialization function of node 'FileLoader' in graph 'VectorDb'
 func newLoader(ctx context.Context) (ldr document.Loader, err error) {
-	// TODO Modify component configuration here.
-	config := &file.FileLoaderConfig{}
+	// 按扩展名选择解析器
+	p, err := newParser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config := &file.FileLoaderConfig{Parser: p}
 	ldr, err = file.NewFileLoader(ctx, config)
 	if err != nil {
 		return nil, err
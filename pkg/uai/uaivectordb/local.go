@@ -0,0 +1,545 @@
+package uaivectordb
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// BM25参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// localStore 进程内向量存储,向量检索为精确的暴力检索(FLAT),全文检索为BM25
+// 数据常驻内存,写入后整体保存到本地文件,批量写入(见 Batch)期间合并为一次保存,适用于分片数量不大的单机部署与测试
+type localStore struct {
+	mu       sync.RWMutex
+	path     string
+	log      *zap.SugaredLogger
+	chunks   map[string]*localChunk
+	strs     map[string]string
+	hashes   map[string]map[string]string
+	df       map[string]int // 包含各词的分片数量
+	totalLen int            // 所有分片的词数之和
+	batches  int            // 进行中的批量写入数量
+	dirty    bool           // 是否有尚未保存的写入
+}
+
+// localChunk 本地存储中的分片,小写字段为加载后计算的索引,不保存到文件
+type localChunk struct {
+	Content  string
+	MetaData []byte // 元数据JSON
+	Tags     map[string]string
+	Vector   []float32
+	norm     float64        // 向量的模
+	terms    map[string]int // 分片内容中各词的词频
+	length   int            // 分片内容的词数
+}
+
+// localData 本地存储保存到文件的数据
+type localData struct {
+	Chunks  map[string]*localChunk
+	Strings map[string]string
+	Hashes  map[string]map[string]string
+}
+
+// newLocalStore 创建本地向量存储,数据文件存在时加载
+func newLocalStore(opt *LocalStoreOption, log *zap.SugaredLogger) (Store, error) {
+	if opt == nil || opt.Path == "" {
+		return nil, errors.New("local store path is required")
+	}
+	s := &localStore{
+		path:   opt.Path,
+		log:    log,
+		chunks: make(map[string]*localChunk),
+		strs:   make(map[string]string),
+		hashes: make(map[string]map[string]string),
+		df:     make(map[string]int),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	s.log.Infow("Loaded local vector store", "path", s.path, "number of parts", len(s.chunks))
+	return s, nil
+}
+
+// load 从数据文件加载数据并建立索引,文件不存在时为空存储
+func (s *localStore) load() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open local store %s: %w", s.path, err)
+	}
+	defer f.Close()
+	data := &localData{}
+	if err = gob.NewDecoder(f).Decode(data); err != nil {
+		return fmt.Errorf("failed to decode local store %s: %w", s.path, err)
+	}
+	if data.Strings != nil {
+		s.strs = data.Strings
+	}
+	if data.Hashes != nil {
+		s.hashes = data.Hashes
+	}
+	for id, c := range data.Chunks {
+		s.add(id, c)
+	}
+	return nil
+}
+
+// save 将数据写入临时文件后替换数据文件,调用方持有写锁
+func (s *localStore) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create local store directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to save local store: %w", err)
+	}
+	err = gob.NewEncoder(f).Encode(&localData{Chunks: s.chunks, Strings: s.strs, Hashes: s.hashes})
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save local store: %w", err)
+	}
+	if err = os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to save local store: %w", err)
+	}
+	return nil
+}
+
+// persist 写入后保存数据,批量写入期间只标记为待保存,调用方持有写锁
+func (s *localStore) persist() error {
+	s.dirty = true
+	if s.batches > 0 {
+		return nil
+	}
+	return s.flush()
+}
+
+// flush 保存待保存的数据,调用方持有写锁
+func (s *localStore) flush() error {
+	if !s.dirty {
+		return nil
+	}
+	if err := s.save(); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// add 添加分片并更新全文索引,已存在的分片先删除
+func (s *localStore) add(id string, c *localChunk) {
+	s.remove(id)
+	c.norm = norm(c.Vector)
+	c.terms = make(map[string]int)
+	for _, t := range Tokenize(c.Content) {
+		c.terms[t]++
+		c.length++
+	}
+	for t := range c.terms {
+		s.df[t]++
+	}
+	s.totalLen += c.length
+	s.chunks[id] = c
+}
+
+// remove 删除分片并更新全文索引
+func (s *localStore) remove(id string) {
+	c, ok := s.chunks[id]
+	if !ok {
+		return
+	}
+	for t := range c.terms {
+		if s.df[t]--; s.df[t] <= 0 {
+			delete(s.df, t)
+		}
+	}
+	s.totalLen -= c.length
+	delete(s.chunks, id)
+}
+
+func (s *localStore) Put(_ context.Context, chunks []*Chunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range chunks {
+		meta, err := json.Marshal(c.MetaData)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		vector := make([]float32, len(c.Vector))
+		for i, v := range c.Vector {
+			vector[i] = float32(v)
+		}
+		tags := make(map[string]string, len(c.Tags))
+		for k, v := range c.Tags {
+			tags[k] = v
+		}
+		s.add(c.ID, &localChunk{Content: c.Content, MetaData: meta, Tags: tags, Vector: vector})
+	}
+	return s.persist()
+}
+
+func (s *localStore) SetTags(_ context.Context, id string, tags map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.chunks[id]
+	if !ok {
+		return fmt.Errorf("%w: chunk %s", ErrNotFound, id)
+	}
+	if c.Tags == nil {
+		c.Tags = make(map[string]string, len(tags))
+	}
+	for k, v := range tags {
+		c.Tags[k] = v
+	}
+	return s.persist()
+}
+
+func (s *localStore) Chunks(_ context.Context, ids []string) ([]*Chunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := make([]*Chunk, 0, len(ids))
+	for _, id := range ids {
+		if c, ok := s.chunks[id]; ok {
+			res = append(res, c.toChunk(id))
+		}
+	}
+	return res, nil
+}
+
+func (s *localStore) Scan(ctx context.Context, fn func(c *Chunk) error) error {
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.chunks))
+	for id := range s.chunks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	list := make([]*Chunk, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, s.chunks[id].toChunk(id))
+	}
+	s.mu.RUnlock()
+	// 回调中可以写入存储,不持有锁
+	for _, c := range list {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *localStore) KNN(_ context.Context, vector []float64, filter Filter, k int) ([]*Hit, error) {
+	q := make([]float32, len(vector))
+	for i, v := range vector {
+		q[i] = float32(v)
+	}
+	qn := norm(q)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hits := make([]*Hit, 0)
+	for id, c := range s.chunks {
+		if !filter.match(c.Tags) {
+			continue
+		}
+		if len(c.Vector) != len(q) {
+			return nil, fmt.Errorf("vector dimension mismatch: chunk %s has %d, query has %d", id, len(c.Vector), len(q))
+		}
+		hits = append(hits, &Hit{Chunk: &Chunk{ID: id}, Score: cosine(q, c.Vector, qn, c.norm)})
+	}
+	return s.fill(topHits(hits, k)), nil
+}
+
+func (s *localStore) FullText(_ context.Context, terms []string, filter Filter, k int) ([]*Hit, error) {
+	query := make(map[string]struct{})
+	for _, t := range terms {
+		for _, v := range Tokenize(t) {
+			query[v] = struct{}{}
+		}
+	}
+	if len(query) == 0 {
+		return nil, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := float64(len(s.chunks))
+	if n == 0 {
+		return nil, nil
+	}
+	avg := float64(s.totalLen) / n
+	hits := make([]*Hit, 0)
+	for id, c := range s.chunks {
+		if !filter.match(c.Tags) {
+			continue
+		}
+		score := 0.0
+		for t := range query {
+			tf := float64(c.terms[t])
+			if tf == 0 {
+				continue
+			}
+			df := float64(s.df[t])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(c.length)/avg))
+		}
+		if score > 0 {
+			hits = append(hits, &Hit{Chunk: &Chunk{ID: id}, Score: score})
+		}
+	}
+	return s.fill(topHits(hits, k)), nil
+}
+
+// fill 为检索结果补充分片内容、元数据与TAG字段,调用方持有读锁
+func (s *localStore) fill(hits []*Hit) []*Hit {
+	for _, h := range hits {
+		h.Chunk = s.chunks[h.Chunk.ID].toChunk(h.Chunk.ID)
+	}
+	return hits
+}
+
+func (s *localStore) Get(_ context.Context, key string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.strs[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func (s *localStore) Exists(_ context.Context, key string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.strs[key]; ok {
+		return true, nil
+	}
+	_, ok := s.hashes[key]
+	return ok, nil
+}
+
+func (s *localStore) HGet(_ context.Context, key, field string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.hashes[key][field]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func (s *localStore) HGetAll(_ context.Context, key string) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := make(map[string]string, len(s.hashes[key]))
+	for k, v := range s.hashes[key] {
+		res[k] = v
+	}
+	return res, nil
+}
+
+func (s *localStore) HSetNX(_ context.Context, key, field, value string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.hashes[key][field]; ok {
+		return false, nil
+	}
+	(&localTx{s: s}).HSet(key, field, value)
+	return true, s.persist()
+}
+
+func (s *localStore) Update(_ context.Context, fn func(tx Tx)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&localTx{s: s})
+	return s.persist()
+}
+
+// Batch 批量写入,所有进行中的批量写入结束后保存一次
+func (s *localStore) Batch(_ context.Context, fn func() error) error {
+	s.mu.Lock()
+	s.batches++
+	s.mu.Unlock()
+	err := fn()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches--
+	if s.batches > 0 {
+		return err
+	}
+	if ferr := s.flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+func (s *localStore) Dimension(_ context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, c := range s.chunks {
+		if len(c.Vector) > 0 {
+			return len(c.Vector), nil
+		}
+	}
+	return 0, nil
+}
+
+// Stats 分片数量与数据文件大小,向量大小按FLOAT32计算
+func (s *localStore) Stats(_ context.Context) (*StoreStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := &StoreStats{Backend: StoreLocal, Chunks: len(s.chunks)}
+	vectors := 0
+	for _, c := range s.chunks {
+		if res.Dimension == 0 {
+			res.Dimension = len(c.Vector)
+		}
+		vectors += 4 * len(c.Vector)
+	}
+	res.VectorSizeMB = float64(vectors) / (1 << 20)
+	if info, err := os.Stat(s.path); err == nil {
+		res.IndexSizeMB = float64(info.Size()) / (1 << 20)
+	}
+	return res, nil
+}
+
+// Reset 删除所有分片,本地存储按查询向量的维度检索,不需要重建索引
+func (s *localStore) Reset(_ context.Context, _ int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chunks = make(map[string]*localChunk)
+	s.df = make(map[string]int)
+	s.totalLen = 0
+	return s.persist()
+}
+
+func (s *localStore) Flush(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.chunks = make(map[string]*localChunk)
+	s.strs = make(map[string]string)
+	s.hashes = make(map[string]map[string]string)
+	s.df = make(map[string]int)
+	s.totalLen = 0
+	return s.persist()
+}
+
+func (s *localStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flush()
+}
+
+var _ Store = &localStore{}
+
+// localTx 本地存储的写操作,调用方持有写锁
+type localTx struct {
+	s *localStore
+}
+
+func (t *localTx) Set(key, value string) {
+	t.s.strs[key] = value
+}
+
+func (t *localTx) Del(keys ...string) {
+	for _, k := range keys {
+		delete(t.s.strs, k)
+		delete(t.s.hashes, k)
+	}
+}
+
+func (t *localTx) HSet(key, field, value string) {
+	h, ok := t.s.hashes[key]
+	if !ok {
+		h = make(map[string]string)
+		t.s.hashes[key] = h
+	}
+	h[field] = value
+}
+
+func (t *localTx) HDel(key string, fields ...string) {
+	h, ok := t.s.hashes[key]
+	if !ok {
+		return
+	}
+	for _, f := range fields {
+		delete(h, f)
+	}
+	if len(h) == 0 {
+		delete(t.s.hashes, key)
+	}
+}
+
+func (t *localTx) DelChunks(ids ...string) {
+	for _, id := range ids {
+		t.s.remove(id)
+	}
+}
+
+// toChunk 转换为不含向量的分片
+func (c *localChunk) toChunk(id string) *Chunk {
+	meta := make(map[string]any)
+	_ = json.Unmarshal(c.MetaData, &meta)
+	tags := make(map[string]string, len(c.Tags))
+	for k, v := range c.Tags {
+		tags[k] = v
+	}
+	return &Chunk{ID: id, Content: c.Content, MetaData: meta, Tags: tags}
+}
+
+// cosine 两个向量的余弦相似度
+func cosine(a, b []float32, normA, normB float64) float64 {
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot / (normA * normB)
+}
+
+// norm 向量的模
+func norm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+// topHits 按分数从高到低返回前k个结果
+func topHits(hits []*Hit, k int) []*Hit {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Chunk.ID < hits[j].Chunk.ID
+	})
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
@@ -0,0 +1,151 @@
+package uaivectordb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestLocalFullTextChinese(t *testing.T) {
+	ctx := context.Background()
+	i := newTestVector(t)
+	err := i.store.Put(ctx, []*Chunk{
+		{ID: "redis", Content: "向量数据库使用RedisStack保存分片,检索前需要创建索引"},
+		{ID: "session", Content: "会话标题在首条消息后自动生成,也可以手动重命名"},
+		{ID: "english", Content: "Configure the embedding model before indexing files"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		terms []string
+		want  string
+	}{
+		{"chinese sentence", []string{"怎么创建向量索引"}, "redis"},
+		{"chinese bigrams", []string{"会话", "标题"}, "session"},
+		{"mixed", []string{"redisstack", "分片"}, "redis"},
+		{"english", []string{"embedding", "model"}, "english"},
+		{"no match", []string{"天气预报"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := i.store.FullText(ctx, tt.terms, nil, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if len(hits) > 0 {
+				got = hits[0].Chunk.ID
+			}
+			if got != tt.want {
+				t.Errorf("FullText(%v) top hit = %q, want %q", tt.terms, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalBatch(t *testing.T) {
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+	tests := []struct {
+		name      string
+		run       func(s Store) error
+		wantErr   error
+		wantSaved bool // 数据文件是否已写入
+		wantKeys  int  // 重新加载后的键数量
+	}{
+		{"write saves immediately", func(s Store) error {
+			return s.Update(ctx, func(tx Tx) { tx.Set("a", "1") })
+		}, nil, true, 1},
+		{"batch saves once at the end", func(s Store) error {
+			return s.Batch(ctx, func() error {
+				for _, k := range []string{"a", "b", "c"} {
+					if err := s.Update(ctx, func(tx Tx) { tx.Set(k, "1") }); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}, nil, true, 3},
+		{"nested batch", func(s Store) error {
+			return s.Batch(ctx, func() error {
+				if err := s.Batch(ctx, func() error {
+					return s.Update(ctx, func(tx Tx) { tx.Set("a", "1") })
+				}); err != nil {
+					return err
+				}
+				return s.Update(ctx, func(tx Tx) { tx.Set("b", "1") })
+			})
+		}, nil, true, 2},
+		{"failed batch keeps written data", func(s Store) error {
+			return s.Batch(ctx, func() error {
+				if err := s.Update(ctx, func(tx Tx) { tx.Set("a", "1") }); err != nil {
+					return err
+				}
+				return errFailed
+			})
+		}, errFailed, true, 1},
+		{"read only batch does not save", func(s Store) error {
+			return s.Batch(ctx, func() error {
+				_, err := s.Exists(ctx, "a")
+				return err
+			})
+		}, nil, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "store.gob")
+			log := zap.NewNop().Sugar()
+			s, err := newLocalStore(&LocalStoreOption{Path: path}, log)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = tt.run(s); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			_, err = os.Stat(path)
+			if saved := err == nil; saved != tt.wantSaved {
+				t.Fatalf("saved = %v, want %v", saved, tt.wantSaved)
+			}
+			if _, err = os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+				t.Errorf("temporary file is left: %v", err)
+			}
+			loaded, err := newLocalStore(&LocalStoreOption{Path: path}, log)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := len(loaded.(*localStore).strs); n != tt.wantKeys {
+				t.Errorf("keys = %d, want %d", n, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestLocalBatchDefersSave(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.gob")
+	s, err := newLocalStore(&LocalStoreOption{Path: path}, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Batch(ctx, func() error {
+		if err := s.Put(ctx, []*Chunk{{ID: "a", Content: "a"}}); err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("data file is saved during batch: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(path); err != nil {
+		t.Errorf("data file is not saved after batch: %v", err)
+	}
+}
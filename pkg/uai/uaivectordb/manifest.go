@@ -0,0 +1,529 @@
+package uaivectordb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino-ext/components/document/loader/file"
+	"github.com/cloudwego/eino/components/document"
+	"github.com/freedqo/fmc-go-agent/pkg/utils"
+)
+
+const (
+	// ManifestPrefix 文件清单的键前缀,键为 ManifestPrefix+文件MD5
+	ManifestPrefix = "eino:manifest:"
+	// SourceKey 来源文件到文件MD5的映射(hash),用于按路径查找清单;内容相同的文件映射到同一清单
+	SourceKey = "eino:source"
+	// PendingKey 正在索引的文件(hash,来源文件:文件MD5),索引中断后用于清理已写入的分片
+	PendingKey = "eino:pending"
+)
+
+// ErrUnsupportedFile 文件类型不支持索引
+var ErrUnsupportedFile = errors.New("unsupported file type")
+
+// Manifest 文件清单,记录文件索引产生的分片
+type Manifest struct {
+	MD5        string   `json:"md5"`        // 文件内容的MD5
+	Source     string   `json:"source"`     // 索引该内容的来源文件的绝对路径,内容相同的其他文件共用清单与分片
+	Chunks     []string `json:"chunks"`     // 分片Id,分片的键为 RedisPrefix+Id
+	Size       int64    `json:"size"`       // 索引时的文件大小
+	ModTime    int64    `json:"modTime"`    // 索引时的文件修改时间,unix秒
+	Updated    int64    `json:"updated"`    // 索引时间,unix秒
+	Collection string   `json:"collection"` // 文件所属的集合
+}
+
+// supported 判断文件是否支持索引
+func supported(path string) bool {
+	ext := filepath.Ext(path)
+	for _, v := range SupportedExts {
+		if strings.EqualFold(ext, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// source 文件在向量数据库中的来源标识,统一为绝对路径
+func source(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+// IndexFile 索引文件到集合,返回新的分片数量;集合为空时为默认集合
+// 先写入新的分片,成功后再删除该文件(按路径或内容MD5)已有的分片并替换清单,索引失败时保留原有分片
+// 相同内容已由另一个仍存在的文件索引到同一集合时共用其清单与分片,不重复索引
+func (i *IRVector) IndexFile(ctx context.Context, path string, collection string) (chunks int, err error) {
+	err = i.store.Batch(ctx, func() error {
+		chunks, err = i.indexFile(ctx, path, collection)
+		return err
+	})
+	return chunks, err
+}
+
+func (i *IRVector) indexFile(ctx context.Context, path string, collection string) (chunks int, err error) {
+	if !supported(path) {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedFile, filepath.Ext(path))
+	}
+	// 降级启动且未配置维度时,向量索引在探测到维度后才创建
+	if i.emb.Load().Dimension == 0 {
+		return 0, ErrEmbeddingUnavailable
+	}
+	c, err := i.Collection(ctx, collection)
+	if err != nil {
+		return 0, err
+	}
+	src := source(path)
+	info, err := os.Stat(src)
+	if err != nil {
+		return 0, err
+	}
+	md5, err := utils.CalculateFileMD5(src)
+	if err != nil {
+		return 0, fmt.Errorf("failed to calculate md5 of %s: %w", src, err)
+	}
+	shared, err := i.share(ctx, src, md5, c.Name)
+	if err != nil || shared {
+		return 0, err
+	}
+	// 登记正在索引的文件,中断后在下次启动时清理已写入的分片
+	err = i.store.Update(ctx, func(tx Tx) {
+		tx.HSet(PendingKey, src, md5)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark %s pending: %w", src, err)
+	}
+	ids, err := i.r.Invoke(withChunkScope(ctx, c, md5), document.Source{URI: src})
+	if err != nil {
+		if _, serr := i.removeStray(context.WithoutCancel(ctx), src); serr != nil {
+			i.log.Errorw("Failed to remove stray chunks", "file", src, "error", serr)
+		}
+		return 0, fmt.Errorf("invoke index graph failed: %w", err)
+	}
+	err = i.replaceManifest(ctx, &Manifest{
+		MD5:        md5,
+		Source:     src,
+		Chunks:     ids,
+		Size:       info.Size(),
+		ModTime:    info.ModTime().Unix(),
+		Updated:    time.Now().Unix(),
+		Collection: c.Name,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// share 相同内容的清单由另一个仍存在的文件索引时,将路径映射到该清单,返回是否共用
+// 原文件已不存在或内容已变化时视为文件移动,返回false由调用方重新索引;原文件属于其他集合时返回错误
+func (i *IRVector) share(ctx context.Context, src, md5, collection string) (bool, error) {
+	m, err := i.Manifest(ctx, md5)
+	if err != nil || m == nil || m.Source == src {
+		return false, err
+	}
+	ok, err := i.alive(ctx, m)
+	if err != nil || !ok {
+		return false, err
+	}
+	if m.Collection != collection {
+		return false, fmt.Errorf("same content is already indexed from %s in collection %s", m.Source, m.Collection)
+	}
+	// 路径原先指向其他内容时,删除其原有的清单与分片
+	old, err := i.ManifestOf(ctx, src)
+	if err != nil {
+		return false, err
+	}
+	if old != nil && old.MD5 != md5 && old.Source == src {
+		if err = i.removeManifest(ctx, old); err != nil {
+			return false, err
+		}
+	}
+	err = i.store.Update(ctx, func(tx Tx) {
+		tx.HSet(SourceKey, src, md5)
+		tx.HDel(PendingKey, src)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to map %s to manifest %s: %w", src, md5, err)
+	}
+	return true, nil
+}
+
+// alive 清单的来源文件仍存在,且仍映射到该清单
+func (i *IRVector) alive(ctx context.Context, m *Manifest) (bool, error) {
+	if _, err := os.Stat(m.Source); err != nil {
+		return false, nil
+	}
+	cur, err := i.store.HGet(ctx, SourceKey, m.Source)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get manifest of %s: %w", m.Source, err)
+	}
+	return cur == m.MD5, nil
+}
+
+// replaceManifest 保存新的文件清单,并删除同一路径(文件内容变化)或同一内容(文件路径变化)的旧清单与分片
+// 旧内容被删除时,共用旧内容的其他路径的映射一并删除,由下次同步重新索引
+func (i *IRVector) replaceManifest(ctx context.Context, m *Manifest) error {
+	olds := make([]*Manifest, 0, 2)
+	bySrc, err := i.ManifestOf(ctx, m.Source)
+	if err != nil {
+		return err
+	}
+	// 与其他文件共用的清单不属于该路径,替换映射即可
+	if bySrc != nil && bySrc.Source != m.Source {
+		bySrc = nil
+	}
+	if bySrc != nil {
+		olds = append(olds, bySrc)
+	}
+	byMD5, err := i.Manifest(ctx, m.MD5)
+	if err != nil {
+		return err
+	}
+	if byMD5 != nil && (bySrc == nil || byMD5.Source != bySrc.Source) {
+		olds = append(olds, byMD5)
+	}
+	keep := make(map[string]struct{}, len(m.Chunks))
+	for _, id := range m.Chunks {
+		keep[id] = struct{}{}
+	}
+	stale := make([]string, 0)
+	moved := make([]string, 0)
+	for _, old := range olds {
+		for _, id := range old.Chunks {
+			if _, ok := keep[id]; !ok {
+				stale = append(stale, id)
+			}
+		}
+		if old.Source == m.Source {
+			continue
+		}
+		// 内容相同但路径变化时,旧路径仍指向该内容则删除其映射
+		cur, err := i.store.HGet(ctx, SourceKey, old.Source)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("failed to get manifest of %s: %w", old.Source, err)
+		}
+		if cur == old.MD5 {
+			moved = append(moved, old.Source)
+		}
+	}
+	removed := make(map[string]struct{})
+	for _, old := range olds {
+		if old.MD5 != m.MD5 {
+			removed[old.MD5] = struct{}{}
+		}
+	}
+	sharers, err := i.sharers(ctx, removed, m.Source)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest %s: %w", m.MD5, err)
+	}
+	err = i.store.Update(ctx, func(tx Tx) {
+		tx.DelChunks(stale...)
+		for md5 := range removed {
+			tx.Del(ManifestPrefix + md5)
+		}
+		tx.HDel(SourceKey, moved...)
+		tx.HDel(SourceKey, sharers...)
+		tx.Set(ManifestPrefix+m.MD5, string(b))
+		tx.HSet(SourceKey, m.Source, m.MD5)
+		tx.HDel(PendingKey, m.Source)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save manifest %s: %w", m.MD5, err)
+	}
+	return nil
+}
+
+// DeleteFile 按路径删除文件在向量数据库中的所有分片,返回删除的分片数量
+// 其他路径引用相同内容时只删除该路径的映射,保留清单与分片
+func (i *IRVector) DeleteFile(ctx context.Context, path string) (chunks int, err error) {
+	src := source(path)
+	m, err := i.ManifestOf(ctx, src)
+	if err != nil || m == nil {
+		return 0, err
+	}
+	shared, err := i.referenced(ctx, m.MD5, src)
+	if err != nil {
+		return 0, err
+	}
+	if shared {
+		err = i.store.Update(ctx, func(tx Tx) {
+			tx.HDel(SourceKey, src)
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to remove source %s: %w", src, err)
+		}
+		return 0, nil
+	}
+	if err = i.removeManifest(ctx, m); err != nil {
+		return 0, err
+	}
+	if m.Source != src {
+		err = i.store.Update(ctx, func(tx Tx) {
+			tx.HDel(SourceKey, src)
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to remove source %s: %w", src, err)
+		}
+	}
+	return len(m.Chunks), nil
+}
+
+// sharers 映射到给定内容的路径,不包括except
+func (i *IRVector) sharers(ctx context.Context, md5s map[string]struct{}, except string) ([]string, error) {
+	res := make([]string, 0)
+	if len(md5s) == 0 {
+		return res, nil
+	}
+	src2md5, err := i.store.HGetAll(ctx, SourceKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sources: %w", err)
+	}
+	for s, v := range src2md5 {
+		if _, ok := md5s[v]; ok && s != except {
+			res = append(res, s)
+		}
+	}
+	return res, nil
+}
+
+// referenced 除src之外是否还有路径引用该文件内容
+func (i *IRVector) referenced(ctx context.Context, md5, src string) (bool, error) {
+	src2md5, err := i.store.HGetAll(ctx, SourceKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to get sources: %w", err)
+	}
+	for s, v := range src2md5 {
+		if v == md5 && s != src {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// RemoveFile 按文件MD5删除文件在向量数据库中的所有分片与清单,返回删除的分片数量
+func (i *IRVector) RemoveFile(ctx context.Context, md5 string) (chunks int, err error) {
+	m, err := i.Manifest(ctx, md5)
+	if err != nil || m == nil {
+		return 0, err
+	}
+	if err = i.removeManifest(ctx, m); err != nil {
+		return 0, err
+	}
+	return len(m.Chunks), nil
+}
+
+// Manifest 按文件MD5获取文件清单,未索引时返回nil
+func (i *IRVector) Manifest(ctx context.Context, md5 string) (*Manifest, error) {
+	raw, err := i.store.Get(ctx, ManifestPrefix+md5)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest %s: %w", md5, err)
+	}
+	m := &Manifest{}
+	if err = json.Unmarshal([]byte(raw), m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal manifest %s: %w", md5, err)
+	}
+	// 集合出现之前索引的文件属于默认集合
+	if m.Collection == "" {
+		m.Collection = DefaultCollection
+	}
+	return m, nil
+}
+
+// Sources 已索引的文件清单,键为文件的绝对路径;内容相同的文件对应同一清单
+func (i *IRVector) Sources(ctx context.Context) (map[string]*Manifest, error) {
+	src2md5, err := i.store.HGetAll(ctx, SourceKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sources: %w", err)
+	}
+	res := make(map[string]*Manifest, len(src2md5))
+	for src, md5 := range src2md5 {
+		m, err := i.Manifest(ctx, md5)
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			continue
+		}
+		res[src] = m
+	}
+	return res, nil
+}
+
+// saveManifest 保存文件清单与来源映射
+func (i *IRVector) saveManifest(ctx context.Context, m *Manifest) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest %s: %w", m.MD5, err)
+	}
+	err = i.store.Update(ctx, func(tx Tx) {
+		tx.Set(ManifestPrefix+m.MD5, string(b))
+		tx.HSet(SourceKey, m.Source, m.MD5)
+		tx.HDel(PendingKey, m.Source)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save manifest %s: %w", m.MD5, err)
+	}
+	return nil
+}
+
+// removeManifest 删除清单记录的分片、清单与映射到该清单的路径
+func (i *IRVector) removeManifest(ctx context.Context, m *Manifest) error {
+	// 来源已指向其他内容时保留映射
+	srcs, err := i.sharers(ctx, map[string]struct{}{m.MD5: {}}, "")
+	if err != nil {
+		return err
+	}
+	err = i.store.Update(ctx, func(tx Tx) {
+		tx.DelChunks(m.Chunks...)
+		tx.Del(ManifestPrefix + m.MD5)
+		tx.HDel(SourceKey, srcs...)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove chunks of %s: %w", m.Source, err)
+	}
+	return nil
+}
+
+// migrateManifest 为清单出现之前索引的分片建立清单,来源文件已不存在的分片直接删除
+// 已有来源映射时认为已迁移,不再扫描
+func (i *IRVector) migrateManifest(ctx context.Context) error {
+	ok, err := i.store.Exists(ctx, SourceKey)
+	if err != nil {
+		return fmt.Errorf("failed to check sources: %w", err)
+	}
+	if ok {
+		return nil
+	}
+	groups := make(map[string][]string)
+	err = i.scanChunks(ctx, func(id, src string) {
+		src = source(src)
+		groups[src] = append(groups[src], id)
+	})
+	if err != nil {
+		return err
+	}
+	for src, ids := range groups {
+		info, err := os.Stat(src)
+		if errors.Is(err, os.ErrNotExist) {
+			err = i.store.Update(ctx, func(tx Tx) {
+				tx.DelChunks(ids...)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to delete chunks of %s: %w", src, err)
+			}
+			i.log.Infow("Removed chunks of missing file", "file", src, "number of parts", len(ids))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", src, err)
+		}
+		md5, err := utils.CalculateFileMD5(src)
+		if err != nil {
+			return fmt.Errorf("failed to calculate md5 of %s: %w", src, err)
+		}
+		err = i.saveManifest(ctx, &Manifest{
+			MD5:     md5,
+			Source:  src,
+			Chunks:  ids,
+			Size:    info.Size(),
+			ModTime: info.ModTime().Unix(),
+			Updated: time.Now().Unix(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(groups) > 0 {
+		i.log.Infow("Migrated chunks to manifests", "number of files", len(groups))
+	}
+	return nil
+}
+
+// recoverPending 清理上次中断的索引已写入的分片
+func (i *IRVector) recoverPending(ctx context.Context) error {
+	pending, err := i.store.HGetAll(ctx, PendingKey)
+	if err != nil {
+		return fmt.Errorf("failed to get pending files: %w", err)
+	}
+	for src := range pending {
+		n, err := i.removeStray(ctx, src)
+		if err != nil {
+			return err
+		}
+		i.log.Infow("Removed chunks of interrupted indexing", "file", src, "number of parts", n)
+	}
+	return nil
+}
+
+// removeStray 删除来源文件没有登记在清单中的分片,并清除正在索引的登记
+func (i *IRVector) removeStray(ctx context.Context, src string) (int, error) {
+	keep := make(map[string]struct{})
+	m, err := i.ManifestOf(ctx, src)
+	if err != nil {
+		return 0, err
+	}
+	if m != nil {
+		for _, id := range m.Chunks {
+			keep[id] = struct{}{}
+		}
+	}
+	ids := make([]string, 0)
+	err = i.scanChunks(ctx, func(id, s string) {
+		if _, ok := keep[id]; !ok && source(s) == src {
+			ids = append(ids, id)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	err = i.store.Update(ctx, func(tx Tx) {
+		tx.DelChunks(ids...)
+		tx.HDel(PendingKey, src)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove stray chunks of %s: %w", src, err)
+	}
+	return len(ids), nil
+}
+
+// ManifestOf 按路径获取文件清单,未索引时返回nil
+func (i *IRVector) ManifestOf(ctx context.Context, path string) (*Manifest, error) {
+	src := source(path)
+	md5, err := i.store.HGet(ctx, SourceKey, src)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest of %s: %w", src, err)
+	}
+	return i.Manifest(ctx, md5)
+}
+
+// scanChunks 遍历所有分片,回调分片Id与来源文件
+func (i *IRVector) scanChunks(ctx context.Context, fn func(id, src string)) error {
+	return i.store.Scan(ctx, func(c *Chunk) error {
+		if s, ok := c.MetaData[file.MetaKeySource].(string); ok {
+			fn(c.ID, s)
+		}
+		return nil
+	})
+}
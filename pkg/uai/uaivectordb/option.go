@@ -9,41 +9,71 @@ func NewOption() *Option {
 			Organization: "ut-pc2-gd",
 			Provider:     "ollama",
 			Timeout:      120,
+			BatchSize:    10,
 		},
+		Store: StoreRedis,
 		RedisStack: &RedisStack{
 			Addr:      "192.168.53.217:16379",
 			Protocol:  2,
-			Dimension: 4096,
+			Dimension: 0,
+		},
+		Local: &LocalStoreOption{
+			Path: "./knowdb/vector.db",
 		},
 		LoadMdFilePloy: &LoadMdFilePloy{
 			IsLoadMdFiles: false,
 			Dir:           "./knowdb/md",
 		},
+		Splitter: NewDefaultSplitterOption(),
 	}
 }
 
 type Option struct {
-	IRVModel       *IRVModelOption `comment:"向量数据库使用的大模型配置"`
-	RedisStack     *RedisStack     `comment:"向量数据库RedisStack配置"`
-	LoadMdFilePloy *LoadMdFilePloy `comment:"本地知识文档（*.md）加载策略"`
+	IRVModel       *IRVModelOption   `comment:"向量数据库使用的大模型配置"`
+	Store          string            `comment:"向量存储后端,redis:RedisStack;local:进程内存储,数据保存在本地文件,无需RedisStack"`
+	RedisStack     *RedisStack       `comment:"向量数据库RedisStack配置,Store为redis时使用"`
+	Local          *LocalStoreOption `comment:"进程内向量存储配置,Store为local时使用"`
+	LoadMdFilePloy *LoadMdFilePloy   `comment:"本地知识文档（*.md）加载策略"`
+	Splitter       *SplitterOption   `comment:"非markdown文档(pdf、docx、txt、html)的分片配置"`
+}
+
+func NewDefaultSplitterOption() *SplitterOption {
+	return &SplitterOption{
+		ChunkSize:   800,
+		OverlapSize: 100,
+	}
+}
+
+// SplitterOption 递归字符分片配置
+type SplitterOption struct {
+	ChunkSize   int `comment:"分片的最大长度,按字符计"`
+	OverlapSize int `comment:"相邻分片的最大重叠长度,按字符计"`
 }
 
 type RedisStack struct {
 	Addr      string `comment:"RedisStack地址,192.168.53.217:16789"` // 192.168.53.217:16789
 	Protocol  int    `comment:"协议类型,2"`                            // 2
-	Dimension int    `comment:"对齐格式,4096"`                         // 4096
+	Dimension int    `comment:"向量维度,0:启动时由嵌入模型探测;非0时须与探测结果一致"`     // 0
 	Db        int    `comment:"数据库索引,0"`                           // 0 int
 }
+
+// LocalStoreOption 进程内向量存储配置,分片常驻内存,精确检索,适用于单机部署与测试
+type LocalStoreOption struct {
+	Path string `comment:"数据文件路径"`
+}
+
 type LoadMdFilePloy struct {
 	IsLoadMdFiles bool   `comment:"是否加载本地文件到向量数据库"`
 	Dir           string `comment:"本地md文件路径"`
 }
 
 type IRVModelOption struct {
-	APIKey       string `comment:"API-秘钥"`    // API秘钥
-	BaseURL      string `comment:"API-链接"`    // API基础链接
-	Organization string `comment:"API-使用组织"`  // API使用组织
-	Provider     string `comment:"API-模型提供商"` // API提供商-OpenAI、Ollama、DeepSeek等支持OpenAi Api的厂商
-	Model        string `comment:"API-应用模型"`  // API-应用模型
-	Timeout      int64  `comment:"API-超时时间"`  // API超时时间,单位秒
+	APIKey       string `comment:"API-秘钥"`                             // API秘钥
+	BaseURL      string `comment:"API-链接"`                             // API基础链接
+	Organization string `comment:"API-使用组织"`                           // API使用组织
+	Provider     string `comment:"API-模型提供商,ollama/openai/compatible"` // API提供商,不区分大小写-ollama:Ollama原生接口;openai:OpenAI,API链接未包含版本路径时追加/v1(旧配置的DeepSeek同openai);compatible:vLLM等其他OpenAI兼容接口,API链接需包含版本路径
+	Model        string `comment:"API-应用模型"`                           // API-应用模型
+	Timeout      int64  `comment:"API-超时时间"`                           // API超时时间,单位秒
+	BatchSize    int    `comment:"API-批大小"`                            // 向量化批大小,每次调用嵌入模型处理的分片数量
+	Reindex      bool   `comment:"嵌入模型或向量维度与已有索引不一致时重建索引,删除所有分片与文件清单,知识库文件需重新同步;为false时拒绝启动"`
 }
@@ -2,35 +2,51 @@ package uaivectordb
 
 import (
 	"context"
+	"strings"
+
+	"github.com/cloudwego/eino-ext/components/document/loader/file"
 	"github.com/cloudwego/eino/components/indexer"
+	"github.com/cloudwego/eino/schema"
 
 	"github.com/cloudwego/eino/components/document"
 	"github.com/cloudwego/eino/compose"
 )
 
-func (i *IRVector) buildIRVector(ctx context.Context, opt *Option, fileLoaderKeyOfLoader document.Loader, idr indexer.Indexer, tfr document.Transformer) (r compose.Runnable[document.Source, []string], err error) {
+func (i *IRVector) buildIRVector(ctx context.Context, opt *Option, fileLoaderKeyOfLoader document.Loader, idr indexer.Indexer, tfr document.Transformer, rtfr document.Transformer) (r compose.Runnable[document.Source, []string], err error) {
 	const (
-		FileLoader       = "FileLoader"
-		MarkdownSplitter = "MarkdownSplitter"
-		RedisIndexer     = "RedisIndexer"
+		FileLoader        = "FileLoader"
+		MarkdownSplitter  = "MarkdownSplitter"
+		RecursiveSplitter = "RecursiveSplitter"
+		StoreIndexer      = "StoreIndexer"
 	)
-	// 初始化RedisStack Vector数据库
-	err = initRedisIndex(ctx, opt, i.rdb)
-	if err != nil {
-		return nil, err
-	}
 	g := compose.NewGraph[document.Source, []string]()
-	// 添加FileLoader节点
+	// 添加FileLoader节点,按扩展名选择解析器
 	_ = g.AddLoaderNode(FileLoader, fileLoaderKeyOfLoader)
 	// 添加MarkdownSplitter节点
 	_ = g.AddDocumentTransformerNode(MarkdownSplitter, tfr)
-	// 添加RedisIndexer节点
-	_ = g.AddIndexerNode(RedisIndexer, idr)
-	// 添加节点之间的边
+	// 添加RecursiveSplitter节点,用于非markdown文本
+	_ = g.AddDocumentTransformerNode(RecursiveSplitter, rtfr)
+	// 添加StoreIndexer节点,分片写入配置的向量存储
+	_ = g.AddIndexerNode(StoreIndexer, idr)
+	// 添加节点之间的边,按文件类型选择分片方式:markdown按标题分片,csv已按行解析不再分片,其他按字符递归分片
 	_ = g.AddEdge(compose.START, FileLoader)
-	_ = g.AddEdge(FileLoader, MarkdownSplitter)
-	_ = g.AddEdge(MarkdownSplitter, RedisIndexer)
-	_ = g.AddEdge(RedisIndexer, compose.END)
+	_ = g.AddBranch(FileLoader, compose.NewGraphBranch(func(ctx context.Context, docs []*schema.Document) (string, error) {
+		if len(docs) == 0 {
+			return StoreIndexer, nil
+		}
+		ext, _ := docs[0].MetaData[file.MetaKeyExtension].(string)
+		switch strings.ToLower(ext) {
+		case ".md":
+			return MarkdownSplitter, nil
+		case ".csv":
+			return StoreIndexer, nil
+		default:
+			return RecursiveSplitter, nil
+		}
+	}, map[string]bool{MarkdownSplitter: true, RecursiveSplitter: true, StoreIndexer: true}))
+	_ = g.AddEdge(MarkdownSplitter, StoreIndexer)
+	_ = g.AddEdge(RecursiveSplitter, StoreIndexer)
+	_ = g.AddEdge(StoreIndexer, compose.END)
 
 	r, err = g.Compile(ctx, compose.WithGraphName("VectorDb"), compose.WithNodeTriggerMode(compose.AnyPredecessor))
 	if err != nil {
@@ -0,0 +1,160 @@
+package uaivectordb
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudwego/eino-ext/components/document/parser/html"
+	"github.com/cloudwego/eino-ext/components/document/parser/pdf"
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/schema"
+	"github.com/freedqo/fmc-go-agent/pkg/ucsvvp"
+)
+
+// SupportedExts 支持索引的文件类型
+var SupportedExts = []string{".md", ".txt", ".pdf", ".docx", ".html", ".htm", ".csv"}
+
+// MetaKeyRow CSV文件按行索引时,分片对应的行号(从1开始,不含表头)
+const MetaKeyRow = "_row"
+
+// newParser 按扩展名选择解析器,未登记的类型按纯文本解析
+func newParser(ctx context.Context) (parser.Parser, error) {
+	pp, err := pdf.NewPDFParser(ctx, &pdf.Config{})
+	if err != nil {
+		return nil, err
+	}
+	hp, err := html.NewParser(ctx, &html.Config{Selector: &html.BodySelector})
+	if err != nil {
+		return nil, err
+	}
+	byExt := map[string]parser.Parser{
+		".md":   parser.TextParser{},
+		".txt":  parser.TextParser{},
+		".pdf":  pp,
+		".docx": docxParser{},
+		".html": hp,
+		".htm":  hp,
+		".csv":  csvParser{},
+	}
+	// 扩展名区分大小写,同时登记大写
+	parsers := make(map[string]parser.Parser, len(byExt)*2)
+	for ext, p := range byExt {
+		parsers[ext] = p
+		parsers[strings.ToUpper(ext)] = p
+	}
+	return parser.NewExtParser(ctx, &parser.ExtParserConfig{
+		Parsers:        parsers,
+		FallbackParser: parser.TextParser{},
+	})
+}
+
+// docxParser Word文档解析器,按段落提取word/document.xml中的文本
+type docxParser struct{}
+
+func (docxParser) Parse(ctx context.Context, reader io.Reader, opts ...parser.Option) ([]*schema.Document, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid docx: %w", err)
+	}
+	var body io.ReadCloser
+	for _, f := range zr.File {
+		if f.Name == "word/document.xml" {
+			body, err = f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("invalid docx: %w", err)
+			}
+			break
+		}
+	}
+	if body == nil {
+		return nil, errors.New("invalid docx: word/document.xml not found")
+	}
+	defer body.Close()
+
+	var sb strings.Builder
+	inText := false
+	dec := xml.NewDecoder(body)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid docx: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				sb.WriteString("\t")
+			case "br", "cr":
+				sb.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				sb.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+	opt := parser.GetCommonOptions(&parser.Options{}, opts...)
+	return []*schema.Document{{
+		Content:  strings.TrimSpace(sb.String()),
+		MetaData: map[string]any{parser.MetaKeySource: opt.URI},
+	}}, nil
+}
+
+// csvParser CSV解析器,每一行为一个文档,内容为"列名: 值"
+type csvParser struct{}
+
+func (csvParser) Parse(ctx context.Context, reader io.Reader, opts ...parser.Option) ([]*schema.Document, error) {
+	header, rows, err := ucsvvp.ReadRows(reader)
+	if err != nil {
+		return nil, err
+	}
+	opt := parser.GetCommonOptions(&parser.Options{}, opts...)
+	docs := make([]*schema.Document, 0, len(rows))
+	for i, row := range rows {
+		var sb strings.Builder
+		for j, v := range row {
+			if v == "" {
+				continue
+			}
+			if j < len(header) && header[j] != "" {
+				sb.WriteString(header[j])
+				sb.WriteString(": ")
+			}
+			sb.WriteString(v)
+			sb.WriteString("\n")
+		}
+		if sb.Len() == 0 {
+			continue
+		}
+		docs = append(docs, &schema.Document{
+			Content: strings.TrimSpace(sb.String()),
+			MetaData: map[string]any{
+				parser.MetaKeySource: opt.URI,
+				MetaKeyRow:           i + 1,
+			},
+		})
+	}
+	return docs, nil
+}
@@ -18,9 +18,16 @@ package uaivectordb
 
 import (
 	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/redis/go-redis/v9"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
 const (
@@ -29,21 +36,31 @@ const (
 
 	ContentField  = "content"
 	MetadataField = "metadata"
+	// TermsField 分片内容按 Tokenize 分词后以空格连接,全文检索在该字段上进行
+	TermsField    = "terms"
 	VectorField   = "content_vector"
 	DistanceField = "distance"
+
+	// 分片的TAG字段,检索时按集合、所有者、文件类型与标题路径过滤
+	CollectionField = "collection"
+	OwnerField      = "owner"
+	FileTypeField   = "file_type"
+	HeaderField     = "header"
 )
 
-func initRedisIndex(ctx context.Context, config *Option, client *redis.Client) (err error) {
-	if config.RedisStack.Dimension <= 0 {
-		return fmt.Errorf("dimension must be positive")
-	}
-	
-	defer func() {
-		if err != nil {
-			client.Close()
-		}
-	}()
+// TermsFilledKey 已有分片补充分词字段后设置,存在时启动不再扫描
+const TermsFilledKey = "eino:terms_filled"
+
+// tagSchema 分片TAG字段的索引定义
+var tagSchema = []interface{}{
+	CollectionField, "TAG",
+	OwnerField, "TAG",
+	FileTypeField, "TAG",
+	HeaderField, "TAG", "SEPARATOR", "|",
+}
 
+// initRedisIndex 创建向量维度为dim的向量索引,索引已存在时补充分词与TAG字段
+func initRedisIndex(ctx context.Context, dim int, client *redis.Client) (err error) {
 	if err = client.Ping(ctx).Err(); err != nil {
 		return fmt.Errorf("failed to connect to Redis: %w", err)
 	}
@@ -58,6 +75,12 @@ func initRedisIndex(ctx context.Context, config *Option, client *redis.Client) (
 		}
 		err = nil
 	} else if exists != nil {
+		// 已有的索引补充分词与TAG字段
+		return alterRedisIndex(ctx, client, indexName)
+	}
+
+	// 嵌入模型不可用且未配置维度时暂不创建索引,探测到维度后由 Reset 创建
+	if dim <= 0 {
 		return nil
 	}
 
@@ -69,12 +92,14 @@ func initRedisIndex(ctx context.Context, config *Option, client *redis.Client) (
 		"SCHEMA",
 		ContentField, "TEXT",
 		MetadataField, "TEXT",
+		TermsField, "TEXT",
 		VectorField, "VECTOR", "FLAT",
 		"6",
 		"TYPE", "FLOAT32",
-		"DIM", config.RedisStack.Dimension,
+		"DIM", dim,
 		"DISTANCE_METRIC", "COSINE",
 	}
+	createIndexArgs = append(createIndexArgs, tagSchema...)
 
 	if err = client.Do(ctx, createIndexArgs...).Err(); err != nil {
 		return fmt.Errorf("failed to create index: %w", err)
@@ -87,3 +112,491 @@ func initRedisIndex(ctx context.Context, config *Option, client *redis.Client) (
 
 	return nil
 }
+
+// alterRedisIndex 为分词与TAG字段出现之前创建的索引补充字段,字段已存在时忽略
+func alterRedisIndex(ctx context.Context, client *redis.Client, indexName string) error {
+	fields := append([]interface{}{TermsField, "TEXT"}, tagSchema...)
+	for i := 0; i < len(fields); {
+		j := i + 2
+		if j < len(fields) && fields[j] == "SEPARATOR" {
+			j += 2
+		}
+		args := append([]interface{}{"FT.ALTER", indexName, "SCHEMA", "ADD"}, fields[i:j]...)
+		if err := client.Do(ctx, args...).Err(); err != nil && !strings.Contains(err.Error(), "Duplicate field") {
+			return fmt.Errorf("failed to add field %v to index: %w", fields[i], err)
+		}
+		i = j
+	}
+	return nil
+}
+
+// redisStore RedisStack向量存储,分片的键为 RedisPrefix+分片Id
+type redisStore struct {
+	rdb   *redis.Client
+	dim   int // 向量索引的维度
+	index string
+}
+
+// newRedisStore 连接RedisStack并创建或升级向量索引
+func newRedisStore(ctx context.Context, opt *Option, dim int) (Store, error) {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     opt.RedisStack.Addr,
+		Protocol: opt.RedisStack.Protocol,
+		DB:       opt.RedisStack.Db,
+	})
+	if err := initRedisIndex(ctx, dim, rdb); err != nil {
+		rdb.Close()
+		return nil, err
+	}
+	s := &redisStore{
+		rdb:   rdb,
+		dim:   dim,
+		index: fmt.Sprintf("%s%s", RedisPrefix, IndexName),
+	}
+	if err := s.fillTerms(ctx); err != nil {
+		rdb.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// fillTerms 为分词字段出现之前写入的分片补充分词字段,完成后设置 TermsFilledKey
+func (s *redisStore) fillTerms(ctx context.Context) error {
+	n, err := s.rdb.Exists(ctx, TermsFilledKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check terms: %w", err)
+	}
+	if n > 0 {
+		return nil
+	}
+	var cursor uint64
+	for {
+		keys, next, err := s.rdb.Scan(ctx, cursor, RedisPrefix+"*", 500).Result()
+		if err != nil {
+			return fmt.Errorf("failed to scan chunks: %w", err)
+		}
+		for _, key := range keys {
+			vals, err := s.rdb.HMGet(ctx, key, ContentField, TermsField).Result()
+			if err != nil {
+				// 非分片的键(如索引)跳过
+				continue
+			}
+			content, ok := vals[0].(string)
+			if !ok || vals[1] != nil {
+				continue
+			}
+			if err = s.rdb.HSet(ctx, key, TermsField, terms(content)).Err(); err != nil {
+				return fmt.Errorf("failed to fill terms of %s: %w", key, err)
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	return s.rdb.Set(ctx, TermsFilledKey, "1", 0).Err()
+}
+
+// terms 分片内容的分词字段
+func terms(content string) string {
+	return strings.Join(Tokenize(content), " ")
+}
+
+func (s *redisStore) Put(ctx context.Context, chunks []*Chunk) error {
+	_, err := s.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, c := range chunks {
+			meta, err := json.Marshal(c.MetaData)
+			if err != nil {
+				return fmt.Errorf("failed to marshal metadata: %w", err)
+			}
+			fields := map[string]any{
+				ContentField:  c.Content,
+				MetadataField: meta,
+				TermsField:    terms(c.Content),
+				VectorField:   vectorBytes(c.Vector),
+			}
+			for k, v := range c.Tags {
+				fields[k] = v
+			}
+			p.HSet(ctx, RedisPrefix+c.ID, fields)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put chunks: %w", err)
+	}
+	return nil
+}
+
+func (s *redisStore) SetTags(ctx context.Context, id string, tags map[string]string) error {
+	if err := s.rdb.HSet(ctx, RedisPrefix+id, tags).Err(); err != nil {
+		return fmt.Errorf("failed to set tags of chunk %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *redisStore) Chunks(ctx context.Context, ids []string) ([]*Chunk, error) {
+	cmds := make([]*redis.SliceCmd, 0, len(ids))
+	_, err := s.rdb.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, id := range ids {
+			cmds = append(cmds, p.HMGet(ctx, RedisPrefix+id, scanFields...))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chunks: %w", err)
+	}
+	res := make([]*Chunk, 0, len(ids))
+	for i, cmd := range cmds {
+		fields := make(map[string]string, len(scanFields))
+		for j, v := range cmd.Val() {
+			if str, ok := v.(string); ok {
+				fields[scanFields[j]] = str
+			}
+		}
+		if c, ok := toChunk(ids[i], fields); ok {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+// scanFields 遍历分片时读取的字段
+var scanFields = []string{ContentField, MetadataField, CollectionField, OwnerField, FileTypeField, HeaderField}
+
+func (s *redisStore) Scan(ctx context.Context, fn func(c *Chunk) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := s.rdb.Scan(ctx, cursor, RedisPrefix+"*", 500).Result()
+		if err != nil {
+			return fmt.Errorf("failed to scan chunks: %w", err)
+		}
+		for _, key := range keys {
+			vals, err := s.rdb.HMGet(ctx, key, scanFields...).Result()
+			if err != nil {
+				// 非分片的键(如索引)跳过
+				continue
+			}
+			fields := make(map[string]string, len(vals))
+			for i, v := range vals {
+				if str, ok := v.(string); ok {
+					fields[scanFields[i]] = str
+				}
+			}
+			c, ok := toChunk(strings.TrimPrefix(key, RedisPrefix), fields)
+			if !ok {
+				continue
+			}
+			if err = fn(c); err != nil {
+				return err
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+func (s *redisStore) KNN(ctx context.Context, vector []float64, filter Filter, k int) ([]*Hit, error) {
+	pre := "*"
+	if len(filter) > 0 {
+		pre = "(" + filter.query() + ")"
+	}
+	q := fmt.Sprintf("%s=>[KNN %d @%s $vector AS %s]", pre, k, VectorField, DistanceField)
+	res, err := s.rdb.FTSearchWithArgs(ctx, s.index, q, &redis.FTSearchOptions{
+		Return:         returnFields(DistanceField),
+		SortBy:         []redis.FTSearchSortBy{{FieldName: DistanceField, Asc: true}},
+		Limit:          k,
+		DialectVersion: 2,
+		Params:         map[string]any{"vector": vectorBytes(vector)},
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("vector search failed: %w", err)
+	}
+	hits := make([]*Hit, 0, len(res.Docs))
+	for _, v := range res.Docs {
+		c, ok := toChunk(strings.TrimPrefix(v.ID, RedisPrefix), v.Fields)
+		if !ok {
+			continue
+		}
+		distance, err := strconv.ParseFloat(v.Fields[DistanceField], 64)
+		if err != nil {
+			continue
+		}
+		hits = append(hits, &Hit{Chunk: c, Score: 1 - distance})
+	}
+	return hits, nil
+}
+
+func (s *redisStore) FullText(ctx context.Context, terms []string, filter Filter, k int) ([]*Hit, error) {
+	seen := make(map[string]struct{})
+	query := make([]string, 0, len(terms))
+	for _, t := range terms {
+		for _, v := range Tokenize(t) {
+			if _, ok := seen[v]; !ok {
+				seen[v] = struct{}{}
+				query = append(query, v)
+			}
+		}
+	}
+	if len(query) == 0 {
+		return nil, nil
+	}
+	q := strings.TrimSpace(fmt.Sprintf("@%s:(%s) %s", TermsField, strings.Join(query, "|"), filter.query()))
+	res, err := s.rdb.FTSearchWithArgs(ctx, s.index, q, &redis.FTSearchOptions{
+		Return:         returnFields(),
+		Limit:          k,
+		DialectVersion: 2,
+		Scorer:         "BM25",
+		WithScores:     true,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("full text search failed: %w", err)
+	}
+	hits := make([]*Hit, 0, len(res.Docs))
+	for _, v := range res.Docs {
+		c, ok := toChunk(strings.TrimPrefix(v.ID, RedisPrefix), v.Fields)
+		if !ok {
+			continue
+		}
+		h := &Hit{Chunk: c}
+		if v.Score != nil {
+			h.Score = *v.Score
+		}
+		hits = append(hits, h)
+	}
+	return hits, nil
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) (string, error) {
+	v, err := s.rdb.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+	return v, err
+}
+
+func (s *redisStore) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := s.rdb.Exists(ctx, key).Result()
+	return n > 0, err
+}
+
+func (s *redisStore) HGet(ctx context.Context, key, field string) (string, error) {
+	v, err := s.rdb.HGet(ctx, key, field).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+	return v, err
+}
+
+func (s *redisStore) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return s.rdb.HGetAll(ctx, key).Result()
+}
+
+func (s *redisStore) HSetNX(ctx context.Context, key, field, value string) (bool, error) {
+	return s.rdb.HSetNX(ctx, key, field, value).Result()
+}
+
+func (s *redisStore) Update(ctx context.Context, fn func(tx Tx)) error {
+	_, err := s.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		fn(&redisTx{ctx: ctx, p: p})
+		return nil
+	})
+	return err
+}
+
+// Dimension 读取任一分片的向量,FLOAT32编码每维4字节
+func (s *redisStore) Dimension(ctx context.Context) (int, error) {
+	var cursor uint64
+	for {
+		keys, next, err := s.rdb.Scan(ctx, cursor, RedisPrefix+"*", 500).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to scan chunks: %w", err)
+		}
+		for _, key := range keys {
+			v, err := s.rdb.HGet(ctx, key, VectorField).Result()
+			if err != nil || len(v) == 0 {
+				continue
+			}
+			return len(v) / 4, nil
+		}
+		cursor = next
+		if cursor == 0 {
+			return 0, nil
+		}
+	}
+}
+
+// Stats 由 FT.INFO 获取索引的文档数量与内存占用
+func (s *redisStore) Stats(ctx context.Context) (*StoreStats, error) {
+	info, err := s.rdb.FTInfo(ctx, s.index).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index info: %w", err)
+	}
+	return &StoreStats{
+		Backend:      StoreRedis,
+		Chunks:       info.NumDocs,
+		Dimension:    s.dim,
+		IndexSizeMB:  info.TotalIndexMemorySzMB,
+		VectorSizeMB: info.VectorIndexSzMB,
+		Failures:     info.HashIndexingFailures,
+		Indexing:     info.Indexing != 0,
+	}, nil
+}
+
+// Reset 删除向量索引及其分片,清理未被索引的分片键后按维度重新创建索引
+func (s *redisStore) Reset(ctx context.Context, dim int) error {
+	err := s.rdb.FTDropIndexWithArgs(ctx, s.index, &redis.FTDropIndexOptions{DeleteDocs: true}).Err()
+	if err != nil && !strings.Contains(strings.ToLower(err.Error()), "unknown index") {
+		return fmt.Errorf("failed to drop index: %w", err)
+	}
+	var cursor uint64
+	for {
+		keys, next, err := s.rdb.Scan(ctx, cursor, RedisPrefix+"*", 500).Result()
+		if err != nil {
+			return fmt.Errorf("failed to scan chunks: %w", err)
+		}
+		if len(keys) > 0 {
+			if err = s.rdb.Del(ctx, keys...).Err(); err != nil {
+				return fmt.Errorf("failed to delete chunks: %w", err)
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	if err = initRedisIndex(ctx, dim, s.rdb); err != nil {
+		return err
+	}
+	s.dim = dim
+	return nil
+}
+
+// Batch RedisStack的写操作直接生效,直接执行fn
+func (s *redisStore) Batch(_ context.Context, fn func() error) error {
+	return fn()
+}
+
+// Flush 清空数据库并重新创建向量索引
+func (s *redisStore) Flush(ctx context.Context) error {
+	if err := s.rdb.FlushDB(ctx).Err(); err != nil {
+		return err
+	}
+	return initRedisIndex(ctx, s.dim, s.rdb)
+}
+
+func (s *redisStore) Close() error {
+	return s.rdb.Close()
+}
+
+var _ Store = &redisStore{}
+
+// redisTx 在事务管道中执行的写操作
+type redisTx struct {
+	ctx context.Context
+	p   redis.Pipeliner
+}
+
+func (t *redisTx) Set(key, value string) {
+	t.p.Set(t.ctx, key, value, 0)
+}
+
+func (t *redisTx) Del(keys ...string) {
+	if len(keys) > 0 {
+		t.p.Del(t.ctx, keys...)
+	}
+}
+
+func (t *redisTx) HSet(key, field, value string) {
+	t.p.HSet(t.ctx, key, field, value)
+}
+
+func (t *redisTx) HDel(key string, fields ...string) {
+	if len(fields) > 0 {
+		t.p.HDel(t.ctx, key, fields...)
+	}
+}
+
+func (t *redisTx) DelChunks(ids ...string) {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, RedisPrefix+id)
+	}
+	t.Del(keys...)
+}
+
+// toChunk 由分片的HASH字段生成分片,没有元数据的键不是分片
+func toChunk(id string, fields map[string]string) (*Chunk, bool) {
+	raw, ok := fields[MetadataField]
+	if !ok {
+		return nil, false
+	}
+	meta := make(map[string]any)
+	if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+		return nil, false
+	}
+	c := &Chunk{
+		ID:       id,
+		Content:  fields[ContentField],
+		MetaData: meta,
+		Tags:     make(map[string]string),
+	}
+	for _, k := range []string{CollectionField, OwnerField, FileTypeField, HeaderField} {
+		if v, ok := fields[k]; ok {
+			c.Tags[k] = v
+		}
+	}
+	return c, true
+}
+
+// returnFields 检索结果返回分片内容、元数据、TAG字段与额外的字段
+func returnFields(extra ...string) []redis.FTSearchReturn {
+	fields := append([]string{ContentField, MetadataField, CollectionField, OwnerField, FileTypeField, HeaderField}, extra...)
+	res := make([]redis.FTSearchReturn, 0, len(fields))
+	for _, v := range fields {
+		res = append(res, redis.FTSearchReturn{FieldName: v})
+	}
+	return res
+}
+
+// query TAG过滤条件的查询语句,字段按名称排序
+func (f Filter) query() string {
+	fields := make([]string, 0, len(f))
+	for k := range f {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	parts := make([]string, 0, len(fields))
+	for _, k := range fields {
+		parts = append(parts, fmt.Sprintf("@%s:{%s}", k, tagValues(f[k])))
+	}
+	return strings.Join(parts, " ")
+}
+
+// tagValues TAG查询的取值,以 | 分隔,非字母数字的字符转义
+func tagValues(values []string) string {
+	var sb strings.Builder
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteString("|")
+		}
+		for _, r := range v {
+			if !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '_' {
+				sb.WriteRune('\\')
+			}
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// vectorBytes 向量按FLOAT32小端序编码
+func vectorBytes(vector []float64) []byte {
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(v)))
+	}
+	return buf
+}
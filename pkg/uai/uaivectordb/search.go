@@ -0,0 +1,105 @@
+package uaivectordb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DefaultSearchTopK 检索未指定返回数量时的默认值
+const DefaultSearchTopK = 5
+
+// Stats 知识库统计信息
+type Stats struct {
+	Files       int                `json:"files"`       // 已索引的文件数量
+	Chunks      int                `json:"chunks"`      // 文件清单记录的分片数量
+	Collections []*CollectionStats `json:"collections"` // 各集合的文件与分片数量
+	Embedding   *EmbeddingInfo     `json:"embedding"`   // 嵌入模型与向量维度
+	Store       *StoreStats        `json:"store"`       // 向量存储的统计信息
+}
+
+// CollectionStats 集合的统计信息
+type CollectionStats struct {
+	Name   string `json:"name"`   // 集合名称
+	Files  int    `json:"files"`  // 已索引的文件数量
+	Chunks int    `json:"chunks"` // 分片数量
+}
+
+// Search 向量检索,在过滤范围内返回与检索文本最相近的topK个分片,分数为余弦相似度
+func (i *IRVector) Search(ctx context.Context, query string, filter Filter, topK int) ([]*Hit, error) {
+	if query == "" {
+		return nil, errors.New("query is required")
+	}
+	if topK <= 0 {
+		topK = DefaultSearchTopK
+	}
+	vectors, err := i.eb.EmbedStrings(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("invalid return length of vector, got=%d, expected=1", len(vectors))
+	}
+	return i.store.KNN(ctx, vectors[0], filter, topK)
+}
+
+// FileChunks 按文件MD5获取文件的分片,顺序与索引时的分片顺序一致;未索引时返回nil
+func (i *IRVector) FileChunks(ctx context.Context, md5 string) ([]*Chunk, error) {
+	m, err := i.Manifest(ctx, md5)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	return i.store.Chunks(ctx, m.Chunks)
+}
+
+// Stats 统计已索引的文件、各集合的分片数量与向量存储的索引信息
+func (i *IRVector) Stats(ctx context.Context) (*Stats, error) {
+	sources, err := i.Sources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := i.Collections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	st, err := i.store.Stats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := &Stats{
+		Files:       len(sources),
+		Collections: make([]*CollectionStats, 0, len(cs)),
+		Embedding:   i.emb.Load(),
+		Store:       st,
+	}
+	byName := make(map[string]*CollectionStats, len(cs))
+	for _, c := range cs {
+		v := &CollectionStats{Name: c.Name}
+		byName[c.Name] = v
+		res.Collections = append(res.Collections, v)
+	}
+	counted := make(map[string]struct{}, len(sources))
+	for _, m := range sources {
+		// 内容相同的文件共用分片,分片只统计一次
+		chunks := 0
+		if _, ok := counted[m.MD5]; !ok {
+			counted[m.MD5] = struct{}{}
+			chunks = len(m.Chunks)
+		}
+		res.Chunks += chunks
+		name := m.Collection
+		if name == "" {
+			name = DefaultCollection
+		}
+		v, ok := byName[name]
+		if !ok {
+			// 集合已删除但文件仍有分片
+			v = &CollectionStats{Name: name}
+			byName[name] = v
+			res.Collections = append(res.Collections, v)
+		}
+		v.Files++
+		v.Chunks += chunks
+	}
+	return res, nil
+}
@@ -0,0 +1,179 @@
+package uaivectordb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// StoreRedis RedisStack向量存储,分片以HASH保存,通过RediSearch检索
+	StoreRedis = "redis"
+	// StoreLocal 进程内向量存储,数据保存在本地文件中,适用于单机部署与测试
+	StoreLocal = "local"
+)
+
+// ErrNotFound 键或字段不存在
+var ErrNotFound = errors.New("not found")
+
+// Chunk 向量存储中的分片
+type Chunk struct {
+	ID       string            // 分片Id
+	Content  string            // 分片内容
+	MetaData map[string]any    // 分片元数据
+	Tags     map[string]string // TAG字段:集合、所有者、文件类型与标题路径,检索时按TAG过滤
+	Vector   []float64         // 内容的向量,遍历与检索结果中不返回
+}
+
+// Hit 检索命中的分片
+type Hit struct {
+	Chunk *Chunk
+	Score float64 // 向量检索为余弦相似度(1-余弦距离),全文检索为BM25分数
+}
+
+// StoreStats 向量存储的统计信息
+type StoreStats struct {
+	Backend      string  `json:"backend"`      // 存储后端,redis或local
+	Chunks       int     `json:"chunks"`       // 分片数量
+	Dimension    int     `json:"dimension"`    // 向量索引的维度
+	IndexSizeMB  float64 `json:"indexSizeMB"`  // 索引大小,RedisStack为索引占用的内存,本地存储为数据文件大小
+	VectorSizeMB float64 `json:"vectorSizeMB"` // 向量索引大小
+	Failures     int     `json:"failures"`     // 索引失败的分片数量
+	Indexing     bool    `json:"indexing"`     // 是否正在建立索引
+}
+
+// Filter TAG过滤条件,键为TAG字段,值为可选取值;字段之间为与,取值之间为或
+type Filter map[string][]string
+
+// match 判断分片的TAG是否满足过滤条件
+func (f Filter) match(tags map[string]string) bool {
+	for field, values := range f {
+		v, ok := tags[field]
+		if !ok {
+			return false
+		}
+		found := false
+		for _, want := range values {
+			if v == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Tx 一组原子执行的写操作
+type Tx interface {
+	Set(key, value string)
+	Del(keys ...string)
+	HSet(key, field, value string)
+	HDel(key string, fields ...string)
+	// DelChunks 删除分片
+	DelChunks(ids ...string)
+}
+
+// Store 向量存储,保存分片及其向量,并以键值与hash保存文件清单、集合等元数据
+type Store interface {
+	// Put 写入分片,Id相同的分片覆盖
+	Put(ctx context.Context, chunks []*Chunk) error
+	// SetTags 更新分片的TAG字段
+	SetTags(ctx context.Context, id string, tags map[string]string) error
+	// Chunks 按Id获取分片,不含向量,不存在的分片跳过
+	Chunks(ctx context.Context, ids []string) ([]*Chunk, error)
+	// Scan 遍历所有分片,回调的分片不含向量;回调返回错误时停止遍历
+	Scan(ctx context.Context, fn func(c *Chunk) error) error
+	// KNN 在过滤范围内按余弦距离返回最相近的k个分片
+	KNN(ctx context.Context, vector []float64, filter Filter, k int) ([]*Hit, error)
+	// FullText 在过滤范围内按BM25返回匹配任一关键词得分最高的k个分片
+	FullText(ctx context.Context, terms []string, filter Filter, k int) ([]*Hit, error)
+
+	// Get 获取键的值,不存在时返回ErrNotFound
+	Get(ctx context.Context, key string) (string, error)
+	// Exists 判断键是否存在
+	Exists(ctx context.Context, key string) (bool, error)
+	// HGet 获取hash字段的值,不存在时返回ErrNotFound
+	HGet(ctx context.Context, key, field string) (string, error)
+	// HGetAll 获取hash的所有字段
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	// HSetNX 字段不存在时设置hash字段,返回是否设置
+	HSetNX(ctx context.Context, key, field, value string) (bool, error)
+	// Update 原子执行一组写操作
+	Update(ctx context.Context, fn func(tx Tx)) error
+	// Batch 批量写入,fn中的写操作在fn返回后合并保存;本地存储据此避免每次写入都保存整个数据文件
+	Batch(ctx context.Context, fn func() error) error
+
+	// Dimension 已写入分片的向量维度,没有分片时为0
+	Dimension(ctx context.Context) (int, error)
+	// Stats 向量存储的统计信息
+	Stats(ctx context.Context) (*StoreStats, error)
+	// Reset 删除所有分片并按维度重建向量索引,键值与hash数据保留
+	Reset(ctx context.Context, dim int) error
+	// Flush 清空所有分片与元数据
+	Flush(ctx context.Context) error
+	// Close 关闭存储,本地存储保存尚未保存的数据
+	Close() error
+}
+
+// newStore 按配置创建向量存储,dim为嵌入模型的向量维度
+func newStore(ctx context.Context, opt *Option, dim int, log *zap.SugaredLogger) (Store, error) {
+	switch strings.ToLower(opt.Store) {
+	case "", StoreRedis:
+		return newRedisStore(ctx, opt, dim)
+	case StoreLocal:
+		s, err := newLocalStore(opt.Local, log)
+		if err != nil {
+			return nil, err
+		}
+		// 退出时保存尚未保存的数据
+		go func() {
+			<-ctx.Done()
+			if err := s.Close(); err != nil {
+				log.Errorw("Failed to save local vector store", "error", err)
+			}
+		}()
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unsupported vector store: %s", opt.Store)
+	}
+}
+
+// Tokenize 全文检索的分词:按非字母数字字符切分并转为小写,连续的汉字按相邻两字切分
+// 本地存储与RedisStack在写入与检索时使用相同的分词,RediSearch默认的分词不切分汉字
+func Tokenize(text string) []string {
+	res := make([]string, 0)
+	run := make([]rune, 0)
+	han := false
+	emit := func() {
+		switch {
+		case len(run) == 0:
+		case !han || len(run) == 1:
+			res = append(res, string(run))
+		default:
+			for i := 0; i+1 < len(run); i++ {
+				res = append(res, string(run[i:i+2]))
+			}
+		}
+		run = run[:0]
+	}
+	for _, r := range strings.ToLower(text) {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+			emit()
+			continue
+		}
+		if isHan := unicode.Is(unicode.Han, r); isHan != han {
+			emit()
+			han = isHan
+		}
+		run = append(run, r)
+	}
+	emit()
+	return res
+}
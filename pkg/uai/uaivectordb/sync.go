@@ -0,0 +1,155 @@
+package uaivectordb
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/freedqo/fmc-go-agent/pkg/utils"
+)
+
+// SyncReport 目录同步结果
+type SyncReport struct {
+	DryRun    bool              // 是否只报告变化
+	Added     []string          // 新增的文件
+	Changed   []string          // 内容变化的文件
+	Removed   []string          // 已移除的文件
+	Unchanged int               // 未变化的文件数量
+	Skipped   int               // 不支持索引的文件数量
+	Chunks    int               // 新写入的分片数量
+	Failed    map[string]string // 失败的文件及原因
+}
+
+// Sync 增量同步目录:按文件大小与修改时间判断变化,变化时再比较MD5,只索引新增或变化的文件,删除已移除文件的分片
+// 单个文件失败不中断同步,已完成的文件记录在清单中,中断后再次同步从未完成的文件继续;dryRun时只报告变化
+// 文件按相对目录的路径归属集合(见 CollectionOf),集合变化的文件重新索引
+func (i *IRVector) Sync(ctx context.Context, dir string, dryRun bool) (report *SyncReport, err error) {
+	err = i.store.Batch(ctx, func() error {
+		report, err = i.sync(ctx, dir, dryRun)
+		return err
+	})
+	return report, err
+}
+
+func (i *IRVector) sync(ctx context.Context, dir string, dryRun bool) (*SyncReport, error) {
+	root := source(dir)
+	if _, err := os.Stat(root); err != nil {
+		return nil, err
+	}
+	known, err := i.Sources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &SyncReport{
+		DryRun: dryRun,
+		Failed: make(map[string]string),
+	}
+	seen := make(map[string]struct{})
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			report.Failed[path] = err.Error()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !supported(path) {
+			report.Skipped++
+			return nil
+		}
+		seen[path] = struct{}{}
+		info, err := d.Info()
+		if err != nil {
+			report.Failed[path] = err.Error()
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			report.Failed[path] = err.Error()
+			return nil
+		}
+		collection := CollectionOf(rel)
+		m := known[path]
+		changed, err := i.changed(ctx, m, path, collection, info, dryRun)
+		if err != nil {
+			report.Failed[path] = err.Error()
+			return nil
+		}
+		if !changed {
+			report.Unchanged++
+			return nil
+		}
+		if m == nil {
+			report.Added = append(report.Added, path)
+		} else {
+			report.Changed = append(report.Changed, path)
+		}
+		if dryRun {
+			return nil
+		}
+		n, err := i.IndexFile(ctx, path, collection)
+		if err != nil {
+			report.Failed[path] = err.Error()
+			i.log.Errorw("Failed to index file", "file", path, "error", err)
+			return nil
+		}
+		report.Chunks += n
+		i.log.Infow("Finished indexing file", "file", path, "number of parts", n)
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	prefix := root + string(filepath.Separator)
+	for src := range known {
+		if _, ok := seen[src]; ok || !strings.HasPrefix(src, prefix) {
+			continue
+		}
+		report.Removed = append(report.Removed, src)
+		if dryRun {
+			continue
+		}
+		if _, err := i.DeleteFile(ctx, src); err != nil {
+			report.Failed[src] = err.Error()
+		}
+	}
+	return report, nil
+}
+
+// changed 判断文件相对清单是否变化;大小与修改时间变化但内容未变时,更新清单中的大小与修改时间
+// 与其他文件共用清单的文件,清单记录的是原文件的大小与修改时间,只比较MD5
+func (i *IRVector) changed(ctx context.Context, m *Manifest, path, collection string, info fs.FileInfo, dryRun bool) (bool, error) {
+	if m == nil || m.Collection != collection {
+		return true, nil
+	}
+	own := m.Source == path
+	if own && m.Size == info.Size() && m.ModTime == info.ModTime().Unix() {
+		return false, nil
+	}
+	md5, err := utils.CalculateFileMD5(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to calculate md5 of %s: %w", path, err)
+	}
+	if md5 != m.MD5 {
+		return true, nil
+	}
+	if own && !dryRun {
+		m.Size = info.Size()
+		m.ModTime = info.ModTime().Unix()
+		m.Updated = time.Now().Unix()
+		if err = i.saveManifest(ctx, m); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,390 @@
+package uaivectordb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino-ext/components/document/loader/file"
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/compose"
+	"github.com/freedqo/fmc-go-agent/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// newTestVector 创建使用本地存储的向量数据库,不建立索引流程,只用于清单与同步的测试
+func newTestVector(t *testing.T) *IRVector {
+	t.Helper()
+	log := zap.NewNop().Sugar()
+	store, err := newLocalStore(&LocalStoreOption{Path: filepath.Join(t.TempDir(), "store.gob")}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &IRVector{log: log, store: store}
+}
+
+// writeFile 写入文件并设置修改时间
+func writeFile(t *testing.T, path, content string, mod time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// indexed 按文件当前内容写入分片与清单,模拟已索引的文件
+func indexed(t *testing.T, i *IRVector, path, collection string, ids ...string) {
+	t.Helper()
+	ctx := context.Background()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md5, err := utils.CalculateFileMD5(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := make([]*Chunk, 0, len(ids))
+	for _, id := range ids {
+		chunks = append(chunks, &Chunk{ID: id, Content: id, MetaData: map[string]any{file.MetaKeySource: path}})
+	}
+	if err = i.store.Put(ctx, chunks); err != nil {
+		t.Fatal(err)
+	}
+	err = i.saveManifest(ctx, &Manifest{
+		MD5:        md5,
+		Source:     source(path),
+		Chunks:     ids,
+		Size:       info.Size(),
+		ModTime:    info.ModTime().Unix(),
+		Collection: collection,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncDryRun(t *testing.T) {
+	mod := time.Now().Add(-time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name        string
+		setup       func(t *testing.T, i *IRVector, dir string)
+		wantAdded   []string
+		wantChanged []string
+		wantRemoved []string
+		unchanged   int
+		skipped     int
+	}{
+		{
+			name: "new file",
+			setup: func(t *testing.T, i *IRVector, dir string) {
+				writeFile(t, filepath.Join(dir, "a.md"), "a", mod)
+			},
+			wantAdded: []string{"a.md"},
+		},
+		{
+			name: "unchanged file",
+			setup: func(t *testing.T, i *IRVector, dir string) {
+				writeFile(t, filepath.Join(dir, "a.md"), "a", mod)
+				indexed(t, i, filepath.Join(dir, "a.md"), DefaultCollection, "a1")
+			},
+			unchanged: 1,
+		},
+		{
+			name: "touched but same content",
+			setup: func(t *testing.T, i *IRVector, dir string) {
+				writeFile(t, filepath.Join(dir, "a.md"), "a", mod)
+				indexed(t, i, filepath.Join(dir, "a.md"), DefaultCollection, "a1")
+				writeFile(t, filepath.Join(dir, "a.md"), "a", mod.Add(time.Minute))
+			},
+			unchanged: 1,
+		},
+		{
+			name: "changed content",
+			setup: func(t *testing.T, i *IRVector, dir string) {
+				writeFile(t, filepath.Join(dir, "a.md"), "a", mod)
+				indexed(t, i, filepath.Join(dir, "a.md"), DefaultCollection, "a1")
+				writeFile(t, filepath.Join(dir, "a.md"), "changed", mod.Add(time.Minute))
+			},
+			wantChanged: []string{"a.md"},
+		},
+		{
+			name: "moved to another collection",
+			setup: func(t *testing.T, i *IRVector, dir string) {
+				writeFile(t, filepath.Join(dir, CollectionsDir, "hr", "a.md"), "a", mod)
+				indexed(t, i, filepath.Join(dir, CollectionsDir, "hr", "a.md"), DefaultCollection, "a1")
+			},
+			wantChanged: []string{filepath.Join(CollectionsDir, "hr", "a.md")},
+		},
+		{
+			name: "removed file",
+			setup: func(t *testing.T, i *IRVector, dir string) {
+				writeFile(t, filepath.Join(dir, "a.md"), "a", mod)
+				indexed(t, i, filepath.Join(dir, "a.md"), DefaultCollection, "a1")
+				os.Remove(filepath.Join(dir, "a.md"))
+			},
+			wantRemoved: []string{"a.md"},
+		},
+		{
+			name: "file outside the directory is kept",
+			setup: func(t *testing.T, i *IRVector, dir string) {
+				other := filepath.Join(filepath.Dir(dir), "other", "a.md")
+				writeFile(t, other, "a", mod)
+				indexed(t, i, other, DefaultCollection, "a1")
+				os.Remove(other)
+			},
+		},
+		{
+			name: "unsupported file",
+			setup: func(t *testing.T, i *IRVector, dir string) {
+				writeFile(t, filepath.Join(dir, "a.bin"), "a", mod)
+			},
+			skipped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newTestVector(t)
+			dir := filepath.Join(t.TempDir(), "knowdb")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			tt.setup(t, i, dir)
+			report, err := i.Sync(context.Background(), dir, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(report.Failed) > 0 {
+				t.Fatalf("failed: %v", report.Failed)
+			}
+			checkPaths(t, "added", dir, report.Added, tt.wantAdded)
+			checkPaths(t, "changed", dir, report.Changed, tt.wantChanged)
+			checkPaths(t, "removed", dir, report.Removed, tt.wantRemoved)
+			if report.Unchanged != tt.unchanged {
+				t.Errorf("unchanged = %d, want %d", report.Unchanged, tt.unchanged)
+			}
+			if report.Skipped != tt.skipped {
+				t.Errorf("skipped = %d, want %d", report.Skipped, tt.skipped)
+			}
+		})
+	}
+}
+
+// newIndexingVector 创建使用本地存储的向量数据库,索引流程为每个文件写入一个分片,不调用嵌入模型;返回索引次数
+func newIndexingVector(t *testing.T) (*IRVector, *int) {
+	t.Helper()
+	i := newTestVector(t)
+	i.emb.Store(&EmbeddingInfo{Dimension: 4})
+	n := 0
+	r, err := compose.NewChain[document.Source, []string]().
+		AppendLambda(compose.InvokableLambda(func(ctx context.Context, src document.Source) ([]string, error) {
+			n++
+			id := fmt.Sprintf("c%d", n)
+			err := i.store.Put(ctx, []*Chunk{{ID: id, Content: id, MetaData: map[string]any{file.MetaKeySource: src.URI}}})
+			return []string{id}, err
+		})).
+		Compile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.r = r
+	return i, &n
+}
+
+// syncDir 同步目录并检查没有失败的文件
+func syncDir(t *testing.T, i *IRVector, dir string) *SyncReport {
+	t.Helper()
+	report, err := i.Sync(context.Background(), dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Failed) > 0 {
+		t.Fatalf("failed: %v", report.Failed)
+	}
+	return report
+}
+
+func TestSyncRemove(t *testing.T) {
+	ctx := context.Background()
+	mod := time.Now().Add(-time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name       string
+		copies     []string // 与a.md内容相同的文件
+		wantChunks int
+	}{
+		{"remove chunks and manifest", nil, 0},
+		{"keep content shared by a copy", []string{"b.md"}, 1},
+		{"keep content shared by a copy in a subdirectory", []string{filepath.Join("sub", "b.md")}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, _ := newIndexingVector(t)
+			dir := filepath.Join(t.TempDir(), "knowdb")
+			removed := filepath.Join(dir, "a.md")
+			writeFile(t, removed, "a", mod)
+			for _, v := range tt.copies {
+				writeFile(t, filepath.Join(dir, v), "a", mod)
+			}
+			syncDir(t, i, dir)
+			m, err := i.ManifestOf(ctx, removed)
+			if err != nil || m == nil {
+				t.Fatalf("manifest of %s = %v, %v", removed, m, err)
+			}
+			os.Remove(removed)
+			report := syncDir(t, i, dir)
+			checkPaths(t, "removed", dir, report.Removed, []string{"a.md"})
+			if m, _ := i.ManifestOf(ctx, removed); m != nil {
+				t.Errorf("source %s is still mapped", removed)
+			}
+			kept, err := i.Manifest(ctx, m.MD5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (kept != nil) != (len(tt.copies) > 0) {
+				t.Errorf("manifest kept = %v, want %v", kept != nil, len(tt.copies) > 0)
+			}
+			for _, v := range tt.copies {
+				if c, _ := i.ManifestOf(ctx, filepath.Join(dir, v)); c == nil {
+					t.Errorf("copy %s is no longer indexed", v)
+				}
+			}
+			chunks, err := i.store.Chunks(ctx, m.Chunks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(chunks) != tt.wantChunks {
+				t.Errorf("chunks = %d, want %d", len(chunks), tt.wantChunks)
+			}
+		})
+	}
+}
+
+func TestSyncCopies(t *testing.T) {
+	ctx := context.Background()
+	mod := time.Now().Add(-time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name       string
+		change     func(t *testing.T, dir string) // 首次同步后的文件变化
+		wantIndex  int                            // 变化后直到没有变化为止的索引次数
+		wantSyncs  int                            // 变化后直到没有变化为止的同步次数
+		wantSource []string                       // 最终已索引的文件
+	}{
+		{
+			name:       "unchanged copies",
+			change:     func(t *testing.T, dir string) {},
+			wantSyncs:  1,
+			wantSource: []string{"a.md", "b.md"},
+		},
+		{
+			name: "touched copy",
+			change: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "b.md"), "a", mod.Add(time.Minute))
+			},
+			wantSyncs:  1,
+			wantSource: []string{"a.md", "b.md"},
+		},
+		{
+			name: "original moved",
+			change: func(t *testing.T, dir string) {
+				os.Rename(filepath.Join(dir, "a.md"), filepath.Join(dir, "c.md"))
+			},
+			// 移动后的文件重新索引,副本仍共用其内容
+			wantIndex:  1,
+			wantSyncs:  2,
+			wantSource: []string{"b.md", "c.md"},
+		},
+		{
+			name: "original changed",
+			change: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "a.md"), "changed", mod.Add(time.Minute))
+			},
+			// 原文件重新索引,共用旧内容的副本在下次同步时重新索引
+			wantIndex:  2,
+			wantSyncs:  3,
+			wantSource: []string{"a.md", "b.md"},
+		},
+		{
+			name: "copy changed",
+			change: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "b.md"), "changed", mod.Add(time.Minute))
+			},
+			wantIndex:  1,
+			wantSyncs:  2,
+			wantSource: []string{"a.md", "b.md"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, n := newIndexingVector(t)
+			dir := filepath.Join(t.TempDir(), "knowdb")
+			writeFile(t, filepath.Join(dir, "a.md"), "a", mod)
+			writeFile(t, filepath.Join(dir, "b.md"), "a", mod)
+			report := syncDir(t, i, dir)
+			checkPaths(t, "added", dir, report.Added, []string{"a.md", "b.md"})
+			if *n != 1 {
+				t.Fatalf("indexed %d times, want 1", *n)
+			}
+			tt.change(t, dir)
+			*n = 0
+			syncs := 0
+			for ; syncs < 5; syncs++ {
+				report = syncDir(t, i, dir)
+				if len(report.Added)+len(report.Changed)+len(report.Removed) == 0 {
+					break
+				}
+			}
+			if syncs+1 != tt.wantSyncs {
+				t.Errorf("synced %d times until no change, want %d", syncs+1, tt.wantSyncs)
+			}
+			if *n != tt.wantIndex {
+				t.Errorf("indexed %d times, want %d", *n, tt.wantIndex)
+			}
+			sources, err := i.Sources(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(sources))
+			for src, m := range sources {
+				got = append(got, src)
+				md5, _ := utils.CalculateFileMD5(src)
+				if md5 != m.MD5 {
+					t.Errorf("%s is mapped to %s, content is %s", src, m.MD5, md5)
+				}
+				if chunks, _ := i.store.Chunks(ctx, m.Chunks); len(chunks) != len(m.Chunks) {
+					t.Errorf("%s has %d of %d chunks", src, len(chunks), len(m.Chunks))
+				}
+			}
+			checkPaths(t, "sources", dir, got, tt.wantSource)
+		})
+	}
+}
+
+// checkPaths 比较同步结果中的文件,want为相对目录的路径
+func checkPaths(t *testing.T, kind, dir string, got, want []string) {
+	t.Helper()
+	exp := make([]string, 0, len(want))
+	for _, v := range want {
+		exp = append(exp, filepath.Join(source(dir), v))
+	}
+	sort.Strings(got)
+	sort.Strings(exp)
+	if len(got) != len(exp) {
+		t.Errorf("%s = %v, want %v", kind, got, exp)
+		return
+	}
+	for k := range got {
+		if got[k] != exp[k] {
+			t.Errorf("%s = %v, want %v", kind, got, exp)
+			return
+		}
+	}
+}
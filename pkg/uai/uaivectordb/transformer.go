@@ -2,11 +2,16 @@ package uaivectordb
 
 import (
 	"context"
+	"unicode/utf8"
 
 	"github.com/cloudwego/eino-ext/components/document/transformer/splitter/markdown"
+	"github.com/cloudwego/eino-ext/components/document/transformer/splitter/recursive"
 	"github.com/cloudwego/eino/components/document"
 )
 
+// headerKeys markdown各级标题在分片元数据中的键,由一级到四级
+var headerKeys = []string{"title", "chapter", "section", "subsection"}
+
 // newDocumentTransformer component initialization function of node 'MarkdownSplitter' in graph 'VectorDb'
 func newDocumentTransformer(ctx context.Context) (tfr document.Transformer, err error) {
 	// TODO Modify component configuration here.
@@ -25,3 +30,19 @@ func newDocumentTransformer(ctx context.Context) (tfr document.Transformer, err
 	}
 	return tfr, nil
 }
+
+// newRecursiveSplitter component initialization function of node 'RecursiveSplitter' in graph 'VectorDb'
+// 非markdown文本按段落、句子递归分片,长度按字符计,兼顾中英文标点
+func newRecursiveSplitter(ctx context.Context, opt *Option) (tfr document.Transformer, err error) {
+	so := opt.Splitter
+	if so == nil {
+		so = NewDefaultSplitterOption()
+	}
+	return recursive.NewSplitter(ctx, &recursive.Config{
+		ChunkSize:   so.ChunkSize,
+		OverlapSize: so.OverlapSize,
+		Separators:  []string{"\n\n", "\n", "。", "！", "？", "；", ". ", "! ", "? ", "; ", " "},
+		LenFunc:     utf8.RuneCountInString,
+		KeepType:    recursive.KeepTypeEnd,
+	})
+}
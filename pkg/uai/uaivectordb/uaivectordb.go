@@ -7,34 +7,61 @@ import (
 	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/compose"
-	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 	"io/fs"
 	"path/filepath"
-	"strings"
+	"sync/atomic"
+	"time"
 )
 
 func New(ctx context.Context, option *Option, log *zap.SugaredLogger) If {
 	irv := &IRVector{
 		log: log,
 		opt: option,
-		rdb: redis.NewClient(&redis.Options{
-			Addr:     option.RedisStack.Addr,
-			Protocol: option.RedisStack.Protocol,
-			DB:       option.RedisStack.Db,
-		}),
 	}
-
 	eb, err := newEmbedding(ctx, option)
 	if err != nil {
-		irv.log.Fatalw("Failed to create embedding", "error", err)
+		irv.log.Fatalw("Failed to create embedding", "provider", option.IRVModel.Provider, "error", err)
 	}
 	irv.eb = eb
+	// 探测嵌入模型的向量维度,配置了维度时须与探测结果一致;探测失败时按配置的维度降级启动,在后台重试探测
+	configured := 0
+	if option.RedisStack != nil {
+		configured = option.RedisStack.Dimension
+	}
+	dim, err := probeDimension(ctx, eb)
+	probed := err == nil
+	if !probed {
+		dim = configured
+		irv.log.Errorw("Failed to probe embedding dimension, starting degraded", "model", option.IRVModel.Model, "dimension", dim, "error", err)
+	} else if configured > 0 && configured != dim {
+		irv.log.Fatalw("Configured dimension does not match embedding model", "model", option.IRVModel.Model, "configured", configured, "probed", dim)
+	}
+	irv.emb.Store(&EmbeddingInfo{
+		Provider:  EmbeddingProvider(option.IRVModel.Provider),
+		Model:     option.IRVModel.Model,
+		Dimension: dim,
+	})
+	if probed {
+		irv.log.Infow("Detected embedding dimension", "provider", EmbeddingProvider(option.IRVModel.Provider), "model", option.IRVModel.Model, "dimension", dim)
+	}
+
+	store, err := newStore(ctx, option, dim, log)
+	if err != nil {
+		irv.log.Fatalw("Failed to create vector store", "store", option.Store, "error", err)
+	}
+	irv.store = store
+	// 已有索引由其他嵌入模型建立时拒绝启动,或按配置重建索引;降级启动时在探测成功后校验
+	if probed {
+		if err = irv.checkEmbedding(ctx); err != nil {
+			irv.log.Fatalw("Failed to check embedding model of vector index", "error", err)
+		}
+	}
 	irv.ldr, err = newLoader(ctx)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to create loader: %v", err))
 	}
-	irv.idr, err = newIndexer(ctx, option, irv.rdb)
+	irv.idr, err = newIndexer(ctx, option, irv.store, irv.eb)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to create indexer: %v", err))
 	}
@@ -42,11 +69,31 @@ func New(ctx context.Context, option *Option, log *zap.SugaredLogger) If {
 	if err != nil {
 		panic(fmt.Sprintf("Failed to create document transformer: %v", err))
 	}
-	r, err := irv.buildIRVector(ctx, irv.opt, irv.ldr, irv.idr, irv.tfr)
+	irv.rtfr, err = newRecursiveSplitter(ctx, option)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create recursive splitter: %v", err))
+	}
+	r, err := irv.buildIRVector(ctx, irv.opt, irv.ldr, irv.idr, irv.tfr, irv.rtfr)
 	if err != nil {
 		irv.log.Fatalw("Failed to build VectorDb", "error", err)
 	}
 	irv.r = r
+	// 为清单出现之前索引的分片建立清单
+	if err = irv.migrateManifest(ctx); err != nil {
+		irv.log.Errorw("Failed to migrate chunk manifests", "error", err)
+	}
+	// 为旧分片补充集合等TAG字段
+	if err = irv.store.Batch(ctx, func() error { return irv.migrateTags(ctx) }); err != nil {
+		irv.log.Errorw("Failed to migrate chunk tags", "error", err)
+	}
+	// 清理上次中断的索引已写入的分片
+	if err = irv.recoverPending(ctx); err != nil {
+		irv.log.Errorw("Failed to recover interrupted indexing", "error", err)
+	}
+	if !probed {
+		go irv.probeLater(ctx)
+		return irv
+	}
 	if option.LoadMdFilePloy.IsLoadMdFiles {
 		err = irv.BuildDir(ctx, option.LoadMdFilePloy.Dir)
 		if err != nil {
@@ -56,25 +103,69 @@ func New(ctx context.Context, option *Option, log *zap.SugaredLogger) If {
 	return irv
 }
 
+// probeRetryInterval 启动时探测向量维度失败后的重试间隔
+const probeRetryInterval = 30 * time.Second
+
+// probeLater 降级启动后定时重试探测向量维度,成功后校验已有索引并按配置加载本地知识文档
+func (i *IRVector) probeLater(ctx context.Context) {
+	ticker := time.NewTicker(probeRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		dim, err := probeDimension(ctx, i.eb)
+		if err != nil {
+			i.log.Warnw("Failed to probe embedding dimension, retrying later", "model", i.opt.IRVModel.Model, "error", err)
+			continue
+		}
+		cur := i.emb.Load()
+		if cur.Dimension > 0 && cur.Dimension != dim {
+			i.log.Errorw("Configured dimension does not match embedding model", "model", cur.Model, "configured", cur.Dimension, "probed", dim)
+			return
+		}
+		i.emb.Store(&EmbeddingInfo{Provider: cur.Provider, Model: cur.Model, Dimension: dim})
+		i.log.Infow("Detected embedding dimension", "provider", cur.Provider, "model", cur.Model, "dimension", dim)
+		if err = i.checkEmbedding(ctx); err != nil {
+			i.log.Errorw("Failed to check embedding model of vector index", "error", err)
+			return
+		}
+		if i.opt.LoadMdFilePloy.IsLoadMdFiles {
+			if err = i.BuildDir(ctx, i.opt.LoadMdFilePloy.Dir); err != nil {
+				i.log.Errorw("Failed to build local knowledge db", "error", err)
+			}
+		}
+		return
+	}
+}
+
 type IRVector struct {
-	log *zap.SugaredLogger
-	opt *Option
-	rdb *redis.Client
-	eb  embedding.Embedder
-	ldr document.Loader
-	idr indexer.Indexer
-	tfr document.Transformer
-	r   compose.Runnable[document.Source, []string]
+	log   *zap.SugaredLogger
+	opt   *Option
+	store Store // 向量存储
+	eb    embedding.Embedder
+	emb   atomic.Pointer[EmbeddingInfo] // 嵌入模型与探测到的向量维度,降级启动时在探测成功后更新
+	ldr   document.Loader
+	idr   indexer.Indexer
+	tfr   document.Transformer
+	rtfr  document.Transformer // 非markdown文本的递归分片
+	r     compose.Runnable[document.Source, []string]
 }
 
-func (i *IRVector) Redis() *redis.Client {
-	return i.rdb
+func (i *IRVector) Store() Store {
+	return i.store
 }
 
 func (i *IRVector) Embedder() embedding.Embedder {
 	return i.eb
 }
 
+func (i *IRVector) Embedding() *EmbeddingInfo {
+	return i.emb.Load()
+}
+
 func (i *IRVector) Runnable() compose.Runnable[document.Source, []string] {
 	return i.r
 }
@@ -91,20 +182,20 @@ func (i *IRVector) BuildDir(ctx context.Context, dir string) (err error) {
 			return nil
 		}
 
-		if !strings.HasSuffix(path, ".md") {
-			i.log.Infow("Skipping non - markdown file", "file", path)
+		if !supported(path) {
+			i.log.Infow("Skipping unsupported file", "file", path)
 			return nil
 		}
 
 		i.log.Infow("Starting to index file", "file", path)
 
-		ids, err := i.r.Invoke(ctx, document.Source{URI: path})
+		n, err := i.IndexFile(ctx, path, DefaultCollection)
 		if err != nil {
 			i.log.Errorw("Failed to invoke index graph", "error", err)
-			return fmt.Errorf("invoke index graph failed: %w", err)
+			return err
 		}
 
-		i.log.Infow("Finished indexing file", "file", path, "number of parts", len(ids))
+		i.log.Infow("Finished indexing file", "file", path, "number of parts", n)
 
 		return nil
 	})
@@ -117,16 +208,16 @@ func (i *IRVector) BuildDir(ctx context.Context, dir string) (err error) {
 }
 func (i *IRVector) BuildFile(ctx context.Context, filepath string) (err error) {
 	i.log.Infow("Starting to build local knowledge db", "file path", filepath)
-	if !strings.HasSuffix(filepath, ".md") {
-		i.log.Infow("Skipping non - markdown file", "file", filepath)
+	if !supported(filepath) {
+		i.log.Infow("Skipping unsupported file", "file", filepath)
 		return nil
 	}
-	ids, err := i.r.Invoke(ctx, document.Source{URI: filepath})
+	n, err := i.IndexFile(ctx, filepath, DefaultCollection)
 	if err != nil {
 		i.log.Errorw("Failed to invoke index graph", "error", err)
-		return fmt.Errorf("invoke index graph failed: %w", err)
+		return err
 	}
 
-	i.log.Infow("Finished indexing file", "file", filepath, "number of parts", len(ids))
+	i.log.Infow("Finished indexing file", "file", filepath, "number of parts", n)
 	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/fsnotify/fsnotify"
 	"github.com/gocarina/gocsv"
 	"github.com/spf13/viper"
+	"io"
 	"os"
 	"path/filepath"
 )
@@ -124,3 +125,15 @@ func (s *CsvVp) Write() error {
 	return nil
 
 }
+
+// ReadRows 逐行读取CSV,返回表头与数据行,每行按表头的列序排列
+func ReadRows(in io.Reader) (header []string, rows [][]string, err error) {
+	records, err := gocsv.DefaultCSVReader(in).ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析 CSV 失败: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil, nil
+	}
+	return records[0], records[1:], nil
+}
@@ -2,6 +2,7 @@ package ujwt
 
 type If interface {
 	GenToken(userName, userId string, effectiveDuration int) (string, error)
+	GenTokenWithOption(userName, userId, option string, effectiveDuration int) (string, error)
 	VerifyToken(token string) (*UClaims, error)
 	GetSession(userId, sessionId string) (*string, error)
 	VerifySession(sessionID string) (*UClaims, error)
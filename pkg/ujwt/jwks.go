@@ -0,0 +1,205 @@
+package ujwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWKS 远程JSON Web Key Set,按kid选择校验签名的公钥,定时刷新,遇到未知kid时立即刷新
+type JWKS struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	mu      sync.RWMutex
+	keys    map[string]any
+	fetched time.Time
+}
+
+// NewJWKS 创建JWKS
+// 入参：url string JWKS地址
+// 入参：refresh time.Duration 刷新间隔,小于等于0时为1小时
+// 返回：*JWKS
+func NewJWKS(url string, refresh time.Duration) *JWKS {
+	if refresh <= 0 {
+		refresh = time.Hour
+	}
+	return &JWKS{
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		keys:    make(map[string]any),
+	}
+}
+
+// DiscoverJWKS 从OIDC身份提供方的 /.well-known/openid-configuration 获取JWKS地址
+func DiscoverJWKS(ctx context.Context, issuer string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("获取OIDC配置失败:%w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("获取OIDC配置失败:%s", resp.Status)
+	}
+	conf := struct {
+		JwksURI string `json:"jwks_uri"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(&conf); err != nil {
+		return "", fmt.Errorf("解析OIDC配置失败:%w", err)
+	}
+	if conf.JwksURI == "" {
+		return "", errors.New("OIDC配置缺少jwks_uri")
+	}
+	return conf.JwksURI, nil
+}
+
+// Keyfunc 供jwt.Parse使用,按令牌头的kid返回公钥
+func (j *JWKS) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := j.key(kid, false); ok {
+		return key, nil
+	}
+	if err := j.fetch(context.Background()); err != nil {
+		// 身份提供方不可用时继续使用已获取的公钥
+		if key, ok := j.key(kid, true); ok {
+			return key, nil
+		}
+		return nil, err
+	}
+	if key, ok := j.key(kid, false); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("JWKS中没有令牌的公钥,kid:%s", kid)
+}
+
+// key 从缓存获取公钥,stale为false时超过刷新间隔视为不存在;kid为空且只有一个公钥时返回该公钥
+func (j *JWKS) key(kid string, stale bool) (any, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if !stale && time.Since(j.fetched) > j.refresh {
+		return nil, false
+	}
+	if kid == "" && len(j.keys) == 1 {
+		for _, v := range j.keys {
+			return v, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetch 获取JWKS,两次获取至少间隔10秒,避免伪造的kid频繁请求身份提供方
+func (j *JWKS) fetch(ctx context.Context) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if time.Since(j.fetched) < 10*time.Second {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("获取JWKS失败:%w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("获取JWKS失败:%s", resp.Status)
+	}
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("解析JWKS失败:%w", err)
+	}
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// 不支持的密钥类型不影响其他公钥
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	j.keys = keys
+	j.fetched = time.Now()
+	return nil
+}
+
+func (k *jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("不支持的椭圆曲线:%s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("不支持的密钥类型:%s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -39,6 +39,16 @@ type UClaims struct {
 // 入参：effectiveDuration int token有效时长(分钟)
 // 返回：string token
 func (j *UJwt) GenToken(userName, userId string, effectiveDuration int) (string, error) {
+	return j.GenTokenWithOption(userName, userId, "", effectiveDuration)
+}
+
+// GenTokenWithOption 生成携带附加信息的token
+// 入参：userName string 用户名
+// 入参：userId string 用户id
+// 入参：option string 附加信息,校验后由UClaims.Option返回
+// 入参：effectiveDuration int token有效时长(分钟)
+// 返回：string token
+func (j *UJwt) GenTokenWithOption(userName, userId, option string, effectiveDuration int) (string, error) {
 	if strings.TrimSpace(userName) == "" || strings.TrimSpace(userId) == "" {
 		return "", errors.New("用户名和用户Id不能为空")
 	}
@@ -50,6 +60,7 @@ func (j *UJwt) GenToken(userName, userId string, effectiveDuration int) (string,
 	claims := &UClaims{
 		UserName: userName,
 		UserId:   userId,
+		Option:   option,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    j.Name,
 			Subject:   userId,
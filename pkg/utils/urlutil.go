@@ -0,0 +1,13 @@
+package utils
+
+import "strings"
+
+// WithVersionPath API链接的最后一段不是版本路径(如/v1、/v4)时追加/v1,兼容只配置了服务地址的OpenAI接口
+func WithVersionPath(baseURL string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	seg := baseURL[strings.LastIndex(baseURL, "/")+1:]
+	if len(seg) > 1 && seg[0] == 'v' && strings.Trim(seg[1:], "0123456789") == "" {
+		return baseURL
+	}
+	return baseURL + "/v1"
+}
@@ -0,0 +1,27 @@
+package utils
+
+import "testing"
+
+func TestWithVersionPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		want    string
+	}{
+		{"host only", "http://127.0.0.1:11434", "http://127.0.0.1:11434/v1"},
+		{"trailing slash", "http://127.0.0.1:11434/", "http://127.0.0.1:11434/v1"},
+		{"with v1", "https://api.openai.com/v1", "https://api.openai.com/v1"},
+		{"with v1 and slash", "https://api.openai.com/v1/", "https://api.openai.com/v1"},
+		{"with v4", "https://open.bigmodel.cn/api/paas/v4", "https://open.bigmodel.cn/api/paas/v4"},
+		{"path without version", "https://example.com/openai", "https://example.com/openai/v1"},
+		{"v without digits", "https://example.com/v", "https://example.com/v/v1"},
+		{"version-like word", "https://example.com/vendor", "https://example.com/vendor/v1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WithVersionPath(tt.baseURL); got != tt.want {
+				t.Errorf("WithVersionPath(%q) = %q, want %q", tt.baseURL, got, tt.want)
+			}
+		})
+	}
+}